@@ -0,0 +1,86 @@
+package main
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/ExploratoryEngineering/params"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/eesrc/geo/pkg/auth"
+	"github.com/eesrc/geo/pkg/auth/providers"
+	"github.com/eesrc/geo/pkg/restapi"
+	"github.com/eesrc/geo/pkg/store"
+	"github.com/eesrc/geo/pkg/sub/manager"
+)
+
+// parameters is the configuration of the geo server. Every parameter can be set either as a
+// command line flag or as an environment variable, ie --store-db-driver or STORE_DB_DRIVER.
+// Without any parameters the server runs with an in-memory SQLite database.
+type parameters struct {
+	Store   store.StorageParams
+	Session auth.AuthenticatorConfig
+	NATS    manager.NATSManagerConfig
+	HTTP    restapi.RestAPIParams
+	Github  providers.GithubConfig
+	Connect providers.ConnectConfig
+}
+
+func main() {
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		migrate(os.Args[2:])
+		return
+	}
+
+	var config parameters
+	if err := params.NewEnvFlag(&config, os.Args[1:]); err != nil {
+		log.Fatalf("Invalid parameters: %v", err)
+	}
+
+	geoStore, err := store.NewWithParams(config.Store)
+	if err != nil {
+		log.Fatalf("Failed to create store: %v", err)
+	}
+
+	authenticator, err := auth.NewAuthenticator(config.Session)
+	if err != nil {
+		log.Fatalf("Failed to create authenticator: %v", err)
+	}
+	authenticator.AddProvider(providers.NewGitHubProvider(config.Github))
+	authenticator.AddProvider(providers.NewConnectProvider(config.Connect))
+
+	natsManager := manager.NewNatsManager(config.NATS)
+
+	go func() {
+		if err := natsManager.Load(geoStore); err != nil {
+			// We consider this fatal as the main purpose of the server is to handle subscriptions
+			log.Fatalf("Failed to load geo subscriptions: %v", err)
+		}
+	}()
+
+	server := restapi.New(config.HTTP, natsManager, geoStore, authenticator)
+	if err := server.Start(); err != nil {
+		log.Fatalf("Failed to start REST API: %v", err)
+	}
+
+	// Wait for the server to be told to stop, then stop accepting requests before the
+	// subscriptions are shut down and the store is closed
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGTERM, os.Interrupt)
+	sig := <-signals
+
+	log.Infof("Received %v, shutting down", sig)
+
+	if err := server.Stop(); err != nil {
+		log.WithError(err).Error("Failed to stop REST API")
+	}
+
+	natsManager.Shutdown()
+
+	if err := geoStore.Close(); err != nil {
+		log.WithError(err).Error("Failed to close store")
+	}
+
+	log.Info("Shutdown complete")
+}
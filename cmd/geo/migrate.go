@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/ExploratoryEngineering/params"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/eesrc/geo/pkg/store"
+	"github.com/eesrc/geo/pkg/store/migration"
+)
+
+// migrateParameters is the configuration of the migrate command
+type migrateParameters struct {
+	Store store.StorageParams
+}
+
+// migrate runs the migrate command. "geo migrate status" lists the schema migrations and
+// whether they are applied, "geo migrate up" applies the pending migrations. The store flags
+// follow the action, ie "geo migrate up --store-db-driver=postgres".
+func migrate(args []string) {
+	action := "status"
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		action, args = args[0], args[1:]
+	}
+
+	var config migrateParameters
+	if err := params.NewEnvFlag(&config, args); err != nil {
+		log.Fatalf("Invalid parameters: %v", err)
+	}
+
+	migrator, err := store.NewMigrator(config.Store)
+	if err != nil {
+		log.Fatalf("Failed to open store: %v", err)
+	}
+
+	// The store is closed before exiting on errors, as deferred calls don't run on log.Fatal
+	err = runMigrateAction(migrator, action)
+	migrator.Close()
+
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// runMigrateAction runs the action of the migrate command with the migrator
+func runMigrateAction(migrator *migration.Migrator, action string) error {
+	switch action {
+	case "status":
+		statuses, err := migrator.Status()
+		if err != nil {
+			return fmt.Errorf("Failed to read migration status: %v", err)
+		}
+
+		writer := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(writer, "VERSION\tDESCRIPTION\tAPPLIED")
+		for _, status := range statuses {
+			applied := "pending"
+			if status.Applied {
+				applied = status.AppliedAt.Format(time.RFC3339)
+			} else if !status.Enabled {
+				applied = fmt.Sprintf("skipped (needs %s)", status.Option)
+			}
+			fmt.Fprintf(writer, "%d\t%s\t%s\n", status.Version, status.Description, applied)
+		}
+		writer.Flush()
+
+	case "up":
+		applied, err := migrator.Up()
+		if err != nil {
+			return fmt.Errorf("Failed to migrate schema after %d migrations: %v", applied, err)
+		}
+		log.Infof("Applied %d migrations", applied)
+
+	default:
+		return fmt.Errorf("Unknown migrate action '%s', use 'status' or 'up'", action)
+	}
+
+	return nil
+}
@@ -130,6 +130,18 @@ func main() {
 			circle := shape
 			log.Printf(" - Circle '%s', r = %f", circle.GetName(), circle.Radius)
 
+		case *geometry.MultiPolygon:
+			multiPolygon := shape
+			log.Printf(" - MultiPolygon '%s', %d polygons", multiPolygon.GetName(), len(multiPolygon.Polygons))
+
+		case *geometry.Corridor:
+			corridor := shape
+			log.Printf(" - Corridor '%s', %d points, w = %f", corridor.GetName(), len(corridor.Line), corridor.Width)
+
+		case *geometry.Tripwire:
+			tripwire := shape
+			log.Printf(" - Tripwire '%s', %d points", tripwire.GetName(), len(tripwire.Line))
+
 		default:
 			log.Printf("Warning: unknown shape: %+v", shape)
 		}
@@ -5,6 +5,7 @@ go 1.14
 require (
 	github.com/ExploratoryEngineering/params v1.0.0
 	github.com/dhconnelly/rtreego v1.0.0
+	github.com/eclipse/paho.mqtt.golang v1.2.0
 	github.com/fsnotify/fsnotify v1.4.9
 	github.com/google/uuid v1.1.1
 	github.com/gorilla/handlers v1.4.2
@@ -96,6 +96,14 @@ type Position struct {
 	Precision float64
 }
 
+// PositionCursor points at a position in a listing ordered by timestamp and ID, newest first.
+// It's used for keyset pagination where the next page starts right after the cursor, which
+// avoids scanning past every skipped position like an offset does.
+type PositionCursor struct {
+	Timestamp int64
+	ID        int64
+}
+
 // TrackerMovement contains information where the position was last
 type TrackerMovement struct {
 	TrackerID      int64
@@ -103,6 +111,49 @@ type TrackerMovement struct {
 	ShapeID        int64
 	PositionID     int64
 	Movements      MovementList
+	// Entered is the timestamp in nanoseconds of the position where the tracker entered the
+	// shape. It's 0 when the tracker is outside the shape
+	Entered int64
+	// Dwelled is set when the dwell trigger has fired since the tracker entered the shape
+	Dwelled bool
+	// Timestamp is the timestamp in nanoseconds of the position. It's only set when movements
+	// are listed, as it's stored with the position
+	Timestamp int64
+}
+
+// PositionMovement is a stored movement of a subscription along with the position which triggered it
+type PositionMovement struct {
+	ID       int64
+	Movement TrackerMovement
+	Position Position
+}
+
+// MovementFilter filters the movement history of a subscription. The tracker, shape and movement
+// filters are ignored when zero or empty. Since and Until are inclusive and in nanoseconds.
+type MovementFilter struct {
+	TrackerID int64
+	ShapeID   int64
+	Movement  string
+	Since     int64
+	Until     int64
+}
+
+// Delivery is a trigger event stored in the outbox of a subscription output along with
+// the status of its delivery
+type Delivery struct {
+	ID             int64
+	SubscriptionID int64
+	Output         string
+	Status         string
+	// Recipient is the recipient of the delivery for outputs delivering to several recipients, ie
+	// a phone number of the SMS output. It's empty when the delivery goes to the whole output
+	Recipient string
+	// Payload is the JSON encoded trigger event
+	Payload   []byte
+	Attempts  int64
+	LastError string
+	Created   time.Time
+	Updated   time.Time
 }
 
 // Subscription represents a subscription between a trackable entity and an output
@@ -122,16 +173,27 @@ type Subscription struct {
 	TrackableType string
 	// TrackableID is the ID of trackable within its domain, either tracker og collection
 	TrackableID int64
+	// DwellTime is how long a tracker must stay inside a shape before the dwell trigger fires
+	DwellTime time.Duration
+	// ConfirmPositions is the number of consecutive positions needed to confirm that a tracker
+	// has entered or exited a shape
+	ConfirmPositions int64
+	// ConfirmTime is the minimum time from the first to the last confirming position before a
+	// tracker has entered or exited a shape
+	ConfirmTime time.Duration
+	// BoundaryBuffer is the distance in metres a tracker must move past the edge of a shape to
+	// enter or exit it
+	BoundaryBuffer float64
 }
 
 // GeoSubscription represents an aggregated struct containing both the subscription details,
-// shape collection, subscription shapes and last tracker movements, all of which is needed to
-// activate a full subscription between a Subscription and a trackable.
+// shape collection and last tracker movements, all of which is needed to activate a full
+// subscription between a Subscription and a trackable. The shapes aren't included, as they're
+// indexed once per shape collection and shared between the subscriptions.
 type GeoSubscription struct {
 	Subscription     Subscription
 	ShapeCollection  ShapeCollection
 	TrackerMovements []TrackerMovement
-	Shapes           []Shape
 }
 
 // OutputConfig is a generic mapping of config for an output
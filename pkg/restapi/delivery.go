@@ -0,0 +1,198 @@
+package restapi
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/eesrc/geo/pkg/restapi/service"
+	"github.com/eesrc/geo/pkg/restapi/validation"
+	"github.com/eesrc/geo/pkg/sub"
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+)
+
+func (s *Server) listDeliveries(w http.ResponseWriter, r *http.Request) {
+	log := s.RequestLogger(r)
+
+	status, err := validation.GetDeliveryStatusFromQueryParams(r.URL.Query())
+	if err != nil {
+		handleError(err, w, log)
+		return
+	}
+
+	s.writeDeliveries(w, r, log, status)
+}
+
+func (s *Server) listDeadLetters(w http.ResponseWriter, r *http.Request) {
+	s.writeDeliveries(w, r, s.RequestLogger(r), sub.DeadLetter)
+}
+
+// writeDeliveries writes the deliveries of the subscription in the request with the given status
+func (s *Server) writeDeliveries(w http.ResponseWriter, r *http.Request, log *logrus.Entry, status sub.DeliveryStatus) {
+	userProfile := s.UserFromRequest(r)
+
+	subscription, err := validation.GetSubscriptionFromHandlerParams(mux.Vars(r), userProfile.ID, s.store)
+	if err != nil {
+		handleError(err, w, log)
+		return
+	}
+
+	filterParams, err := validation.NewFilterParamsFromQueryParams(r.URL.Query())
+	if err != nil {
+		handleError(err, w, log)
+		return
+	}
+
+	deliveries, err := validation.ListDeliveriesBySubscriptionID(subscription.ID, status, filterParams, s.store)
+	if err != nil {
+		handleError(err, w, log)
+		return
+	}
+
+	jsonBytes, err := json.Marshal(deliveries)
+	if err != nil {
+		validation.NewErrorResponse(http.StatusInternalServerError).WriteHTTPError(w)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(jsonBytes)
+}
+
+func (s *Server) getDelivery(w http.ResponseWriter, r *http.Request) {
+	log := s.RequestLogger(r)
+	userProfile := s.UserFromRequest(r)
+
+	subscription, err := validation.GetSubscriptionFromHandlerParams(mux.Vars(r), userProfile.ID, s.store)
+	if err != nil {
+		handleError(err, w, log)
+		return
+	}
+
+	deliveryID, err := validation.GetDeliveryID(mux.Vars(r))
+	if err != nil {
+		handleError(err, w, log)
+		return
+	}
+
+	delivery, err := validation.GetDelivery(subscription.ID, deliveryID, s.store)
+	if err != nil {
+		handleError(err, w, log)
+		return
+	}
+
+	jsonBytes, err := delivery.MarshalJSON()
+	if err != nil {
+		validation.NewErrorResponse(http.StatusInternalServerError).WriteHTTPError(w)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(jsonBytes)
+}
+
+func (s *Server) resendDelivery(w http.ResponseWriter, r *http.Request) {
+	log := s.RequestLogger(r)
+	userProfile := s.UserFromRequest(r)
+
+	subscription, err := validation.GetSubscriptionFromHandlerParams(mux.Vars(r), userProfile.ID, s.store)
+	if err != nil {
+		handleError(err, w, log)
+		return
+	}
+
+	deliveryID, err := validation.GetDeliveryID(mux.Vars(r))
+	if err != nil {
+		handleError(err, w, log)
+		return
+	}
+
+	delivery, err := validation.GetDelivery(subscription.ID, deliveryID, s.store)
+	if err != nil {
+		handleError(err, w, log)
+		return
+	}
+
+	resentDelivery, err := s.resend(delivery)
+	if err != nil {
+		log.WithError(err).Warnf("Failed to re-send delivery %d for subscription %d", delivery.ID, subscription.ID)
+		writeResendError(w)
+		return
+	}
+
+	jsonBytes, err := resentDelivery.MarshalJSON()
+	if err != nil {
+		validation.NewErrorResponse(http.StatusInternalServerError).WriteHTTPError(w)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+	_, _ = w.Write(jsonBytes)
+}
+
+func (s *Server) resendDeadLetters(w http.ResponseWriter, r *http.Request) {
+	log := s.RequestLogger(r)
+	userProfile := s.UserFromRequest(r)
+
+	subscription, err := validation.GetSubscriptionFromHandlerParams(mux.Vars(r), userProfile.ID, s.store)
+	if err != nil {
+		handleError(err, w, log)
+		return
+	}
+
+	filterParams, err := validation.NewFilterParamsFromQueryParams(r.URL.Query())
+	if err != nil {
+		handleError(err, w, log)
+		return
+	}
+
+	deadLetters, err := validation.ListDeliveriesBySubscriptionID(subscription.ID, sub.DeadLetter, filterParams, s.store)
+	if err != nil {
+		handleError(err, w, log)
+		return
+	}
+
+	resentDeliveries := make([]*service.Delivery, 0, len(deadLetters))
+
+	for _, deadLetter := range deadLetters {
+		resentDelivery, err := s.resend(deadLetter)
+		if err != nil {
+			log.WithError(err).Warnf("Failed to re-send delivery %d for subscription %d", deadLetter.ID, subscription.ID)
+			writeResendError(w)
+			return
+		}
+
+		resentDeliveries = append(resentDeliveries, resentDelivery)
+	}
+
+	jsonBytes, err := json.Marshal(resentDeliveries)
+	if err != nil {
+		validation.NewErrorResponse(http.StatusInternalServerError).WriteHTTPError(w)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+	_, _ = w.Write(jsonBytes)
+}
+
+// resend re-sends the delivery through the running output of the subscription and returns the
+// delivery as it is stored after the attempt
+func (s *Server) resend(delivery *service.Delivery) (*service.Delivery, error) {
+	err := s.manager.Redeliver(delivery.SubscriptionID, delivery.ToModel())
+	if err != nil {
+		return delivery, err
+	}
+
+	return validation.GetDelivery(delivery.SubscriptionID, delivery.ID, s.store)
+}
+
+// writeResendError writes the error for deliveries which the subscription output can't re-send
+func writeResendError(w http.ResponseWriter) {
+	validation.NewErrorResponse(
+		http.StatusConflict,
+		validation.NewParameterErrorDetail(
+			"subscription.output",
+			"The output of the subscription isn't running or can't re-send deliveries",
+		),
+	).WriteHTTPError(w)
+}
@@ -0,0 +1,146 @@
+package restapi
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/eesrc/geo/pkg/restapi/service"
+	"github.com/eesrc/geo/pkg/restapi/validation"
+	"github.com/gorilla/mux"
+	geojson "github.com/paulmach/go.geojson"
+)
+
+// movementCSVHeader is the header row of the movement history as CSV. Timestamps are in milliseconds.
+var movementCSVHeader = []string{
+	"id", "subscriptionId", "trackerId", "shapeId", "movements", "entered", "dwelled",
+	"positionId", "timestamp", "lat", "lng", "alt", "heading", "speed", "precision",
+}
+
+func (s *Server) listMovements(w http.ResponseWriter, r *http.Request) {
+	log := s.RequestLogger(r)
+	userProfile := s.UserFromRequest(r)
+
+	subscription, err := validation.GetSubscriptionFromHandlerParams(mux.Vars(r), userProfile.ID, s.store)
+	if err != nil {
+		handleError(err, w, log)
+		return
+	}
+
+	filterParams, err := validation.NewFilterParamsFromQueryParams(r.URL.Query())
+	if err != nil {
+		handleError(err, w, log)
+		return
+	}
+
+	filter, err := validation.GetMovementFilterFromQueryParams(r.URL.Query())
+	if err != nil {
+		handleError(err, w, log)
+		return
+	}
+
+	format, err := validation.GetMovementFormatFromQueryParams(r.URL.Query())
+	if err != nil {
+		handleError(err, w, log)
+		return
+	}
+
+	movements, err := validation.ListMovementsBySubscriptionID(subscription.ID, filterParams, filter, s.store)
+	if err != nil {
+		handleError(err, w, log)
+		return
+	}
+
+	var body []byte
+	switch format {
+	case validation.MovementFormatCSV:
+		w.Header().Set("Content-Type", "text/csv")
+		body, err = movementsToCSV(movements)
+	case validation.MovementFormatGeoJSON:
+		w.Header().Set("Content-Type", "application/geo+json")
+		body, err = movementsToFeatureCollection(movements).MarshalJSON()
+	default:
+		body, err = json.Marshal(movements)
+	}
+
+	if err != nil {
+		w.Header().Set("Content-Type", "application/json")
+		validation.NewErrorResponse(http.StatusInternalServerError).WriteHTTPError(w)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(body)
+}
+
+// movementsToCSV returns the movements as CSV with one row per movement. The movement types of a row
+// are separated by a space.
+func movementsToCSV(movements []*service.Movement) ([]byte, error) {
+	var buffer bytes.Buffer
+	writer := csv.NewWriter(&buffer)
+
+	if err := writer.Write(movementCSVHeader); err != nil {
+		return nil, err
+	}
+
+	for _, movement := range movements {
+		position := movement.Position
+		err := writer.Write([]string{
+			strconv.FormatInt(movement.ID, 10),
+			strconv.FormatInt(movement.SubscriptionID, 10),
+			strconv.FormatInt(movement.TrackerID, 10),
+			strconv.FormatInt(movement.ShapeID, 10),
+			strings.Join(movement.Movements, " "),
+			strconv.FormatInt(movement.Entered, 10),
+			strconv.FormatBool(movement.Dwelled),
+			strconv.FormatInt(position.ID, 10),
+			strconv.FormatInt(*position.Timestamp, 10),
+			formatFloat(*position.Lat),
+			formatFloat(*position.Long),
+			formatFloat(*position.Alt),
+			formatFloat(*position.Heading),
+			formatFloat(*position.Speed),
+			formatFloat(*position.Precision),
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	writer.Flush()
+	return buffer.Bytes(), writer.Error()
+}
+
+// movementsToFeatureCollection returns the movements as a feature collection with a point feature at
+// the triggering position of each movement
+func movementsToFeatureCollection(movements []*service.Movement) *geojson.FeatureCollection {
+	featureCollection := geojson.NewFeatureCollection()
+
+	for _, movement := range movements {
+		position := movement.Position
+		feature := geojson.NewPointFeature([]float64{*position.Long, *position.Lat})
+		feature.ID = movement.ID
+		feature.SetProperty("subscriptionId", movement.SubscriptionID)
+		feature.SetProperty("trackerId", movement.TrackerID)
+		feature.SetProperty("shapeId", movement.ShapeID)
+		feature.SetProperty("movements", movement.Movements)
+		feature.SetProperty("entered", movement.Entered)
+		feature.SetProperty("dwelled", movement.Dwelled)
+		feature.SetProperty("positionId", position.ID)
+		feature.SetProperty("timestamp", *position.Timestamp)
+		feature.SetProperty("alt", *position.Alt)
+		feature.SetProperty("heading", *position.Heading)
+		feature.SetProperty("speed", *position.Speed)
+		feature.SetProperty("precision", *position.Precision)
+		featureCollection.AddFeature(feature)
+	}
+
+	return featureCollection
+}
+
+func formatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
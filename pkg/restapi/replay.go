@@ -0,0 +1,303 @@
+package restapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/eesrc/geo/pkg/restapi/service"
+	"github.com/eesrc/geo/pkg/restapi/validation"
+	"github.com/eesrc/geo/pkg/store/errors"
+	"github.com/eesrc/geo/pkg/sub/manager"
+	"github.com/eesrc/geo/pkg/sub/manager/event"
+	"github.com/eesrc/geo/pkg/sub/output"
+)
+
+// replayStopTimeout is how long the output emitting the triggers of a replay gets to deliver them
+const replayStopTimeout = 30 * time.Second
+
+// replayJob is a replay of a subscription running in the background. The replay is guarded by the
+// mutex since it's read by the REST API while the job updates its progress.
+type replayJob struct {
+	mutex  sync.Mutex
+	replay service.Replay
+}
+
+func (job *replayJob) get() service.Replay {
+	job.mutex.Lock()
+	defer job.mutex.Unlock()
+
+	return job.replay
+}
+
+func (job *replayJob) update(update func(replay *service.Replay)) {
+	job.mutex.Lock()
+	defer job.mutex.Unlock()
+
+	update(&job.replay)
+}
+
+// replayJobs holds the latest replay job of each subscription. Jobs are kept in memory only, so the
+// progress of a replay is lost if the server restarts.
+type replayJobs struct {
+	mutex *sync.Mutex
+	jobs  map[int64]*replayJob
+}
+
+func newReplayJobs() replayJobs {
+	return replayJobs{
+		mutex: &sync.Mutex{},
+		jobs:  make(map[int64]*replayJob),
+	}
+}
+
+// start registers a new replay job for the subscription. It returns false if a replay of the
+// subscription is already running.
+func (jobs *replayJobs) start(replay service.Replay) (*replayJob, bool) {
+	jobs.mutex.Lock()
+	defer jobs.mutex.Unlock()
+
+	if job, ok := jobs.jobs[replay.SubscriptionID]; ok && job.get().Status == service.ReplayRunning {
+		return job, false
+	}
+
+	job := &replayJob{replay: replay}
+	jobs.jobs[replay.SubscriptionID] = job
+
+	return job, true
+}
+
+func (jobs *replayJobs) get(subscriptionID int64) (*replayJob, bool) {
+	jobs.mutex.Lock()
+	defer jobs.mutex.Unlock()
+
+	job, ok := jobs.jobs[subscriptionID]
+	return job, ok
+}
+
+func (s *Server) replaySubscription(w http.ResponseWriter, r *http.Request) {
+	log := s.RequestLogger(r)
+	userProfile := s.UserFromRequest(r)
+
+	subscription, err := validation.GetSubscriptionFromHandlerParams(mux.Vars(r), userProfile.ID, s.store)
+	if err != nil {
+		handleError(err, w, log)
+		return
+	}
+
+	replay, err := validation.GetReplayFromBody(r.Body)
+	if err != nil {
+		handleError(err, w, log)
+		return
+	}
+
+	job, ok := s.replays.start(service.Replay{
+		SubscriptionID: subscription.ID,
+		Status:         service.ReplayRunning,
+		Since:          replay.Since,
+		Until:          replay.Until,
+		EmitTriggers:   replay.EmitTriggers,
+		Started:        time.Now().UnixNano() / int64(time.Millisecond),
+	})
+	if !ok {
+		validation.NewErrorResponse(
+			http.StatusConflict,
+			validation.NewParameterErrorDetail("subscriptionId", "A replay of the subscription is already running"),
+		).WriteHTTPError(w)
+		return
+	}
+
+	go s.runReplay(job, subscription, userProfile.ID)
+
+	startedReplay := job.get()
+	jsonBytes, err := startedReplay.MarshalJSON()
+	if err != nil {
+		validation.NewErrorResponse(http.StatusInternalServerError).WriteHTTPError(w)
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+	_, _ = w.Write(jsonBytes)
+}
+
+func (s *Server) getReplay(w http.ResponseWriter, r *http.Request) {
+	log := s.RequestLogger(r)
+	userProfile := s.UserFromRequest(r)
+
+	subscription, err := validation.GetSubscriptionFromHandlerParams(mux.Vars(r), userProfile.ID, s.store)
+	if err != nil {
+		handleError(err, w, log)
+		return
+	}
+
+	job, ok := s.replays.get(subscription.ID)
+	if !ok {
+		validation.NewErrorResponse(
+			http.StatusNotFound,
+			validation.NewParameterErrorDetail("subscriptionId", "The subscription hasn't been replayed since the server started"),
+		).WriteHTTPError(w)
+		return
+	}
+
+	replay := job.get()
+	jsonBytes, err := replay.MarshalJSON()
+	if err != nil {
+		validation.NewErrorResponse(http.StatusInternalServerError).WriteHTTPError(w)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(jsonBytes)
+}
+
+// runReplay runs the replay job and records the outcome
+func (s *Server) runReplay(job *replayJob, subscription *service.Subscription, userID int64) {
+	err := s.replay(job, subscription, userID)
+
+	job.update(func(replay *service.Replay) {
+		replay.Finished = time.Now().UnixNano() / int64(time.Millisecond)
+		replay.Status = service.ReplayCompleted
+		if err != nil {
+			replay.Status = service.ReplayFailed
+			replay.Error = err.Error()
+		}
+	})
+
+	if err != nil {
+		log.WithError(err).Errorf("Failed to replay subscription %d", subscription.ID)
+	}
+}
+
+// replay feeds the stored positions of each tracker within the time range of the job in timestamp order
+// through a copy of the subscription, starting from the movements stored before the time range. The movements
+// found replace the stored movements of each tracker within the time range, a tracker at a time. If the job emits triggers the
+// positions are also fed through a throwaway output of the subscription, which delivers the historical
+// triggers like the running output would have.
+//
+// A running subscription is suspended for the length of the replay, holding on to the positions arriving
+// meanwhile. It's resumed from the latest stored movements once the replay is done, even if it failed.
+func (s *Server) replay(job *replayJob, subscription *service.Subscription, userID int64) (err error) {
+	replay := job.get()
+	subscriptionModel := *subscription.ToModel()
+
+	if subscription.Active {
+		if err := s.manager.Suspend(subscription.ID); err != nil {
+			log.WithError(err).Warnf("Subscription %d isn't running, it's launched once the replay is done", subscription.ID)
+		}
+
+		defer func() {
+			if resumeErr := s.resumeReplayedSubscription(subscription.ID); err == nil {
+				err = resumeErr
+			}
+		}()
+	}
+
+	shapeIndex, err := s.acquireShapeIndex(*subscription.ShapeCollectionID)
+	if err != nil {
+		return err
+	}
+	defer s.manager.ShapeIndexes().Release(*subscription.ShapeCollectionID, shapeIndex)
+
+	trackerIDs, err := validation.ListSubscriptionTrackerIDs(subscription, userID, s.store)
+	if err != nil {
+		return err
+	}
+
+	job.update(func(replay *service.Replay) {
+		replay.Trackers = int64(len(trackerIDs))
+	})
+
+	filterParams := validation.FilterParams{Since: replay.Since, Until: replay.Until}
+	since, until := filterParams.SinceNano(), filterParams.UntilNano()
+
+	// The replay starts from the movements stored before the time range, so trackers already inside a
+	// shape don't enter it again
+	movements, err := s.store.ListLatestMovementsBySubscriptionIDBefore(subscription.ID, since)
+	if err != nil {
+		return err
+	}
+
+	var emitter output.Output
+	var emitterChannel chan interface{}
+	if replay.EmitTriggers {
+		emitter, err = output.NewOutput(output.NewReplayedGeoSubscription(subscriptionModel, shapeIndex, s.store, movements), s.manager.Publish)
+		if err != nil {
+			return err
+		}
+
+		emitterChannel = make(chan interface{})
+		emitter.Start(output.Config(subscriptionModel.OutputConfig), emitterChannel)
+	}
+
+	// The output is stopped once every position is fed through it. The channel is unbuffered, so the
+	// output has read every position once the channel is closed.
+	defer func() {
+		if emitter != nil {
+			close(emitterChannel)
+			emitter.Stop(replayStopTimeout)
+		}
+	}()
+
+	geoSubscription := output.NewSimulatedGeoSubscriptionWithMovements(subscriptionModel, shapeIndex, movements)
+
+	for _, trackerID := range trackerIDs {
+		positions, err := validation.ListReplayPositions(trackerID, userID, since, until, s.store)
+		if err != nil {
+			return err
+		}
+
+		trackerMovements, triggerEvents := geoSubscription.Replay(positions)
+
+		// The movements of each tracker are replaced on their own, so the trackers which aren't replayed
+		// keep their movements if the replay fails
+		err = s.store.ReplaceMovementsByTrackerID(subscription.ID, trackerID, since, until, trackerMovements)
+		if err != nil {
+			return err
+		}
+
+		if emitter != nil {
+			// The outputs only read the position of the event
+			for _, position := range positions {
+				positionEvent, err := json.Marshal(event.NewPositionEvent(0, position))
+				if err != nil {
+					return err
+				}
+				emitterChannel <- positionEvent
+			}
+		}
+
+		job.update(func(replay *service.Replay) {
+			replay.ProcessedTrackers++
+			replay.Positions += int64(len(positions))
+			replay.Movements += int64(len(trackerMovements))
+			replay.Triggers += int64(len(triggerEvents))
+		})
+	}
+
+	return nil
+}
+
+// resumeReplayedSubscription launches the subscription from the current configuration and latest stored
+// movements of it, handing it the positions held while it was suspended. A subscription which has been
+// deleted during the replay is left stopped.
+func (s *Server) resumeReplayedSubscription(subscriptionID int64) error {
+	geoSubscriptionModel, err := s.store.GetGeoSubscriptionBySubscription(subscriptionID)
+	if err != nil {
+		if storageError, ok := err.(*errors.StorageError); ok && storageError.Type == errors.NotFoundError {
+			_ = s.manager.Stop(subscriptionID)
+			return nil
+		}
+		return err
+	}
+
+	geoSubscription, err := manager.NewSharedGeoSubscription(*geoSubscriptionModel, s.manager, s.store)
+	if err != nil {
+		return err
+	}
+
+	return s.manager.Update(geoSubscription)
+}
@@ -11,7 +11,6 @@ import (
 	"github.com/eesrc/geo/pkg/restapi/validation"
 	"github.com/eesrc/geo/pkg/store"
 	"github.com/eesrc/geo/pkg/sub/manager"
-	"github.com/eesrc/geo/pkg/sub/output"
 
 	"github.com/gorilla/handlers"
 	"github.com/gorilla/mux"
@@ -31,7 +30,10 @@ type Server struct {
 	server        *http.Server
 	authenticator *auth.Authenticator
 	manager       manager.Manager
-	done          chan bool
+	replays       replayJobs
+	// shapeChanges receives the IDs of shape collections whose shapes have changed
+	shapeChanges chan int64
+	done         chan bool
 }
 
 // New creates a new HTTP server instance for serving the REST API
@@ -47,16 +49,12 @@ func New(params RestAPIParams, manager manager.Manager, store store.Store, authe
 		store:         store,
 		manager:       manager,
 		authenticator: authenticator,
+		replays:       newReplayJobs(),
+		shapeChanges:  make(chan int64, shapeChangesBuffer),
+		done:          make(chan bool, 1),
 	}
 
-	go func() {
-		geoSubs, err := getGeoSubscriptionsFromStore(httpServer.store)
-		if err != nil {
-			// We consider this fatal as the main purpose of the server is to handle subscriptions
-			log.Fatalf("Failed to list geo subscriptions: %v", err)
-		}
-		httpServer.manager.Refresh(geoSubs)
-	}()
+	go httpServer.reloadOnShapeChanges()
 
 	// Set up handlers.
 	// Order matters here, as some of the handlers either have certain preconditions
@@ -143,6 +141,7 @@ func (s Server) createRouter() *mux.Router {
 	apiRouter.HandleFunc("/collections/{collectionID}", s.updateCollection).Methods("PUT")
 	apiRouter.HandleFunc("/collections/{collectionID}", s.deleteCollection).Methods("DELETE")
 	apiRouter.HandleFunc("/collections/{collectionID}/stream", s.collectionWebsocketData).Methods("GET")
+	apiRouter.HandleFunc("/collections/{collectionID}/positions", s.createCollectionPositions).Methods("POST")
 
 	// Tracker management
 	apiRouter.HandleFunc("/collections/{collectionID}/trackers", s.listTrackers).Methods("GET")
@@ -165,6 +164,16 @@ func (s Server) createRouter() *mux.Router {
 	apiRouter.HandleFunc("/subscriptions/{subscriptionID}", s.updateSubscription).Methods("PUT")
 	apiRouter.HandleFunc("/subscriptions/{subscriptionID}", s.deleteSubscription).Methods("DELETE")
 	apiRouter.HandleFunc("/subscriptions/{subscriptionID}/stream", s.subscriptionWebsocketData).Methods("GET")
+	apiRouter.HandleFunc("/subscriptions/{subscriptionID}/secret", s.rotateSubscriptionSecret).Methods("POST")
+	apiRouter.HandleFunc("/subscriptions/{subscriptionID}/simulate", s.simulateSubscription).Methods("POST")
+	apiRouter.HandleFunc("/subscriptions/{subscriptionID}/replay", s.getReplay).Methods("GET")
+	apiRouter.HandleFunc("/subscriptions/{subscriptionID}/replay", s.replaySubscription).Methods("POST")
+	apiRouter.HandleFunc("/subscriptions/{subscriptionID}/movements", s.listMovements).Methods("GET")
+	apiRouter.HandleFunc("/subscriptions/{subscriptionID}/deliveries", s.listDeliveries).Methods("GET")
+	apiRouter.HandleFunc("/subscriptions/{subscriptionID}/deliveries/{deliveryID}", s.getDelivery).Methods("GET")
+	apiRouter.HandleFunc("/subscriptions/{subscriptionID}/deliveries/{deliveryID}/resend", s.resendDelivery).Methods("POST")
+	apiRouter.HandleFunc("/subscriptions/{subscriptionID}/deadletters", s.listDeadLetters).Methods("GET")
+	apiRouter.HandleFunc("/subscriptions/{subscriptionID}/deadletters/resend", s.resendDeadLetters).Methods("POST")
 
 	// Subscription management for collection
 	apiRouter.HandleFunc("/collections/{collectionID}/subscriptions", s.listCollectionSubscriptions).Methods("GET")
@@ -293,23 +302,6 @@ func (s *Server) startServer(result chan error, tls bool, tlscert, tlskey string
 	}
 }
 
-func getGeoSubscriptionsFromStore(store store.Store) ([]output.GeoSubscription, error) {
-	// Populate subscriptions from DB along with shapes.
-	geoSubModels, err := store.ListGeoSubscriptions(0, 1000)
-	if err != nil {
-		return []output.GeoSubscription{}, err
-	}
-
-	geoSubs := make([]output.GeoSubscription, len(geoSubModels))
-
-	// Create indexes and initialize new GeoSubscriptions from models
-	for i, geoSubModel := range geoSubModels {
-		geoSubs[i] = output.NewGeoSubscriptionFromModel(geoSubModel, store)
-	}
-
-	return geoSubs, nil
-}
-
 func notImplemented(w http.ResponseWriter, r *http.Request) {
 	validation.NewErrorResponse(http.StatusNotImplemented).WriteHTTPError(w)
 }
@@ -0,0 +1,60 @@
+package service
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/eesrc/geo/pkg/model"
+	"github.com/eesrc/geo/pkg/sub"
+)
+
+// Delivery is the API representation of a trigger event delivered through a subscription output
+type Delivery struct {
+	ID             int64              `json:"id"`
+	SubscriptionID int64              `json:"subscriptionId"`
+	Output         sub.OutputType     `json:"output"`
+	Status         sub.DeliveryStatus `json:"status"`
+	Recipient      string             `json:"recipient"`
+	Payload        json.RawMessage    `json:"payload"`
+	Attempts       int64              `json:"attempts"`
+	LastError      string             `json:"lastError"`
+	Created        int64              `json:"created"`
+	Updated        int64              `json:"updated"`
+}
+
+// ToModel creates a storage model from the API representation
+func (delivery *Delivery) ToModel() *model.Delivery {
+	return &model.Delivery{
+		ID:             delivery.ID,
+		SubscriptionID: delivery.SubscriptionID,
+		Output:         string(delivery.Output),
+		Status:         string(delivery.Status),
+		Recipient:      delivery.Recipient,
+		Payload:        delivery.Payload,
+		Attempts:       delivery.Attempts,
+		LastError:      delivery.LastError,
+		Created:        time.Unix(0, milliToNanoSeconds(delivery.Created)),
+		Updated:        time.Unix(0, milliToNanoSeconds(delivery.Updated)),
+	}
+}
+
+// MarshalJSON marshals a JSON string from the API representation
+func (delivery *Delivery) MarshalJSON() ([]byte, error) {
+	return json.Marshal(*delivery)
+}
+
+// NewDeliveryFromModel creates a HTTP representation of a model delivery
+func NewDeliveryFromModel(deliveryModel *model.Delivery) *Delivery {
+	return &Delivery{
+		ID:             deliveryModel.ID,
+		SubscriptionID: deliveryModel.SubscriptionID,
+		Output:         sub.OutputType(deliveryModel.Output),
+		Status:         sub.DeliveryStatus(deliveryModel.Status),
+		Recipient:      deliveryModel.Recipient,
+		Payload:        deliveryModel.Payload,
+		Attempts:       deliveryModel.Attempts,
+		LastError:      deliveryModel.LastError,
+		Created:        nanoToMilliSeconds(deliveryModel.Created.UnixNano()),
+		Updated:        nanoToMilliSeconds(deliveryModel.Updated.UnixNano()),
+	}
+}
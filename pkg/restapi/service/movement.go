@@ -0,0 +1,44 @@
+package service
+
+import (
+	"encoding/json"
+
+	"github.com/eesrc/geo/pkg/model"
+)
+
+// Movement is the API representation of a stored movement of a tracker in relation to a shape, along
+// with the position which triggered it
+type Movement struct {
+	ID             int64     `json:"id"`
+	SubscriptionID int64     `json:"subscriptionId"`
+	TrackerID      int64     `json:"trackerId"`
+	ShapeID        int64     `json:"shapeId"`
+	Movements      []string  `json:"movements"`
+	Entered        int64     `json:"entered"`
+	Dwelled        bool      `json:"dwelled"`
+	Position       *Position `json:"position"`
+}
+
+// MarshalJSON marshals a JSON string from the API representation
+func (movement *Movement) MarshalJSON() ([]byte, error) {
+	return json.Marshal(*movement)
+}
+
+// NewMovementFromModel creates a HTTP representation of a model movement
+func NewMovementFromModel(positionMovementModel *model.PositionMovement) *Movement {
+	movements := []string(positionMovementModel.Movement.Movements)
+	if movements == nil {
+		movements = []string{}
+	}
+
+	return &Movement{
+		ID:             positionMovementModel.ID,
+		SubscriptionID: positionMovementModel.Movement.SubscriptionID,
+		TrackerID:      positionMovementModel.Movement.TrackerID,
+		ShapeID:        positionMovementModel.Movement.ShapeID,
+		Movements:      movements,
+		Entered:        nanoToMilliSeconds(positionMovementModel.Movement.Entered),
+		Dwelled:        positionMovementModel.Movement.Dwelled,
+		Position:       NewPositionFromModel(&positionMovementModel.Position),
+	}
+}
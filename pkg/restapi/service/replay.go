@@ -0,0 +1,40 @@
+package service
+
+import (
+	"encoding/json"
+)
+
+// ReplayStatus is the status of a replay of a subscription
+type ReplayStatus string
+
+const (
+	// ReplayRunning is a replay still feeding positions through the subscription
+	ReplayRunning ReplayStatus = "running"
+	// ReplayCompleted is a replay which has rebuilt the movements of the subscription
+	ReplayCompleted ReplayStatus = "completed"
+	// ReplayFailed is a replay which stopped before the movements were rebuilt
+	ReplayFailed ReplayStatus = "failed"
+)
+
+// Replay is the API representation of a replay of the stored positions through a subscription. Since,
+// Until, Started and Finished are in milliseconds.
+type Replay struct {
+	SubscriptionID    int64        `json:"subscriptionId"`
+	Status            ReplayStatus `json:"status"`
+	Since             int64        `json:"since"`
+	Until             int64        `json:"until"`
+	EmitTriggers      bool         `json:"emitTriggers"`
+	Trackers          int64        `json:"trackers"`
+	ProcessedTrackers int64        `json:"processedTrackers"`
+	Positions         int64        `json:"positions"`
+	Movements         int64        `json:"movements"`
+	Triggers          int64        `json:"triggers"`
+	Error             string       `json:"error,omitempty"`
+	Started           int64        `json:"started"`
+	Finished          int64        `json:"finished,omitempty"`
+}
+
+// MarshalJSON marshals a JSON string from the API representation
+func (replay *Replay) MarshalJSON() ([]byte, error) {
+	return json.Marshal(*replay)
+}
@@ -19,11 +19,9 @@ type Shape struct {
 func (shape *Shape) ToModel() *model.Shape {
 	shapeProperties := shape.Properties
 
-	// Radius is a special case where we assume m and reduce it to a simplified WGS84 compliant value
+	// Radius is a special case which is given in metres and must be a number
 	if radius, ok := shapeProperties["radius"]; ok {
-		if radiusNumber, ok := radius.(float64); ok {
-			shapeProperties["radius"] = radiusNumber / 111111
-		} else {
+		if _, ok := radius.(float64); !ok {
 			delete(shapeProperties, "radius")
 		}
 	}
@@ -48,13 +46,6 @@ func NewShapeFromModel(shapeModel *model.Shape) *Shape {
 		properties = geometry.ShapeProperties{}
 	}
 
-	// Radius is a special case where we assume WGS84 compliant value and multiply the value to represent m
-	if radius, ok := properties["radius"]; ok {
-		if radiusNumber, ok := radius.(float64); ok {
-			properties["radius"] = radiusNumber * 111111
-		}
-	}
-
 	return &Shape{
 		ID:                shapeModel.ID,
 		ShapeCollectionID: shapeModel.ShapeCollectionID,
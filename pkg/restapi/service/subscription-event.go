@@ -29,6 +29,8 @@ type TriggerDetails struct {
 	Movements         []string `json:"movements"`
 	ShapecollectionID int64    `json:"shapeCollectionId"`
 	ShapeID           int64    `json:"shapeId"`
+	// Direction is the direction a tripwire was crossed in, ie "left-to-right"
+	Direction string `json:"direction,omitempty"`
 }
 
 // NewSubscriptionEventFromModel returns a new service SubscriptionEvent from a model
@@ -42,6 +44,7 @@ func NewSubscriptionEventFromModel(subscriptionEvent *event.SubscriptionEvent) *
 				Movements:         subscriptionEvent.Data.Details.Movements,
 				ShapecollectionID: subscriptionEvent.Data.Details.ShapecollectionID,
 				ShapeID:           subscriptionEvent.Data.Details.ShapeID,
+				Direction:         subscriptionEvent.Data.Details.Direction,
 			},
 		},
 	}
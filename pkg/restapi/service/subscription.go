@@ -2,12 +2,16 @@ package service
 
 import (
 	"encoding/json"
+	"time"
 
 	"github.com/eesrc/geo/pkg/model"
 	"github.com/eesrc/geo/pkg/sub"
 	"github.com/eesrc/geo/pkg/sub/output"
 )
 
+// WebhookSecretKey is the key of the signing secret in the output config of webhook subscriptions
+const WebhookSecretKey = "secret"
+
 // Subscription is a representation of subscription for a collection or
 // tracker towards a output
 type Subscription struct {
@@ -38,6 +42,17 @@ type TrackableEntry struct {
 type TriggerCriteria struct {
 	TriggerTypes sub.MovementList   `json:"triggerTypes"`
 	Confidence   sub.ConfidenceList `json:"confidence"`
+	// DwellTime is how long in milliseconds a tracker must stay inside a shape before the dwell
+	// trigger fires. The dwell trigger is evaluated as positions are received.
+	DwellTime int64 `json:"dwellTime"`
+	// ConfirmPositions is the number of consecutive positions needed before a tracker has entered
+	// or exited a shape
+	ConfirmPositions int64 `json:"confirmPositions"`
+	// ConfirmTime is the minimum time in milliseconds from the first to the last confirming position
+	ConfirmTime int64 `json:"confirmTime"`
+	// BoundaryBuffer is the distance in metres a tracker must move past the edge of a shape to
+	// enter or exit it
+	BoundaryBuffer float64 `json:"boundaryBuffer"`
 }
 
 // ToModel creates a storage model from the API representation
@@ -52,15 +67,39 @@ func (subscription *Subscription) ToModel() *model.Subscription {
 		OutputConfig:      model.OutputConfig(subscription.Output.Config),
 		Types:             subscription.TriggerCriteria.TriggerTypes.ToModel(),
 		Confidences:       subscription.TriggerCriteria.Confidence.ToModel(),
+		DwellTime:         time.Duration(milliToNanoSeconds(subscription.TriggerCriteria.DwellTime)),
+		ConfirmPositions:  subscription.TriggerCriteria.ConfirmPositions,
+		ConfirmTime:       time.Duration(milliToNanoSeconds(subscription.TriggerCriteria.ConfirmTime)),
+		BoundaryBuffer:    subscription.TriggerCriteria.BoundaryBuffer,
 		ShapeCollectionID: *subscription.ShapeCollectionID,
 		TrackableType:     string(subscription.Trackable.Type),
 		TrackableID:       *subscription.Trackable.ID,
 	}
 }
 
-// MarshalJSON marshals a JSON string from the API representation
+// MarshalJSON marshals a JSON string from the API representation. The signing secret of the output
+// is left out, it's only returned when the subscription is created and when the secret is rotated
 func (subscription *Subscription) MarshalJSON() ([]byte, error) {
-	return json.Marshal(*subscription)
+	return json.Marshal(subscription.redacted())
+}
+
+// subscriptionJSON is the JSON representation of a subscription, without the methods of Subscription
+type subscriptionJSON Subscription
+
+// redacted returns the subscription without the signing secret in the output config
+func (subscription *Subscription) redacted() subscriptionJSON {
+	redacted := subscriptionJSON(*subscription)
+
+	if _, ok := subscription.Output.Config[WebhookSecretKey]; ok {
+		redacted.Output.Config = make(output.Config, len(subscription.Output.Config)-1)
+		for key, value := range subscription.Output.Config {
+			if key != WebhookSecretKey {
+				redacted.Output.Config[key] = value
+			}
+		}
+	}
+
+	return redacted
 }
 
 // NewSubscriptionFromModel creates a HTTP representation of a model subscription
@@ -79,8 +118,12 @@ func NewSubscriptionFromModel(subscriptionModel *model.Subscription) *Subscripti
 			Config: output.Config(subscriptionModel.OutputConfig),
 		},
 		TriggerCriteria: TriggerCriteria{
-			TriggerTypes: sub.NewMovementTypeFromModel(subscriptionModel.Types),
-			Confidence:   sub.NewConfidenceListFromModel(subscriptionModel.Confidences),
+			TriggerTypes:     sub.NewMovementTypeFromModel(subscriptionModel.Types),
+			Confidence:       sub.NewConfidenceListFromModel(subscriptionModel.Confidences),
+			DwellTime:        nanoToMilliSeconds(int64(subscriptionModel.DwellTime)),
+			ConfirmPositions: subscriptionModel.ConfirmPositions,
+			ConfirmTime:      nanoToMilliSeconds(int64(subscriptionModel.ConfirmTime)),
+			BoundaryBuffer:   subscriptionModel.BoundaryBuffer,
 		},
 		ShapeCollectionID: &shapeID,
 		Trackable: TrackableEntry{
@@ -98,9 +141,51 @@ func NewSubscription() Subscription {
 			Type:   sub.Webhook,
 			Config: output.Config{},
 		},
-		TriggerCriteria: TriggerCriteria{},
+		TriggerCriteria: TriggerCriteria{
+			ConfirmPositions: 1,
+		},
 		Trackable: TrackableEntry{
 			Type: sub.Collection,
 		},
 	}
 }
+
+// SubscriptionSecret is the signing secret of a subscription
+type SubscriptionSecret struct {
+	Secret string `json:"secret"`
+}
+
+// MarshalJSON marshals a JSON string from the API representation
+func (subscriptionSecret *SubscriptionSecret) MarshalJSON() ([]byte, error) {
+	return json.Marshal(*subscriptionSecret)
+}
+
+// CreatedSubscription is a newly created subscription along with its signing secret, which isn't
+// returned again unless the secret is rotated
+type CreatedSubscription struct {
+	Subscription       *Subscription
+	SubscriptionSecret SubscriptionSecret
+}
+
+// NewCreatedSubscription returns the created subscription along with the signing secret of its output.
+// The secret is empty for outputs which don't sign their requests.
+func NewCreatedSubscription(subscription *Subscription) *CreatedSubscription {
+	return &CreatedSubscription{
+		Subscription: subscription,
+		SubscriptionSecret: SubscriptionSecret{
+			Secret: subscription.Output.Config.GetStringWithDefault(WebhookSecretKey, ""),
+		},
+	}
+}
+
+// MarshalJSON marshals a JSON string from the API representation. The secret is added next to the
+// fields of the subscription and left out if empty
+func (createdSubscription *CreatedSubscription) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		subscriptionJSON
+		Secret string `json:"secret,omitempty"`
+	}{
+		subscriptionJSON: createdSubscription.Subscription.redacted(),
+		Secret:           createdSubscription.SubscriptionSecret.Secret,
+	})
+}
@@ -91,8 +91,6 @@ func (s *Server) updateFeatureCollection(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	updateShapeCollectionGeoSubscriptions(shapeCollectionID, s.manager, s.store)
-
 	jsonBytes, err := json.Marshal(updateFeatureCollection)
 	if err != nil {
 		validation.NewErrorResponse(http.StatusInternalServerError).WriteHTTPError(w)
@@ -103,6 +101,7 @@ func (s *Server) updateFeatureCollection(w http.ResponseWriter, r *http.Request)
 		topic.NewEntityTopic(topic.ShapeCollections, shapeCollectionID, topic.LifecycleEvents),
 		event.NewLifecycleEvent(event.UpdatedEvent, event.ShapeCollectionEntity, shapeCollectionID),
 	)
+	s.shapeChanges <- shapeCollectionID
 
 	w.WriteHeader(http.StatusOK)
 	_, _ = w.Write(jsonBytes)
@@ -136,8 +135,6 @@ func (s *Server) createShape(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	updateShapeCollectionGeoSubscriptions(shapeCollectionID, s.manager, s.store)
-
 	newShape, err := validation.GetShape(shapeCollectionID, newShapeID, userProfile.ID, s.store)
 	if err != nil {
 		handleError(err, w, log)
@@ -154,6 +151,7 @@ func (s *Server) createShape(w http.ResponseWriter, r *http.Request) {
 		topic.NewEntityTopic(topic.ShapeCollections, shapeCollectionID, topic.LifecycleEvents),
 		event.NewLifecycleEvent(event.UpdatedEvent, event.ShapeCollectionEntity, shapeCollectionID),
 	)
+	s.shapeChanges <- shapeCollectionID
 
 	w.WriteHeader(http.StatusCreated)
 	_, _ = w.Write(jsonBytes)
@@ -194,8 +192,6 @@ func (s *Server) updateShapeGeoJSON(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	updateShapeCollectionGeoSubscriptions(shapeCollectionID, s.manager, s.store)
-
 	updatedShape, err := validation.GetShape(shapeCollectionID, shapeID, userProfile.ID, s.store)
 	if err != nil {
 		handleError(err, w, log)
@@ -212,6 +208,7 @@ func (s *Server) updateShapeGeoJSON(w http.ResponseWriter, r *http.Request) {
 		topic.NewEntityTopic(topic.ShapeCollections, shapeCollectionID, topic.LifecycleEvents),
 		event.NewLifecycleEvent(event.UpdatedEvent, event.ShapeCollectionEntity, shapeCollectionID),
 	)
+	s.shapeChanges <- shapeCollectionID
 
 	w.WriteHeader(http.StatusOK)
 	_, _ = w.Write(jsonBytes)
@@ -279,12 +276,11 @@ func (s *Server) deleteShape(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	updateShapeCollectionGeoSubscriptions(shapeCollectionID, s.manager, s.store)
-
 	s.manager.Publish(
 		topic.NewEntityTopic(topic.ShapeCollections, shapeCollectionID, topic.LifecycleEvents),
 		event.NewLifecycleEvent(event.UpdatedEvent, event.ShapeCollectionEntity, shapeCollectionID),
 	)
+	s.shapeChanges <- shapeCollectionID
 
 	w.WriteHeader(http.StatusNoContent)
 }
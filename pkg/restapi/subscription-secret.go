@@ -0,0 +1,105 @@
+package restapi
+
+import (
+	"net/http"
+
+	"github.com/eesrc/geo/pkg/restapi/service"
+	"github.com/eesrc/geo/pkg/restapi/validation"
+	"github.com/eesrc/geo/pkg/sub"
+	"github.com/eesrc/geo/pkg/sub/manager/event"
+	"github.com/eesrc/geo/pkg/sub/manager/topic"
+	"github.com/eesrc/geo/pkg/sub/output"
+	"github.com/eesrc/geo/pkg/webhook"
+	"github.com/gorilla/mux"
+)
+
+func (s *Server) rotateSubscriptionSecret(w http.ResponseWriter, r *http.Request) {
+	log := s.RequestLogger(r)
+	userProfile := s.UserFromRequest(r)
+
+	subscription, err := validation.GetSubscriptionFromHandlerParams(mux.Vars(r), userProfile.ID, s.store)
+	if err != nil {
+		handleError(err, w, log)
+		return
+	}
+
+	err = validation.ValidateSignedSubscription(subscription)
+	if err != nil {
+		handleError(err, w, log)
+		return
+	}
+
+	secret, err := webhook.NewSecret()
+	if err != nil {
+		validation.NewErrorResponse(http.StatusInternalServerError).WriteHTTPError(w)
+		return
+	}
+
+	if subscription.Output.Config == nil {
+		subscription.Output.Config = output.Config{}
+	}
+	subscription.Output.Config[service.WebhookSecretKey] = secret
+
+	err = validation.UpdateSubscription(subscription, userProfile.ID, s.store)
+	if err != nil {
+		handleError(err, w, log)
+		return
+	}
+
+	// Restart the geoSubscription so the output picks up the new secret
+	err = s.updateGeoSubscription(subscription)
+	if err != nil {
+		handleError(err, w, log)
+		return
+	}
+
+	subscriptionSecret := service.SubscriptionSecret{Secret: secret}
+
+	jsonBytes, err := subscriptionSecret.MarshalJSON()
+	if err != nil {
+		validation.NewErrorResponse(http.StatusInternalServerError).WriteHTTPError(w)
+		return
+	}
+
+	s.manager.Publish(
+		topic.NewEntityTopic(topic.Subscription, subscription.ID, topic.LifecycleEvents),
+		event.NewLifecycleEvent(event.UpdatedEvent, event.SubscriptionEntity, subscription.ID),
+	)
+
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(jsonBytes)
+}
+
+// setWebhookSecret makes sure a webhook subscription has a signing secret. A secret provided in the
+// subscription is kept as is. If missing, the secret of the existing subscription is reused, otherwise
+// a new secret is generated. Subscriptions with other outputs are left untouched.
+func setWebhookSecret(subscription *service.Subscription, existingSubscription *service.Subscription) error {
+	if subscription.Output.Type != sub.Webhook {
+		return nil
+	}
+
+	if subscription.Output.Config == nil {
+		subscription.Output.Config = output.Config{}
+	}
+
+	if subscription.Output.Config.GetStringWithDefault(service.WebhookSecretKey, "") != "" {
+		return nil
+	}
+
+	if existingSubscription != nil && existingSubscription.Output.Type == sub.Webhook {
+		existingSecret := existingSubscription.Output.Config.GetStringWithDefault(service.WebhookSecretKey, "")
+		if existingSecret != "" {
+			subscription.Output.Config[service.WebhookSecretKey] = existingSecret
+			return nil
+		}
+	}
+
+	secret, err := webhook.NewSecret()
+	if err != nil {
+		return err
+	}
+
+	subscription.Output.Config[service.WebhookSecretKey] = secret
+
+	return nil
+}
@@ -6,8 +6,8 @@ import (
 
 	log "github.com/sirupsen/logrus"
 
+	"github.com/eesrc/geo/pkg/restapi/service"
 	"github.com/eesrc/geo/pkg/restapi/validation"
-	"github.com/eesrc/geo/pkg/store"
 	"github.com/eesrc/geo/pkg/sub/manager"
 	"github.com/eesrc/geo/pkg/sub/manager/event"
 	"github.com/eesrc/geo/pkg/sub/manager/topic"
@@ -26,6 +26,12 @@ func (s *Server) createSubscription(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	err = setWebhookSecret(subscriptionBody, nil)
+	if err != nil {
+		handleError(err, w, log)
+		return
+	}
+
 	newSubscriptionID, err := validation.CreateSubscription(subscriptionBody, userProfile.ID, s.store)
 	if err != nil {
 		handleError(err, w, log)
@@ -40,17 +46,15 @@ func (s *Server) createSubscription(w http.ResponseWriter, r *http.Request) {
 
 	if newSubscription.Active {
 		// The subscription is active, so we initiate a geoSubscription
-		modelShapes, err := s.store.ListShapesByShapeCollectionIDAndUserID(*subscriptionBody.ShapeCollectionID, userProfile.ID, true, 0, 10000)
+		shapeIndex, err := s.acquireShapeIndex(*subscriptionBody.ShapeCollectionID)
 		if err != nil {
 			handleError(err, w, log)
 			return
 		}
 
-		index := index.NewRTreeIndexFromModel(modelShapes)
-
 		geoSubscription := output.NewGeoSubscription(
 			*newSubscription.ToModel(),
-			index,
+			shapeIndex,
 			s.store,
 		)
 
@@ -61,7 +65,7 @@ func (s *Server) createSubscription(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	jsonBytes, err := newSubscription.MarshalJSON()
+	jsonBytes, err := service.NewCreatedSubscription(newSubscription).MarshalJSON()
 	if err != nil {
 		validation.NewErrorResponse(http.StatusInternalServerError).WriteHTTPError(w)
 		return
@@ -114,44 +118,35 @@ func (s *Server) updateSubscription(w http.ResponseWriter, r *http.Request) {
 
 	subscriptionBody.ID = subscriptionID
 
-	err = validation.UpdateSubscription(subscriptionBody, userProfile.ID, s.store)
+	existingSubscription, err := validation.GetSubscription(subscriptionID, userProfile.ID, s.store)
 	if err != nil {
 		handleError(err, w, log)
 		return
 	}
 
-	updatedSubscription, err := validation.GetSubscription(subscriptionID, userProfile.ID, s.store)
+	err = setWebhookSecret(subscriptionBody, existingSubscription)
 	if err != nil {
 		handleError(err, w, log)
 		return
 	}
 
-	// The subscription is potentially running/not running, so we initiate an update of a geoSubscription
-	shapes, err := s.store.ListShapesByShapeCollectionIDAndUserID(*updatedSubscription.ShapeCollectionID, userProfile.ID, true, 0, 1000000)
+	err = validation.UpdateSubscription(subscriptionBody, userProfile.ID, s.store)
 	if err != nil {
 		handleError(err, w, log)
 		return
 	}
 
-	shapeIndex := index.NewRTreeIndexFromModel(shapes)
-
-	movements, err := s.store.ListMovementsBySubscriptionID(updatedSubscription.ID, 0, 100)
+	updatedSubscription, err := validation.GetSubscription(subscriptionID, userProfile.ID, s.store)
 	if err != nil {
 		handleError(err, w, log)
 		return
 	}
 
-	geoSubscription := output.NewGeoSubscriptionWithMovements(
-		*updatedSubscription.ToModel(),
-		shapeIndex,
-		s.store,
-		movements,
-	)
-
-	err = s.manager.Update(geoSubscription)
-
+	// The subscription is potentially running/not running, so we initiate an update of a geoSubscription
+	err = s.updateGeoSubscription(updatedSubscription)
 	if err != nil {
-		handleSubscriptionUpdateError(geoSubscription, err)
+		handleError(err, w, log)
+		return
 	}
 
 	jsonBytes, err := updatedSubscription.MarshalJSON()
@@ -224,24 +219,153 @@ func (s *Server) listSubscriptions(w http.ResponseWriter, r *http.Request) {
 	_, _ = w.Write(jsonBytes)
 }
 
-// updateShapeCollectionGeoSubscriptions will update all subscriptions reliant on the shape collection. The update will be launched
-// in its own go routine. This update might fail, but should not affect the normal program flow
-func updateShapeCollectionGeoSubscriptions(shapeCollectionID int64, manager manager.Manager, store store.Store) {
-	go func() {
-		geoSubscriptionModels, err := store.ListGeoSubscriptionsByShapeCollectionID(shapeCollectionID, 0, 100000)
+// simulateSubscription runs positions through a throwaway copy of the subscription and writes the trigger
+// events it would have produced. Nothing is stored, published or sent through the output.
+func (s *Server) simulateSubscription(w http.ResponseWriter, r *http.Request) {
+	log := s.RequestLogger(r)
+	userProfile := s.UserFromRequest(r)
+
+	subscription, err := validation.GetSubscriptionFromHandlerParams(mux.Vars(r), userProfile.ID, s.store)
+	if err != nil {
+		handleError(err, w, log)
+		return
+	}
+
+	simulation, err := validation.GetSimulationFromBody(r.Body)
+	if err != nil {
+		handleError(err, w, log)
+		return
+	}
+
+	positions, err := validation.GetSimulationPositions(subscription, simulation, userProfile.ID, s.store)
+	if err != nil {
+		handleError(err, w, log)
+		return
+	}
+
+	shapeIndex, err := s.acquireShapeIndex(*subscription.ShapeCollectionID)
+	if err != nil {
+		handleError(err, w, log)
+		return
+	}
+	defer s.manager.ShapeIndexes().Release(*subscription.ShapeCollectionID, shapeIndex)
+
+	geoSubscription := output.NewSimulatedGeoSubscription(*subscription.ToModel(), shapeIndex)
+
+	triggerEvents := geoSubscription.Simulate(positions)
+	subscriptionEvents := make([]*service.SubscriptionEvent, len(triggerEvents))
+	for i, triggerEvent := range triggerEvents {
+		subscriptionEvents[i] = service.NewSubscriptionEventFromModel(triggerEvent)
+	}
+
+	jsonBytes, err := json.Marshal(subscriptionEvents)
+	if err != nil {
+		validation.NewErrorResponse(http.StatusInternalServerError).WriteHTTPError(w)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(jsonBytes)
+}
+
+// updateGeoSubscription initiates an update of the geoSubscription of the given subscription, starting or
+// stopping it according to its current state. Returns an error if the shapes or movements couldn't be fetched.
+func (s *Server) updateGeoSubscription(subscription *service.Subscription) error {
+	movements, err := s.store.ListLatestMovementsBySubscriptionID(subscription.ID)
+	if err != nil {
+		return err
+	}
+
+	shapeIndex, err := s.acquireShapeIndex(*subscription.ShapeCollectionID)
+	if err != nil {
+		return err
+	}
+
+	geoSubscription := output.NewGeoSubscriptionWithMovements(
+		*subscription.ToModel(),
+		shapeIndex,
+		s.store,
+		movements,
+	)
+
+	err = s.manager.Update(geoSubscription)
+
+	if err != nil {
+		handleSubscriptionUpdateError(geoSubscription, err)
+	}
+
+	return nil
+}
+
+// shapeChangesBuffer is the number of shape changes queued for reloading before the handlers changing
+// shapes have to wait for the reloads
+const shapeChangesBuffer = 100
+
+// reloadOnShapeChanges reloads the subscriptions of a shape collection whenever the shapes of the shape
+// collection change, so the running subscriptions use the current shapes. Updates of the shape collection
+// itself, ie the name or description, don't affect the shapes and don't reload the subscriptions. The
+// reloads are done one at a time in the order of the changes.
+func (s *Server) reloadOnShapeChanges() {
+	for shapeCollectionID := range s.shapeChanges {
+		s.manager.ShapeIndexes().Invalidate(shapeCollectionID)
+		s.reloadShapeCollectionGeoSubscriptions(shapeCollectionID)
+	}
+}
+
+// reloadPageSize is the number of GeoSubscriptions listed from the store at a time when reloading
+const reloadPageSize = 100
+
+// reloadShapeCollectionGeoSubscriptions reloads all subscriptions reliant on the shape collection with the
+// current shapes, sharing a new index of them. The subscriptions are listed a page at a time and swapped
+// together, keeping the movement state of the shapes which still exist. This reload might fail, but should
+// not affect the normal program flow
+func (s *Server) reloadShapeCollectionGeoSubscriptions(shapeCollectionID int64) {
+	var geoSubscriptions []output.GeoSubscription
+
+	// The subscriptions hold a reference to the index until they're handed over to the manager
+	releaseIndexes := func() {
+		for _, geoSubscription := range geoSubscriptions {
+			s.manager.ShapeIndexes().Release(geoSubscription.Subscription.ShapeCollectionID, geoSubscription.Index)
+		}
+	}
+
+	var lastID int64
+	for {
+		geoSubscriptionModels, err := s.store.ListGeoSubscriptionsByShapeCollectionIDAfterID(shapeCollectionID, lastID, reloadPageSize)
 		if err != nil {
+			releaseIndexes()
 			log.WithError(err).Errorf("Failed to list geo subscriptions for ShapeCollectionID %d", shapeCollectionID)
+			return
 		}
 
 		for _, geoSubscriptionModel := range geoSubscriptionModels {
-			geoSubscription := output.NewGeoSubscriptionFromModel(geoSubscriptionModel, store)
-			err = manager.Update(geoSubscription)
-
+			geoSubscription, err := manager.NewSharedGeoSubscription(geoSubscriptionModel, s.manager, s.store)
 			if err != nil {
-				handleSubscriptionUpdateError(geoSubscription, err)
+				releaseIndexes()
+				log.WithError(err).Errorf("Failed to index the shapes of ShapeCollectionID %d", shapeCollectionID)
+				return
 			}
+
+			geoSubscriptions = append(geoSubscriptions, geoSubscription)
 		}
-	}()
+
+		if len(geoSubscriptionModels) < reloadPageSize {
+			break
+		}
+
+		lastID = geoSubscriptionModels[len(geoSubscriptionModels)-1].Subscription.ID
+	}
+
+	err := s.manager.Reload(geoSubscriptions)
+	if err != nil {
+		log.WithError(err).Errorf("Failed to reload geo subscriptions for ShapeCollectionID %d", shapeCollectionID)
+	}
+}
+
+// acquireShapeIndex acquires the shared index of the shape collection from the manager, see
+// manager.AcquireShapeIndex
+func (s *Server) acquireShapeIndex(shapeCollectionID int64) (index.TriaIndex, error) {
+	return manager.AcquireShapeIndex(shapeCollectionID, s.manager, s.store)
 }
 
 func handleSubscriptionUpdateError(geoSubscription output.GeoSubscription, err error) {
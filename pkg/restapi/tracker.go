@@ -2,7 +2,9 @@ package restapi
 
 import (
 	"encoding/json"
+	"fmt"
 	"net/http"
+	"sort"
 
 	"github.com/eesrc/geo/pkg/restapi/validation"
 	"github.com/eesrc/geo/pkg/sub/manager/event"
@@ -207,13 +209,42 @@ func (s *Server) createTrackerPos(w http.ResponseWriter, r *http.Request) {
 	}
 
 	position.TrackerID = trackerID
+	positionModel := position.ToModel()
 
-	newPositionID, err := validation.CreatePosition(position.ToModel(), userProfile.ID, s.store)
+	// An exact duplicate of a stored position, ie from an upload which is retried, is dropped
+	duplicate, err := validation.GetDuplicatePosition(positionModel, userProfile.ID, s.store)
 	if err != nil {
 		handleError(err, w, log)
 		return
 	}
 
+	var newPositionID int64
+	if duplicate == nil {
+		newPositionID, err = validation.CreatePosition(positionModel, userProfile.ID, s.store)
+
+		// The duplicate might have been stored concurrently since the check
+		if validation.IsDuplicatePositionError(err) {
+			duplicate, err = validation.GetDuplicatePosition(positionModel, userProfile.ID, s.store)
+		}
+
+		if err != nil {
+			handleError(err, w, log)
+			return
+		}
+	}
+
+	if duplicate != nil {
+		jsonBytes, err := duplicate.MarshalJSON()
+		if err != nil {
+			validation.NewErrorResponse(http.StatusInternalServerError).WriteHTTPError(w)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write(jsonBytes)
+		return
+	}
+
 	newPosition, err := validation.GetPosition(newPositionID, userProfile.ID, s.store)
 	if err != nil {
 		handleError(err, w, log)
@@ -240,6 +271,64 @@ func (s *Server) createTrackerPos(w http.ResponseWriter, r *http.Request) {
 	_, _ = w.Write(jsonBytes)
 }
 
+// createCollectionPositions creates a batch of positions for one or more trackers in the collection. The
+// positions are created in one transaction and the response has a result per position, with the status
+// 207 Multi-Status if any of the positions has an error. Duplicates of stored positions are dropped.
+func (s *Server) createCollectionPositions(w http.ResponseWriter, r *http.Request) {
+	log := s.RequestLogger(r)
+	userProfile := s.UserFromRequest(r)
+
+	collectionID, err := validation.GetCollectionID(mux.Vars(r))
+	if err != nil {
+		handleError(err, w, log)
+		return
+	}
+
+	results, err := validation.GetPositionsFromBody(r.Body, r.Header.Get("Content-Type"))
+	if err != nil {
+		handleError(err, w, log)
+		return
+	}
+
+	positions, err := validation.CreatePositions(collectionID, results, userProfile.ID, s.store)
+	if err != nil {
+		handleError(err, w, log)
+		return
+	}
+
+	jsonBytes, err := json.Marshal(results)
+	if err != nil {
+		validation.NewErrorResponse(http.StatusInternalServerError).WriteHTTPError(w)
+		return
+	}
+
+	// Publish collection and tracker data in the order the positions were recorded
+	sort.SliceStable(positions, func(i, j int) bool {
+		return positions[i].Timestamp < positions[j].Timestamp
+	})
+	for _, position := range positions {
+		s.manager.Publish(
+			topic.NewEntityTopic(topic.Collection, collectionID, topic.DataEvents),
+			event.NewPositionEvent(collectionID, position),
+		)
+		s.manager.Publish(
+			topic.NewEntityTopic(topic.Tracker, position.TrackerID, topic.DataEvents),
+			event.NewPositionEvent(collectionID, position),
+		)
+	}
+
+	status := http.StatusCreated
+	for _, result := range results {
+		if result.Error != nil {
+			status = http.StatusMultiStatus
+			break
+		}
+	}
+
+	w.WriteHeader(status)
+	_, _ = w.Write(jsonBytes)
+}
+
 func (s *Server) getTrackerPosition(w http.ResponseWriter, r *http.Request) {
 	log := s.RequestLogger(r)
 	userProfile := s.UserFromRequest(r)
@@ -295,12 +384,20 @@ func (s *Server) listTrackerPositions(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	positions, err := validation.ListPositionsByTrackerID(trackerID, userProfile.ID, filterParams, s.store)
+	positions, nextCursor, err := validation.ListPositionsByTrackerID(trackerID, userProfile.ID, filterParams, s.store)
 	if err != nil {
 		handleError(err, w, log)
 		return
 	}
 
+	// Link to the next page by cursor, keeping the rest of the query
+	if nextCursor != "" {
+		query := r.URL.Query()
+		query.Del("offset")
+		query.Set("cursor", nextCursor)
+		w.Header().Set("Link", fmt.Sprintf("<%s?%s>; rel=\"next\"", r.URL.Path, query.Encode()))
+	}
+
 	jsonBytes, err := json.Marshal(positions)
 	if err != nil {
 		validation.NewErrorResponse(http.StatusInternalServerError).WriteHTTPError(w)
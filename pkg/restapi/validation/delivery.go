@@ -0,0 +1,84 @@
+package validation
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/eesrc/geo/pkg/restapi/service"
+	"github.com/eesrc/geo/pkg/store"
+	"github.com/eesrc/geo/pkg/store/errors"
+	"github.com/eesrc/geo/pkg/sub"
+)
+
+// GetDeliveryID returns a DeliveryID from given HandlerParameterMap. If missing or corrupt, returns a
+// validationError
+func GetDeliveryID(handlerParams HandlerParameterMap) (int64, error) {
+	deliveryID, err := handlerParams.DeliveryID()
+	if err != nil {
+		return -1, newError(NewErrorResponse(
+			http.StatusBadRequest,
+			NewParameterErrorDetail("deliveryId", fmt.Sprintf("The delivery id '%s' is malformed", handlerParams["deliveryID"])),
+		))
+	}
+
+	return deliveryID, nil
+}
+
+// GetDelivery returns a delivery of the subscription from store. Returns a validation error or regular error
+// if the fetch fails. Access to the subscription must be validated before calling.
+func GetDelivery(subscriptionID int64, deliveryID int64, store store.Store) (*service.Delivery, error) {
+	delivery, err := store.GetDelivery(subscriptionID, deliveryID)
+
+	if err != nil {
+		if storageError, ok := err.(*errors.StorageError); ok {
+			if storageError.Type == errors.NotFoundError {
+				return &service.Delivery{}, newError(NewErrorResponse(
+					http.StatusNotFound,
+					NewParameterErrorDetail("deliveryId", fmt.Sprintf("The delivery with id '%d' might not exist", deliveryID)),
+				))
+			}
+		}
+
+		return &service.Delivery{}, err
+	}
+
+	return service.NewDeliveryFromModel(delivery), nil
+}
+
+// ListDeliveriesBySubscriptionID lists the deliveries of the subscription with the given status. An empty
+// status lists all deliveries. Access to the subscription must be validated before calling.
+func ListDeliveriesBySubscriptionID(subscriptionID int64, status sub.DeliveryStatus, filterParams FilterParams, store store.Store) ([]*service.Delivery, error) {
+	deliveries, err := store.ListDeliveriesBySubscriptionID(subscriptionID, string(status), filterParams.Offset, filterParams.Limit)
+	if err != nil {
+		return []*service.Delivery{}, err
+	}
+
+	var deliveryList []*service.Delivery = make([]*service.Delivery, len(deliveries))
+
+	for i, delivery := range deliveries {
+		deliveryList[i] = service.NewDeliveryFromModel(&delivery)
+	}
+
+	return deliveryList, nil
+}
+
+// GetDeliveryStatusFromQueryParams returns the optional delivery status from the query parameters.
+// Returns a validation error if the status is unknown
+func GetDeliveryStatusFromQueryParams(values url.Values) (sub.DeliveryStatus, error) {
+	status := sub.DeliveryStatus(values.Get("status"))
+	if status == "" {
+		return status, nil
+	}
+
+	for _, validStatus := range sub.ValidDeliveryStatuses {
+		if validStatus == status {
+			return status, nil
+		}
+	}
+
+	return status, newError(NewErrorResponse(
+		http.StatusBadRequest,
+		NewParameterErrorDetail("status", fmt.Sprintf("The delivery status '%s' is not valid. Valid statuses are %v", status, sub.ValidDeliveryStatuses)),
+	))
+}
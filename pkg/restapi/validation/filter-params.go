@@ -1,11 +1,16 @@
 package validation
 
 import (
+	"encoding/base64"
 	"fmt"
+	"math"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
 	"time"
+
+	"github.com/eesrc/geo/pkg/model"
 )
 
 const (
@@ -13,12 +18,70 @@ const (
 	defaultLimit = 255
 )
 
-// FilterParams contains parameters regarding listing normal list parameters
+// FilterParams contains parameters regarding listing normal list parameters. Since and Until
+// are unix timestamps in milliseconds.
 type FilterParams struct {
 	Since  int64
 	Until  int64
 	Limit  int64
 	Offset int64
+	// Cursor is where the page starts when paging by cursor instead of offset. It's nil unless
+	// a cursor is provided.
+	Cursor *model.PositionCursor
+}
+
+// SinceNano returns Since in nanoseconds
+func (filterParams FilterParams) SinceNano() int64 {
+	return milliToNanoSeconds(filterParams.Since)
+}
+
+// UntilNano returns Until in nanoseconds, including the whole millisecond of Until
+func (filterParams FilterParams) UntilNano() int64 {
+	if filterParams.Until >= math.MaxInt64/int64(time.Millisecond) {
+		return math.MaxInt64
+	}
+	return milliToNanoSeconds(filterParams.Until+1) - 1
+}
+
+// EncodeCursor returns the cursor as the opaque string used by the cursor query parameter
+func EncodeCursor(cursor model.PositionCursor) string {
+	return base64.RawURLEncoding.EncodeToString(
+		[]byte(strconv.FormatInt(cursor.Timestamp, 10) + "," + strconv.FormatInt(cursor.ID, 10)),
+	)
+}
+
+func decodeCursor(value string) (*model.PositionCursor, error) {
+	decoded, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+
+	parts := strings.Split(string(decoded), ",")
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("expected timestamp and id in cursor")
+	}
+
+	var cursor model.PositionCursor
+	if cursor.Timestamp, err = strconv.ParseInt(parts[0], 10, 64); err != nil {
+		return nil, err
+	}
+	if cursor.ID, err = strconv.ParseInt(parts[1], 10, 64); err != nil {
+		return nil, err
+	}
+
+	return &cursor, nil
+}
+
+// milliToNanoSeconds converts milliseconds to nanoseconds, clamping to the range of int64
+func milliToNanoSeconds(milli int64) int64 {
+	switch {
+	case milli > math.MaxInt64/int64(time.Millisecond):
+		return math.MaxInt64
+	case milli < math.MinInt64/int64(time.Millisecond):
+		return math.MinInt64
+	default:
+		return milli * int64(time.Millisecond)
+	}
 }
 
 // NewFilterParamsFromQueryParams returns FilterParams from given Query values.
@@ -91,6 +154,29 @@ func NewFilterParamsFromQueryParams(values url.Values) (FilterParams, error) {
 		}
 	}
 
+	if cursor, ok := parameterMap["cursor"]; ok {
+		if _, hasOffset := parameterMap["offset"]; hasOffset {
+			return filterParams, newError(
+				NewErrorResponse(
+					http.StatusBadRequest,
+					NewParameterErrorDetail("offset", "Offset can't be combined with a cursor"),
+				),
+			)
+		}
+
+		decodedCursor, err := decodeCursor(cursor)
+		if err != nil {
+			return filterParams, newError(
+				NewErrorResponse(
+					http.StatusBadRequest,
+					NewParameterErrorDetail("cursor", "The provided cursor is not valid"),
+				),
+			)
+		}
+
+		filterParams.Cursor = decodedCursor
+	}
+
 	// Validate dates
 	if filterParams.Since > filterParams.Until {
 		return filterParams, newError(
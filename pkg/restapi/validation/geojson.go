@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
+	"math"
 	"net/http"
 
 	"github.com/eesrc/geo/pkg/tria/geometry"
@@ -44,6 +45,17 @@ func GetShapesFromBody(body io.ReadCloser) ([]geometry.Shape, error) {
 		)
 	}
 
+	for i, feature := range featureCollection.Features {
+		if !validCorridorWidth(feature) {
+			return []geometry.Shape{}, newError(
+				NewErrorResponse(
+					http.StatusBadRequest,
+					NewParameterErrorDetail(fmt.Sprintf("featureCollection.features[%d].properties.width", i), corridorWidthError),
+				),
+			)
+		}
+	}
+
 	// Try to triangulate input GeoJSON.
 	shapes, err := triangulation.TriangulateGeoJSONFeatureCollectionToShapes(featureCollection, gj.WGS84Projection)
 
@@ -94,6 +106,15 @@ func GetShapeFromBody(body io.ReadCloser) (geometry.Shape, error) {
 		)
 	}
 
+	if !validCorridorWidth(feature) {
+		return &geometry.Polygon{}, newError(
+			NewErrorResponse(
+				http.StatusBadRequest,
+				NewParameterErrorDetail("feature.properties.width", corridorWidthError),
+			),
+		)
+	}
+
 	// Try to triangulate input GeoJSON.
 	shape, err := triangulation.TriangulateGeoJSONFeatureToShape(feature, gj.WGS84Projection)
 
@@ -108,3 +129,22 @@ func GetShapeFromBody(body io.ReadCloser) (geometry.Shape, error) {
 
 	return shape, nil
 }
+
+const corridorWidthError = "The width of a corridor needs to be a number of metres above 0"
+
+// validCorridorWidth checks the width property of line strings, which are buffered to corridors of the
+// width. A width that isn't a finite number above 0 would give an empty or unbounded corridor. Features
+// without a width get the default width.
+func validCorridorWidth(feature *geojson.Feature) bool {
+	if feature.Geometry == nil || !feature.Geometry.IsLineString() || feature.PropertyMustBool("tripwire", false) {
+		return true
+	}
+
+	if _, ok := feature.Properties["width"]; !ok {
+		return true
+	}
+
+	width, err := feature.PropertyFloat64("width")
+
+	return err == nil && width > 0 && !math.IsInf(width, 1)
+}
@@ -58,6 +58,11 @@ func (parameterMap *HandlerParameterMap) SubscriptionID() (int64, error) {
 	return parameterMap.AsInt64("subscriptionID")
 }
 
+// DeliveryID retrieves the DeliveryID from the parameterMap
+func (parameterMap *HandlerParameterMap) DeliveryID() (int64, error) {
+	return parameterMap.AsInt64("deliveryID")
+}
+
 // AsInt64 returns the parameter as an int64
 func (parameterMap *HandlerParameterMap) AsInt64(id string) (int64, error) {
 	v, ok := (*parameterMap)[id]
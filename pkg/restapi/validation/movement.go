@@ -0,0 +1,117 @@
+package validation
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/eesrc/geo/pkg/model"
+	"github.com/eesrc/geo/pkg/restapi/service"
+	"github.com/eesrc/geo/pkg/store"
+	"github.com/eesrc/geo/pkg/sub"
+)
+
+// MovementFormat is the output format of the movement history
+type MovementFormat string
+
+// The available output formats of the movement history
+const (
+	MovementFormatJSON    MovementFormat = "json"
+	MovementFormatCSV     MovementFormat = "csv"
+	MovementFormatGeoJSON MovementFormat = "geojson"
+)
+
+var validMovementFormats = []MovementFormat{MovementFormatJSON, MovementFormatCSV, MovementFormatGeoJSON}
+
+// GetMovementFilterFromQueryParams returns the optional tracker, shape and movement type filters of the
+// movement history from the query parameters. Returns a validation error if a filter is malformed
+func GetMovementFilterFromQueryParams(values url.Values) (model.MovementFilter, error) {
+	parameterMap := make(HandlerParameterMap)
+
+	for key, val := range values {
+		parameterMap[key] = strings.Join(val, ",")
+	}
+
+	var filter model.MovementFilter
+
+	if trackerID, err := parameterMap.AsInt64("trackerId"); err == nil {
+		if trackerID < 1 {
+			return filter, getTooLowValidationError("trackerId")
+		}
+
+		filter.TrackerID = trackerID
+	} else {
+		if _, ok := err.(*KeyNotFoundError); !ok {
+			return filter, getNonNumberValidationError("trackerId")
+		}
+	}
+
+	if shapeID, err := parameterMap.AsInt64("shapeId"); err == nil {
+		if shapeID < 1 {
+			return filter, getTooLowValidationError("shapeId")
+		}
+
+		filter.ShapeID = shapeID
+	} else {
+		if _, ok := err.(*KeyNotFoundError); !ok {
+			return filter, getNonNumberValidationError("shapeId")
+		}
+	}
+
+	if movement := parameterMap["movement"]; movement != "" {
+		for _, validMovementType := range sub.ValidMovementTypes {
+			if string(validMovementType) == movement {
+				filter.Movement = movement
+				return filter, nil
+			}
+		}
+
+		return filter, newError(NewErrorResponse(
+			http.StatusBadRequest,
+			NewParameterErrorDetail("movement", fmt.Sprintf("The movement type '%s' is not valid. Valid types are %v", movement, sub.ValidMovementTypes)),
+		))
+	}
+
+	return filter, nil
+}
+
+// GetMovementFormatFromQueryParams returns the output format of the movement history from the query
+// parameters. The default format is JSON. Returns a validation error if the format is unknown
+func GetMovementFormatFromQueryParams(values url.Values) (MovementFormat, error) {
+	format := MovementFormat(values.Get("format"))
+	if format == "" {
+		return MovementFormatJSON, nil
+	}
+
+	for _, validFormat := range validMovementFormats {
+		if validFormat == format {
+			return format, nil
+		}
+	}
+
+	return format, newError(NewErrorResponse(
+		http.StatusBadRequest,
+		NewParameterErrorDetail("format", fmt.Sprintf("The format '%s' is not valid. Valid formats are %v", format, validMovementFormats)),
+	))
+}
+
+// ListMovementsBySubscriptionID lists the movement history of the subscription within the time range of the
+// filter params, newest first. Access to the subscription must be validated before calling.
+func ListMovementsBySubscriptionID(subscriptionID int64, filterParams FilterParams, filter model.MovementFilter, store store.Store) ([]*service.Movement, error) {
+	filter.Since = filterParams.SinceNano()
+	filter.Until = filterParams.UntilNano()
+
+	positionMovements, err := store.ListPositionMovementsBySubscriptionID(subscriptionID, filter, filterParams.Offset, filterParams.Limit)
+	if err != nil {
+		return []*service.Movement{}, err
+	}
+
+	var movementList []*service.Movement = make([]*service.Movement, len(positionMovements))
+
+	for i, positionMovement := range positionMovements {
+		movementList[i] = service.NewMovementFromModel(&positionMovement)
+	}
+
+	return movementList, nil
+}
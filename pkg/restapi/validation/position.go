@@ -1,10 +1,13 @@
 package validation
 
 import (
+	"bufio"
+	"bytes"
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io"
+	"mime"
 	"net/http"
 
 	"github.com/eesrc/geo/pkg/model"
@@ -85,6 +88,28 @@ func CreatePosition(position *model.Position, userID int64, store store.Store) (
 	return newPositionID, err
 }
 
+// IsDuplicatePositionError returns true if a position couldn't be created since an exact duplicate of it
+// was stored concurrently, after the check for duplicates
+func IsDuplicatePositionError(err error) bool {
+	storageError, ok := err.(*errors.StorageError)
+	return ok && storageError.Type == errors.AlreadyExistsError
+}
+
+// GetDuplicatePosition returns the stored position with the same tracker, timestamp and coordinates as the
+// given position, or nil if there's none. Returns a regular error if the fetch fails
+func GetDuplicatePosition(position *model.Position, userID int64, store store.Store) (*service.Position, error) {
+	duplicate, err := store.GetDuplicatePositionByUserID(position, userID)
+	if err != nil {
+		if storageError, ok := err.(*errors.StorageError); ok && storageError.Type == errors.NotFoundError {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return service.NewPositionFromModel(duplicate), nil
+}
+
 // DeletePosition tries to delete a position and returns a validation error or regular error
 // if the delete fails
 func DeletePosition(positionID int64, userID int64, store store.Store) error {
@@ -107,9 +132,18 @@ func DeletePosition(positionID int64, userID int64, store store.Store) error {
 	return err
 }
 
-// ListPositionsByTrackerID lists collections based on userID and returns a store error if the list fails
-func ListPositionsByTrackerID(trackerID int64, userID int64, filterParams FilterParams, store store.Store) ([]*service.Position, error) {
-	positions, err := store.ListPositionsByTrackerID(trackerID, userID, filterParams.Offset, filterParams.Limit)
+// ListPositionsByTrackerID lists the positions of a tracker within the time range of the filter params, starting
+// at the cursor if provided. Along with the positions it returns the cursor of the next page, which is empty
+// when there are no more positions. Returns a store error if the list fails
+func ListPositionsByTrackerID(trackerID int64, userID int64, filterParams FilterParams, store store.Store) ([]*service.Position, string, error) {
+	var positions []model.Position
+	var err error
+
+	if filterParams.Cursor != nil {
+		positions, err = store.ListPositionsByTrackerIDBeforeCursor(trackerID, userID, filterParams.SinceNano(), *filterParams.Cursor, filterParams.Limit)
+	} else {
+		positions, err = store.ListPositionsByTrackerID(trackerID, userID, filterParams.SinceNano(), filterParams.UntilNano(), filterParams.Offset, filterParams.Limit)
+	}
 
 	// Check if there's a reason to create a validationError
 	if err != nil {
@@ -117,14 +151,14 @@ func ListPositionsByTrackerID(trackerID int64, userID int64, filterParams Filter
 			switch storageError.Type {
 			// AccessDenied and NotFound are handled the same
 			case errors.AccessDeniedError, errors.NotFoundError:
-				return []*service.Position{}, newError(NewErrorResponse(
+				return []*service.Position{}, "", newError(NewErrorResponse(
 					http.StatusNotFound,
 					NewParameterErrorDetail("trackerId", fmt.Sprintf("The tracker id '%d' might not exist", trackerID)),
 				))
 			}
 		}
 
-		return []*service.Position{}, err
+		return []*service.Position{}, "", err
 	}
 
 	var positionList []*service.Position = make([]*service.Position, len(positions))
@@ -133,7 +167,14 @@ func ListPositionsByTrackerID(trackerID int64, userID int64, filterParams Filter
 		positionList[i] = service.NewPositionFromModel(&position)
 	}
 
-	return positionList, nil
+	// A full page might be followed by more positions
+	var nextCursor string
+	if len(positions) > 0 && int64(len(positions)) == filterParams.Limit {
+		last := positions[len(positions)-1]
+		nextCursor = EncodeCursor(model.PositionCursor{Timestamp: last.Timestamp, ID: last.ID})
+	}
+
+	return positionList, nextCursor, nil
 }
 
 // GetPositionFromBody retrieves a position from given body and decodes it.
@@ -144,26 +185,241 @@ func GetPositionFromBody(body io.ReadCloser) (*service.Position, error) {
 
 	err := jsonDecoder.Decode(&position)
 	if err != nil {
-		switch err := err.(type) {
-		case *json.UnmarshalTypeError:
-			return &position, getUnmarshalError(err)
-		case base64.CorruptInputError:
-			return &position, newError(
+		return &position, getPositionDecodeError(err)
+	}
+
+	return &position, validatePosition(&position)
+}
+
+// PositionBatchResult is the result of a single position in a batch of positions. It holds either the
+// position or the error which kept the position from being created. Duplicate is set when the position
+// is already stored, or given earlier in the batch, and holds the stored position.
+type PositionBatchResult struct {
+	Index     int               `json:"index"`
+	Position  *service.Position `json:"position,omitempty"`
+	Duplicate bool              `json:"duplicate,omitempty"`
+	Error     *ErrorResponse    `json:"error,omitempty"`
+}
+
+const (
+	maxPositionBatchSize = 10000
+	maxNDJSONLineLength  = 1024 * 1024
+)
+
+// GetPositionsFromBody retrieves a batch of positions from given body, either as a JSON array or as
+// newline delimited JSON when the content type is application/x-ndjson. Positions which can't be decoded
+// or are invalid get an error result at their index. Returns a validation error containing an
+// ErrorResponse if the batch itself is malformed
+func GetPositionsFromBody(body io.Reader, contentType string) ([]PositionBatchResult, error) {
+	var items []json.RawMessage
+	var err error
+
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	if mediaType == "application/x-ndjson" || mediaType == "application/ndjson" {
+		items, err = readNDJSON(body)
+	} else {
+		err = json.NewDecoder(body).Decode(&items)
+	}
+
+	if err != nil {
+		return []PositionBatchResult{}, newError(
+			NewErrorResponse(
+				http.StatusBadRequest,
+				NewParameterErrorDetail("positions", "You need to provide an array or newline delimited JSON of positions"),
+			),
+		)
+	}
+
+	if len(items) == 0 || len(items) > maxPositionBatchSize {
+		return []PositionBatchResult{}, newError(
+			NewErrorResponse(
+				http.StatusBadRequest,
+				NewParameterErrorDetail("positions", fmt.Sprintf("You need to provide between 1 and %d positions", maxPositionBatchSize)),
+			),
+		)
+	}
+
+	results := make([]PositionBatchResult, len(items))
+	for i, item := range items {
+		results[i].Index = i
+
+		position := service.NewPosition()
+		err := json.Unmarshal(item, &position)
+		if err != nil {
+			err = getPositionDecodeError(err)
+		} else if err = validatePosition(&position); err == nil && position.TrackerID == 0 {
+			err = newError(
 				NewErrorResponse(
 					http.StatusBadRequest,
-					NewParameterErrorDetail("payload", "The payload provided needs to be base64 encoded"),
+					NewParameterErrorDetail("trackerId", "The tracker id of the position must be set"),
 				),
 			)
-		default:
-			return &position, newError(
-				NewErrorResponse(
-					http.StatusBadRequest,
-					NewParameterErrorDetail("position", "You need to provide a valid position object"),
-				),
+		}
+
+		if err != nil {
+			results[i].Error = err.(*Error).ErrorResponse
+			continue
+		}
+
+		results[i].Position = &position
+	}
+
+	return results, nil
+}
+
+// readNDJSON reads one JSON value per line, skipping blank lines
+func readNDJSON(body io.Reader) ([]json.RawMessage, error) {
+	var items []json.RawMessage
+
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 64*1024), maxNDJSONLineLength)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		items = append(items, json.RawMessage(append([]byte{}, line...)))
+	}
+
+	return items, scanner.Err()
+}
+
+// CreatePositions creates the positions of a batch without an error in one transaction. Positions of
+// trackers outside the collection get an error result instead, while duplicates of stored positions or
+// of positions earlier in the batch are dropped. The results get the created positions, which are
+// returned in the order of the batch. Returns a validation error or regular error if the creation fails
+func CreatePositions(collectionID int64, results []PositionBatchResult, userID int64, store store.Store) ([]model.Position, error) {
+	positions, err := createPositions(collectionID, results, userID, store)
+
+	// Duplicates stored concurrently by another request fail the whole transaction, checking the
+	// batch again drops them like any other duplicate
+	if IsDuplicatePositionError(err) {
+		positions, err = createPositions(collectionID, results, userID, store)
+	}
+
+	return positions, err
+}
+
+func createPositions(collectionID int64, results []PositionBatchResult, userID int64, store store.Store) ([]model.Position, error) {
+	var positions []model.Position
+	var resultIndexes []int
+
+	// Duplicates within the batch refer to the result of the first of them
+	type positionKey struct {
+		trackerID int64
+		timestamp int64
+		lat       float64
+		lon       float64
+	}
+	firstResults := make(map[positionKey]int)
+	duplicateOf := make(map[int]int)
+
+	trackerInCollection := make(map[int64]bool)
+	for i := range results {
+		if results[i].Error != nil {
+			continue
+		}
+
+		trackerID := results[i].Position.TrackerID
+		inCollection, checked := trackerInCollection[trackerID]
+		if !checked {
+			tracker, err := store.GetTrackerByUserID(trackerID, userID)
+			if err != nil {
+				if storageError, ok := err.(*errors.StorageError); !ok ||
+					(storageError.Type != errors.AccessDeniedError && storageError.Type != errors.NotFoundError) {
+					return []model.Position{}, err
+				}
+			}
+
+			inCollection = err == nil && tracker.CollectionID == collectionID
+			trackerInCollection[trackerID] = inCollection
+		}
+
+		if !inCollection {
+			results[i].Position = nil
+			results[i].Error = NewErrorResponse(
+				http.StatusNotFound,
+				NewParameterErrorDetail("trackerId", fmt.Sprintf("The tracker with id '%d' might not exist", trackerID)),
 			)
+			continue
+		}
+
+		position := results[i].Position.ToModel()
+		key := positionKey{position.TrackerID, position.Timestamp, position.Lat, position.Lon}
+		if first, ok := firstResults[key]; ok {
+			results[i].Duplicate = true
+			duplicateOf[i] = first
+			continue
 		}
+		firstResults[key] = i
+
+		duplicate, err := GetDuplicatePosition(position, userID, store)
+		if err != nil {
+			return []model.Position{}, err
+		}
+		if duplicate != nil {
+			results[i].Position = duplicate
+			results[i].Duplicate = true
+			continue
+		}
+
+		positions = append(positions, *position)
+		resultIndexes = append(resultIndexes, i)
 	}
 
+	if len(positions) > 0 {
+		positionIDs, err := store.CreatePositions(positions, userID)
+		if err != nil {
+			if storageError, ok := err.(*errors.StorageError); ok {
+				switch storageError.Type {
+				// AccessDenied and NotFound are handled the same
+				case errors.AccessDeniedError, errors.NotFoundError:
+					return []model.Position{}, newError(NewErrorResponse(
+						http.StatusNotFound,
+						NewParameterErrorDetail("trackerId", "One of the trackers might not exist"),
+					))
+				}
+			}
+
+			return []model.Position{}, err
+		}
+
+		for n, i := range resultIndexes {
+			positions[n].ID = positionIDs[n]
+			results[i].Position = service.NewPositionFromModel(&positions[n])
+		}
+	}
+
+	for i, first := range duplicateOf {
+		results[i].Position = results[first].Position
+	}
+
+	return positions, nil
+}
+
+func getPositionDecodeError(err error) error {
+	switch err := err.(type) {
+	case *json.UnmarshalTypeError:
+		return getUnmarshalError(err)
+	case base64.CorruptInputError:
+		return newError(
+			NewErrorResponse(
+				http.StatusBadRequest,
+				NewParameterErrorDetail("payload", "The payload provided needs to be base64 encoded"),
+			),
+		)
+	default:
+		return newError(
+			NewErrorResponse(
+				http.StatusBadRequest,
+				NewParameterErrorDetail("position", "You need to provide a valid position object"),
+			),
+		)
+	}
+}
+
+// validatePosition checks the fields of a decoded position and sets defaults for the optional fields
+func validatePosition(position *service.Position) error {
 	var fieldErrors []ErrorDetail
 
 	if position.Lat == nil {
@@ -179,6 +435,16 @@ func GetPositionFromBody(body io.ReadCloser) (*service.Position, error) {
 		position.Alt = &alt
 	}
 
+	if position.Heading == nil {
+		heading := 0.0
+		position.Heading = &heading
+	}
+
+	if position.Speed == nil {
+		speed := 0.0
+		position.Speed = &speed
+	}
+
 	if position.Precision == nil {
 		precision := 1.0
 		position.Precision = &precision
@@ -189,7 +455,7 @@ func GetPositionFromBody(body io.ReadCloser) (*service.Position, error) {
 	}
 
 	if len(fieldErrors) > 0 {
-		return &position, newError(
+		return newError(
 			NewErrorResponse(
 				http.StatusBadRequest,
 				fieldErrors...,
@@ -197,5 +463,5 @@ func GetPositionFromBody(body io.ReadCloser) (*service.Position, error) {
 		)
 	}
 
-	return &position, nil
+	return nil
 }
@@ -0,0 +1,80 @@
+package validation
+
+import (
+	"encoding/json"
+	"io"
+	"math"
+	"net/http"
+	"time"
+
+	"github.com/eesrc/geo/pkg/model"
+	"github.com/eesrc/geo/pkg/restapi/service"
+	"github.com/eesrc/geo/pkg/store"
+)
+
+// GetReplayFromBody retrieves a replay from given body and decodes it. The body is optional, by default
+// every stored position up until now is replayed without emitting triggers. Until is capped to now as
+// positions arriving while the replay runs are held and handled by the subscription once it resumes.
+// Returns a validation error containing an ErrorResponse if something went wrong
+func GetReplayFromBody(body io.ReadCloser) (*service.Replay, error) {
+	now := time.Now().UnixNano() / int64(time.Millisecond)
+	replay := service.Replay{
+		Since: 0,
+		Until: now,
+	}
+
+	err := json.NewDecoder(body).Decode(&replay)
+	if err != nil && err != io.EOF {
+		return &replay, newError(
+			NewErrorResponse(
+				http.StatusBadRequest,
+				NewParameterErrorDetail("replay", "You need to provide a valid replay object"),
+			),
+		)
+	}
+
+	if replay.Until > now {
+		replay.Until = now
+	}
+
+	if replay.Since > replay.Until {
+		return &replay, newError(
+			NewErrorResponse(
+				http.StatusBadRequest,
+				NewParameterErrorDetail("since", "Provided since is after until"),
+				NewParameterErrorDetail("until", "Provided until is before since"),
+			),
+		)
+	}
+
+	return &replay, nil
+}
+
+// ListReplayPositions lists every position of the tracker with a timestamp from since to until, both
+// inclusive and in nanoseconds, oldest first
+func ListReplayPositions(trackerID int64, userID int64, since int64, until int64, store store.Store) ([]model.Position, error) {
+	var positions []model.Position
+
+	cursor := model.PositionCursor{Timestamp: until, ID: math.MaxInt64}
+	for {
+		page, err := store.ListPositionsByTrackerIDBeforeCursor(trackerID, userID, since, cursor, maxLimit)
+		if err != nil {
+			return []model.Position{}, err
+		}
+
+		positions = append(positions, page...)
+
+		if int64(len(page)) < maxLimit {
+			break
+		}
+
+		last := page[len(page)-1]
+		cursor = model.PositionCursor{Timestamp: last.Timestamp, ID: last.ID}
+	}
+
+	for i, j := 0, len(positions)-1; i < j; i, j = i+1, j-1 {
+		positions[i], positions[j] = positions[j], positions[i]
+	}
+
+	return positions, nil
+}
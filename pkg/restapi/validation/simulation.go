@@ -0,0 +1,156 @@
+package validation
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/eesrc/geo/pkg/model"
+	"github.com/eesrc/geo/pkg/restapi/service"
+	"github.com/eesrc/geo/pkg/store"
+	"github.com/eesrc/geo/pkg/sub"
+)
+
+// maxSimulationPositions is the maximum number of positions run through a simulated subscription
+const maxSimulationPositions = maxPositionBatchSize
+
+// Simulation is the request body of a subscription simulation. It holds either a time range in
+// milliseconds for the stored positions of the trackable or a list of positions to simulate.
+type Simulation struct {
+	Since     *int64            `json:"since"`
+	Until     *int64            `json:"until"`
+	Positions []json.RawMessage `json:"positions"`
+}
+
+// GetSimulationFromBody retrieves a simulation from given body and decodes it.
+// Returns a validation error containing an ErrorResponse if something went wrong
+func GetSimulationFromBody(body io.ReadCloser) (*Simulation, error) {
+	var simulation Simulation
+
+	err := json.NewDecoder(body).Decode(&simulation)
+	if err != nil {
+		return &simulation, newError(
+			NewErrorResponse(
+				http.StatusBadRequest,
+				NewParameterErrorDetail("simulation", "You need to provide a time range or a list of positions"),
+			),
+		)
+	}
+
+	if simulation.Positions != nil && (simulation.Since != nil || simulation.Until != nil) {
+		return &simulation, newError(
+			NewErrorResponse(
+				http.StatusBadRequest,
+				NewParameterErrorDetail("positions", "Positions can't be combined with a time range"),
+			),
+		)
+	}
+
+	if simulation.Positions != nil && (len(simulation.Positions) == 0 || len(simulation.Positions) > maxSimulationPositions) {
+		return &simulation, newError(
+			NewErrorResponse(
+				http.StatusBadRequest,
+				NewParameterErrorDetail("positions", fmt.Sprintf("You need to provide between 1 and %d positions", maxSimulationPositions)),
+			),
+		)
+	}
+
+	if simulation.Since != nil && simulation.Until != nil && *simulation.Since > *simulation.Until {
+		return &simulation, newError(
+			NewErrorResponse(
+				http.StatusBadRequest,
+				NewParameterErrorDetail("since", "Provided since is after until"),
+				NewParameterErrorDetail("until", "Provided until is before since"),
+			),
+		)
+	}
+
+	return &simulation, nil
+}
+
+// GetSimulationPositions returns the positions to run through the simulated subscription. These are
+// either the positions given in the simulation or the stored positions of the trackable of the
+// subscription within the time range. Returns a validation error or regular error if the positions
+// are invalid or the list fails
+func GetSimulationPositions(subscription *service.Subscription, simulation *Simulation, userID int64, store store.Store) ([]model.Position, error) {
+	if simulation.Positions != nil {
+		return getSimulationPositionsFromBody(subscription, simulation)
+	}
+
+	filterParams := FilterParams{
+		Since: 0,
+		Until: time.Now().UnixNano() / int64(time.Millisecond),
+	}
+	if simulation.Since != nil {
+		filterParams.Since = *simulation.Since
+	}
+	if simulation.Until != nil {
+		filterParams.Until = *simulation.Until
+	}
+
+	trackerIDs, err := ListSubscriptionTrackerIDs(subscription, userID, store)
+	if err != nil {
+		return []model.Position{}, err
+	}
+
+	var positions []model.Position
+	for _, trackerID := range trackerIDs {
+		trackerPositions, err := store.ListPositionsByTrackerID(
+			trackerID,
+			userID,
+			filterParams.SinceNano(),
+			filterParams.UntilNano(),
+			0,
+			int64(maxSimulationPositions-len(positions)+1),
+		)
+		if err != nil {
+			return []model.Position{}, err
+		}
+
+		positions = append(positions, trackerPositions...)
+		if len(positions) > maxSimulationPositions {
+			return []model.Position{}, newError(
+				NewErrorResponse(
+					http.StatusBadRequest,
+					NewParameterErrorDetail("since", fmt.Sprintf("The time range holds more than %d positions", maxSimulationPositions)),
+				),
+			)
+		}
+	}
+
+	return positions, nil
+}
+
+// getSimulationPositionsFromBody decodes and validates the positions of the simulation. Positions without a
+// tracker belong to the tracker of a tracker subscription.
+func getSimulationPositionsFromBody(subscription *service.Subscription, simulation *Simulation) ([]model.Position, error) {
+	positions := make([]model.Position, len(simulation.Positions))
+
+	for i, item := range simulation.Positions {
+		position := service.NewPosition()
+
+		err := json.Unmarshal(item, &position)
+		if err == nil {
+			err = validatePosition(&position)
+		}
+
+		if err != nil {
+			return []model.Position{}, newError(
+				NewErrorResponse(
+					http.StatusBadRequest,
+					NewParameterErrorDetail(fmt.Sprintf("positions[%d]", i), "You need to provide a valid position object"),
+				),
+			)
+		}
+
+		if position.TrackerID == 0 && subscription.Trackable.Type == sub.Tracker {
+			position.TrackerID = *subscription.Trackable.ID
+		}
+
+		positions[i] = *position.ToModel()
+	}
+
+	return positions, nil
+}
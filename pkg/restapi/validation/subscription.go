@@ -11,6 +11,7 @@ import (
 	"github.com/eesrc/geo/pkg/store"
 	"github.com/eesrc/geo/pkg/store/errors"
 	"github.com/eesrc/geo/pkg/sub"
+	"github.com/eesrc/geo/pkg/sub/output"
 )
 
 // GetSubscriptionID returns a SubscriptionID from given HandlerParameterMap. If missing or corrupt, returns a
@@ -182,6 +183,30 @@ func ListSubscriptionsByCollectionID(collectionID int64, userID int64, filterPar
 	return subscriptionList, nil
 }
 
+// ListSubscriptionTrackerIDs lists the IDs of the trackers followed by the subscription, which is either the
+// tracker of a tracker subscription or every tracker in the collection of a collection subscription
+func ListSubscriptionTrackerIDs(subscription *service.Subscription, userID int64, store store.Store) ([]int64, error) {
+	if subscription.Trackable.Type != sub.Collection {
+		return []int64{*subscription.Trackable.ID}, nil
+	}
+
+	var trackerIDs []int64
+	for offset := int64(0); ; offset += maxLimit {
+		trackers, err := store.ListTrackersByCollectionID(*subscription.Trackable.ID, userID, offset, maxLimit)
+		if err != nil {
+			return []int64{}, err
+		}
+
+		for _, tracker := range trackers {
+			trackerIDs = append(trackerIDs, tracker.ID)
+		}
+
+		if int64(len(trackers)) < maxLimit {
+			return trackerIDs, nil
+		}
+	}
+}
+
 // GetSubscriptionFromBody retrieves a subscription from given body and decodes it.
 // Returns a validation error containing an ErrorResponse if something went wrong
 func GetSubscriptionFromBody(body io.ReadCloser) (*service.Subscription, error) {
@@ -215,6 +240,8 @@ func GetSubscriptionFromBody(body io.ReadCloser) (*service.Subscription, error)
 			"subscription.output",
 			fmt.Sprintf("The output '%s' is not valid. Available outputs are: %s", subscription.Output, strings.Join(validOutputs, ", "))),
 		)
+	} else if err := output.ValidateConfig(subscription.Output.Type, subscription.Output.Config); err != nil {
+		fieldErrors = append(fieldErrors, NewParameterErrorDetail("subscription.output.config", err.Error()))
 	}
 
 	if !isValidTrackableType(subscription.Trackable.Type) {
@@ -245,6 +272,27 @@ func GetSubscriptionFromBody(body io.ReadCloser) (*service.Subscription, error)
 		)
 	}
 
+	if subscription.TriggerCriteria.DwellTime < 0 {
+		fieldErrors = append(fieldErrors, NewParameterErrorDetail("subscription.triggerCriteria.dwellTime", "The dwell time can't be negative"))
+	} else if subscription.TriggerCriteria.DwellTime == 0 && subscription.TriggerCriteria.TriggerTypes.Contains(sub.Dwell) {
+		fieldErrors = append(fieldErrors, NewParameterErrorDetail(
+			"subscription.triggerCriteria.dwellTime",
+			fmt.Sprintf("You need to provide a dwell time in milliseconds for the '%s' trigger type", sub.Dwell),
+		))
+	}
+
+	if subscription.TriggerCriteria.ConfirmPositions < 1 {
+		fieldErrors = append(fieldErrors, NewParameterErrorDetail("subscription.triggerCriteria.confirmPositions", "At least one position is needed to confirm a trigger"))
+	}
+
+	if subscription.TriggerCriteria.ConfirmTime < 0 {
+		fieldErrors = append(fieldErrors, NewParameterErrorDetail("subscription.triggerCriteria.confirmTime", "The confirm time can't be negative"))
+	}
+
+	if subscription.TriggerCriteria.BoundaryBuffer < 0 {
+		fieldErrors = append(fieldErrors, NewParameterErrorDetail("subscription.triggerCriteria.boundaryBuffer", "The boundary buffer can't be negative"))
+	}
+
 	if subscription.TeamID == nil {
 		fieldErrors = append(fieldErrors, NewParameterErrorDetail("subscription.teamId", fmt.Sprintf("You need to provide a teamId")))
 	}
@@ -269,6 +317,22 @@ func GetSubscriptionFromBody(body io.ReadCloser) (*service.Subscription, error)
 	return &subscription, nil
 }
 
+// ValidateSignedSubscription validates that the subscription has an output which signs its requests.
+// Returns a validation error containing an ErrorResponse if it doesn't
+func ValidateSignedSubscription(subscription *service.Subscription) error {
+	if subscription.Output.Type != sub.Webhook {
+		return newError(NewErrorResponse(
+			http.StatusBadRequest,
+			NewParameterErrorDetail(
+				"subscription.output",
+				fmt.Sprintf("The output '%s' doesn't sign its requests. Only '%s' outputs have a secret", subscription.Output.Type, sub.Webhook),
+			),
+		))
+	}
+
+	return nil
+}
+
 func isValidOutputType(outputType sub.OutputType) bool {
 	for _, validType := range sub.ValidOutputTypes {
 		if validType == outputType {
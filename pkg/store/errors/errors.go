@@ -65,6 +65,11 @@ func NewStorageErrorFromError(err error) error {
 
 	var errorType StorageErrorType
 
+	// The PostgreSQL driver returns its errors by reference
+	if pqError, ok := err.(*pq.Error); ok {
+		err = *pqError
+	}
+
 	switch err := err.(type) {
 	case pq.Error:
 		switch err.Code {
@@ -79,7 +84,12 @@ func NewStorageErrorFromError(err error) error {
 	case sqlite3.Error:
 		switch err.Code {
 		case sqlite3.ErrConstraint:
-			errorType = ForeignKeyViolation
+			switch err.ExtendedCode {
+			case sqlite3.ErrConstraintUnique, sqlite3.ErrConstraintPrimaryKey:
+				errorType = AlreadyExistsError
+			default:
+				errorType = ForeignKeyViolation
+			}
 		default:
 			log.Warnf("Could not find SQLite error number '%s', '%s'", err.Code, err)
 			errorType = InternalError
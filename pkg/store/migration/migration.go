@@ -0,0 +1,207 @@
+// Package migration applies numbered schema migrations to a SQL database. The applied
+// migrations are recorded in the schema_version table, so each migration is only applied
+// once. Migrations are written in the SQL dialect of the store they belong to.
+package migration
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Migration is a numbered change to the database schema. Up contains the SQL statements,
+// separated by semicolons, which are applied in a single transaction. Changes to the stored
+// data which can't be expressed in SQL are done by UpFunc, which is called in the same
+// transaction after the statements.
+//
+// A migration with an Option is only applied when the option is enabled for the Migrator, ie
+// migrations which need a database extension. Such a migration is applied whenever the option
+// is enabled, even if later migrations are applied already.
+type Migration struct {
+	Version     int
+	Description string
+	Option      string
+	Up          string
+	UpFunc      func(tx *sql.Tx) error
+}
+
+// Status is the state of a single migration in a database
+type Status struct {
+	Migration
+	Applied   bool
+	AppliedAt time.Time
+	// Enabled is false for migrations with an option which isn't enabled
+	Enabled bool
+}
+
+const createVersionTable = `
+CREATE TABLE IF NOT EXISTS schema_version (
+    version      INTEGER NOT NULL PRIMARY KEY,
+    description  TEXT,
+    applied      TIMESTAMP
+)`
+
+// Migrator applies a list of migrations to a database
+type Migrator struct {
+	db         *sql.DB
+	migrations []Migration
+	options    map[string]bool
+}
+
+// NewMigrator returns a Migrator for the given database. The migrations must be sorted by
+// version, starting at 1 without any gaps. Migrations with one of the given options are
+// applied along with the migrations without an option.
+func NewMigrator(db *sql.DB, migrations []Migration, options ...string) (*Migrator, error) {
+	for i, migration := range migrations {
+		if migration.Version != i+1 {
+			return nil, fmt.Errorf("migration %d (%s) is out of order, expected version %d", migration.Version, migration.Description, i+1)
+		}
+	}
+
+	if _, err := db.Exec(createVersionTable); err != nil {
+		return nil, fmt.Errorf("failed to create schema_version table: %v", err)
+	}
+
+	enabled := make(map[string]bool)
+	for _, option := range options {
+		enabled[option] = true
+	}
+
+	return &Migrator{db: db, migrations: migrations, options: enabled}, nil
+}
+
+// Version returns the latest applied version of the schema. A database without any applied
+// migrations is at version 0.
+func (m *Migrator) Version() (int, error) {
+	var version sql.NullInt64
+	if err := m.db.QueryRow(`SELECT MAX(version) FROM schema_version`).Scan(&version); err != nil {
+		return 0, err
+	}
+	return int(version.Int64), nil
+}
+
+// Status returns the status of every known migration
+func (m *Migrator) Status() ([]Status, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, len(m.migrations))
+	for i, migration := range m.migrations {
+		appliedAt, ok := applied[migration.Version]
+		statuses[i] = Status{Migration: migration, Applied: ok, AppliedAt: appliedAt, Enabled: m.enabled(migration)}
+	}
+
+	return statuses, nil
+}
+
+// Pending returns the enabled migrations which are not applied yet
+func (m *Migrator) Pending() ([]Migration, error) {
+	version, err := m.Version()
+	if err != nil {
+		return nil, err
+	}
+
+	if version > len(m.migrations) {
+		return nil, fmt.Errorf("schema version %d is newer than the latest known migration %d", version, len(m.migrations))
+	}
+
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+
+	var pending []Migration
+	for _, migration := range m.migrations {
+		if _, ok := applied[migration.Version]; !ok && m.enabled(migration) {
+			pending = append(pending, migration)
+		}
+	}
+
+	return pending, nil
+}
+
+// Up applies all pending migrations in order and returns the number of migrations applied.
+// Each migration is applied in its own transaction, so a failing migration leaves the schema
+// at the version before it.
+func (m *Migrator) Up() (int, error) {
+	pending, err := m.Pending()
+	if err != nil {
+		return 0, err
+	}
+
+	for n, migration := range pending {
+		if err := m.apply(migration); err != nil {
+			return n, fmt.Errorf("migration %d (%s) failed: %v", migration.Version, migration.Description, err)
+		}
+		log.Infof("Applied migration %d: %s", migration.Version, migration.Description)
+	}
+
+	return len(pending), nil
+}
+
+// Close closes the database of the Migrator
+func (m *Migrator) Close() error {
+	return m.db.Close()
+}
+
+// applied returns when each applied migration was applied by version
+func (m *Migrator) applied() (map[int]time.Time, error) {
+	rows, err := m.db.Query(`SELECT version, applied FROM schema_version`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := make(map[int]time.Time)
+	for rows.Next() {
+		var version int
+		var appliedAt time.Time
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, err
+		}
+		applied[version] = appliedAt
+	}
+
+	return applied, rows.Err()
+}
+
+func (m *Migrator) enabled(migration Migration) bool {
+	return migration.Option == "" || m.options[migration.Option]
+}
+
+func (m *Migrator) apply(migration Migration) error {
+	tx, err := m.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	for n, statement := range strings.Split(migration.Up, ";") {
+		if strings.TrimSpace(statement) == "" {
+			continue
+		}
+		if _, err := tx.Exec(statement); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("statement %d \"%s\": %v", n+1, strings.TrimSpace(statement), err)
+		}
+	}
+
+	if migration.UpFunc != nil {
+		if err := migration.UpFunc(tx); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	if _, err := tx.Exec(`INSERT INTO schema_version (version, description, applied) VALUES ($1, $2, $3)`,
+		migration.Version, migration.Description, time.Now().UTC()); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,200 @@
+package migration
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/stretchr/testify/assert"
+)
+
+var testMigrations = []Migration{
+	{Version: 1, Description: "Create things", Up: `CREATE TABLE things (id INTEGER NOT NULL PRIMARY KEY);`},
+	{Version: 2, Description: "Add name to things", Up: `
+ALTER TABLE things ADD COLUMN name TEXT;
+CREATE INDEX idx_things_name ON things(name);
+`},
+}
+
+func newTestDB(t *testing.T) *sql.DB {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	db.SetMaxOpenConns(1)
+	return db
+}
+
+func TestMigratorUp(t *testing.T) {
+	db := newTestDB(t)
+	defer db.Close()
+
+	migrator, err := NewMigrator(db, testMigrations[:1])
+	assert.NoError(t, err)
+
+	applied, err := migrator.Up()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, applied)
+
+	// Adding a migration to an existing database only applies the new migration
+	migrator, err = NewMigrator(db, testMigrations)
+	assert.NoError(t, err)
+
+	statuses, err := migrator.Status()
+	assert.NoError(t, err)
+	assert.True(t, statuses[0].Applied)
+	assert.False(t, statuses[1].Applied)
+
+	applied, err = migrator.Up()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, applied)
+
+	version, err := migrator.Version()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, version)
+
+	_, err = db.Exec(`INSERT INTO things (id, name) VALUES (1, 'thing')`)
+	assert.NoError(t, err)
+
+	// Nothing is pending once applied
+	applied, err = migrator.Up()
+	assert.NoError(t, err)
+	assert.Equal(t, 0, applied)
+}
+
+func TestMigratorFailingMigration(t *testing.T) {
+	db := newTestDB(t)
+	defer db.Close()
+
+	migrator, err := NewMigrator(db, append(testMigrations, Migration{
+		Version:     3,
+		Description: "Broken",
+		Up:          `CREATE TABLE others (id INTEGER); ALTER TABLE missing ADD COLUMN name TEXT;`,
+	}))
+	assert.NoError(t, err)
+
+	applied, err := migrator.Up()
+	assert.Error(t, err)
+	assert.Equal(t, 2, applied)
+
+	version, err := migrator.Version()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, version)
+
+	// The statements of the failing migration are rolled back
+	_, err = db.Exec(`SELECT id FROM others`)
+	assert.Error(t, err)
+}
+
+func TestMigratorUpFunc(t *testing.T) {
+	db := newTestDB(t)
+	defer db.Close()
+
+	migrator, err := NewMigrator(db, testMigrations)
+	assert.NoError(t, err)
+
+	_, err = migrator.Up()
+	assert.NoError(t, err)
+
+	_, err = db.Exec(`INSERT INTO things (id, name) VALUES (1, 'thing')`)
+	assert.NoError(t, err)
+
+	migrations := append(testMigrations, Migration{
+		Version:     3,
+		Description: "Rename things",
+		Up:          `ALTER TABLE things ADD COLUMN renamed BOOL NOT NULL DEFAULT 0;`,
+		UpFunc: func(tx *sql.Tx) error {
+			_, err := tx.Exec(`UPDATE things SET name = 'renamed', renamed = 1`)
+			return err
+		},
+	})
+
+	migrator, err = NewMigrator(db, migrations)
+	assert.NoError(t, err)
+
+	applied, err := migrator.Up()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, applied)
+
+	var name string
+	assert.NoError(t, db.QueryRow(`SELECT name FROM things WHERE renamed = 1`).Scan(&name))
+	assert.Equal(t, "renamed", name)
+
+	// A failing function rolls back the statements of the migration
+	migrator, err = NewMigrator(db, append(migrations, Migration{
+		Version:     4,
+		Description: "Broken function",
+		Up:          `CREATE TABLE others (id INTEGER);`,
+		UpFunc: func(tx *sql.Tx) error {
+			return errors.New("broken")
+		},
+	}))
+	assert.NoError(t, err)
+
+	_, err = migrator.Up()
+	assert.Error(t, err)
+
+	version, err := migrator.Version()
+	assert.NoError(t, err)
+	assert.Equal(t, 3, version)
+
+	_, err = db.Exec(`SELECT id FROM others`)
+	assert.Error(t, err)
+}
+
+func TestMigratorOption(t *testing.T) {
+	db := newTestDB(t)
+	defer db.Close()
+
+	migrations := append(testMigrations, Migration{
+		Version:     3,
+		Description: "Add extension things",
+		Option:      "extension",
+		Up:          `CREATE TABLE extension_things (id INTEGER);`,
+	}, Migration{
+		Version:     4,
+		Description: "Add size to things",
+		Up:          `ALTER TABLE things ADD COLUMN size INTEGER;`,
+	})
+
+	// Migrations with an option are skipped unless the option is enabled
+	migrator, err := NewMigrator(db, migrations)
+	assert.NoError(t, err)
+
+	applied, err := migrator.Up()
+	assert.NoError(t, err)
+	assert.Equal(t, 3, applied)
+
+	statuses, err := migrator.Status()
+	assert.NoError(t, err)
+	assert.False(t, statuses[2].Applied)
+	assert.False(t, statuses[2].Enabled)
+	assert.True(t, statuses[3].Applied)
+
+	_, err = db.Exec(`SELECT id FROM extension_things`)
+	assert.Error(t, err)
+
+	// Enabling the option later applies the skipped migration
+	migrator, err = NewMigrator(db, migrations, "extension")
+	assert.NoError(t, err)
+
+	applied, err = migrator.Up()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, applied)
+
+	_, err = db.Exec(`SELECT id FROM extension_things`)
+	assert.NoError(t, err)
+
+	pending, err := migrator.Pending()
+	assert.NoError(t, err)
+	assert.Empty(t, pending)
+}
+
+func TestMigratorOutOfOrder(t *testing.T) {
+	db := newTestDB(t)
+	defer db.Close()
+
+	_, err := NewMigrator(db, []Migration{testMigrations[1], testMigrations[0]})
+	assert.Error(t, err)
+}
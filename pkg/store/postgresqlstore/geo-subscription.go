@@ -8,9 +8,11 @@ import (
 )
 
 type geoSubscriptionStatements struct {
-	getBySubscription       *sql.Stmt
-	list                    *sql.Stmt
-	listByShapeCollectionID *sql.Stmt
+	getBySubscription              *sql.Stmt
+	list                           *sql.Stmt
+	listAfterID                    *sql.Stmt
+	listByShapeCollectionID        *sql.Stmt
+	listByShapeCollectionIDAfterID *sql.Stmt
 }
 
 func (s *sqlStore) initGeoSubscriptionStatements() error {
@@ -30,6 +32,10 @@ func (s *sqlStore) initGeoSubscriptionStatements() error {
 		subscriptions.shape_collection_id,
 		subscriptions.trackable_type,
 		subscriptions.trackable_id,
+		subscriptions.dwell_time,
+		subscriptions.confirm_positions,
+		subscriptions.confirm_time,
+		subscriptions.boundary_buffer,
 
 		shape_collections.id,
 		shape_collections.team_id,
@@ -60,6 +66,10 @@ func (s *sqlStore) initGeoSubscriptionStatements() error {
 		subscriptions.shape_collection_id,
 		subscriptions.trackable_type,
 		subscriptions.trackable_id,
+		subscriptions.dwell_time,
+		subscriptions.confirm_positions,
+		subscriptions.confirm_time,
+		subscriptions.boundary_buffer,
 
 		shape_collections.id,
 		shape_collections.team_id,
@@ -78,6 +88,43 @@ func (s *sqlStore) initGeoSubscriptionStatements() error {
 		return err
 	}
 
+	if s.geoSubscriptionStatements.listAfterID, err = s.db.Prepare(`
+	SELECT
+		subscriptions.id,
+		subscriptions.team_id,
+		subscriptions.name,
+		subscriptions.description,
+		subscriptions.active,
+		subscriptions.output,
+		subscriptions.output_config,
+		subscriptions.types,
+		subscriptions.confidences,
+		subscriptions.shape_collection_id,
+		subscriptions.trackable_type,
+		subscriptions.trackable_id,
+		subscriptions.dwell_time,
+		subscriptions.confirm_positions,
+		subscriptions.confirm_time,
+		subscriptions.boundary_buffer,
+
+		shape_collections.id,
+		shape_collections.team_id,
+		shape_collections.name,
+		shape_collections.description
+	FROM subscriptions
+	LEFT JOIN
+		shape_collections
+	ON
+		shape_collections.id=subscriptions.shape_collection_id
+	WHERE
+		subscriptions.id > $1
+	ORDER BY
+		subscriptions.id ASC
+	LIMIT $2
+	`); err != nil {
+		return err
+	}
+
 	if s.geoSubscriptionStatements.listByShapeCollectionID, err = s.db.Prepare(`
 	SELECT
 		subscriptions.id,
@@ -92,6 +139,10 @@ func (s *sqlStore) initGeoSubscriptionStatements() error {
 		subscriptions.shape_collection_id,
 		subscriptions.trackable_type,
 		subscriptions.trackable_id,
+		subscriptions.dwell_time,
+		subscriptions.confirm_positions,
+		subscriptions.confirm_time,
+		subscriptions.boundary_buffer,
 
 		shape_collections.id,
 		shape_collections.team_id,
@@ -112,6 +163,45 @@ func (s *sqlStore) initGeoSubscriptionStatements() error {
 		return err
 	}
 
+	if s.geoSubscriptionStatements.listByShapeCollectionIDAfterID, err = s.db.Prepare(`
+	SELECT
+		subscriptions.id,
+		subscriptions.team_id,
+		subscriptions.name,
+		subscriptions.description,
+		subscriptions.active,
+		subscriptions.output,
+		subscriptions.output_config,
+		subscriptions.types,
+		subscriptions.confidences,
+		subscriptions.shape_collection_id,
+		subscriptions.trackable_type,
+		subscriptions.trackable_id,
+		subscriptions.dwell_time,
+		subscriptions.confirm_positions,
+		subscriptions.confirm_time,
+		subscriptions.boundary_buffer,
+
+		shape_collections.id,
+		shape_collections.team_id,
+		shape_collections.name,
+		shape_collections.description
+	FROM subscriptions
+	LEFT JOIN
+		shape_collections
+	ON
+		shape_collections.id=subscriptions.shape_collection_id
+	WHERE
+		subscriptions.shape_collection_id = $1
+		AND
+		subscriptions.id > $2
+	ORDER BY
+		subscriptions.id ASC
+	LIMIT $3
+	`); err != nil {
+		return err
+	}
+
 	return err
 }
 
@@ -125,74 +215,39 @@ func (s *sqlStore) GetGeoSubscriptionBySubscription(subscriptionID int64) (*mode
 		return &geoSubscription, errors.NewStorageErrorFromError(err)
 	}
 
-	movements, err := s.ListMovementsBySubscriptionID(geoSubscription.Subscription.ID, 0, 100)
+	movements, err := s.ListLatestMovementsBySubscriptionID(geoSubscription.Subscription.ID)
 
 	if err != nil {
 		return &geoSubscription, errors.NewStorageErrorFromError(err)
 	}
 
-	shapes, err := s.ListShapesByShapeCollectionID(geoSubscription.Subscription.ShapeCollectionID, true, 0, 1000000)
-	if err != nil {
-		return &geoSubscription, errors.NewStorageErrorFromError(err)
-	}
-
 	geoSubscription.TrackerMovements = append(geoSubscription.TrackerMovements, movements...)
-	geoSubscription.Shapes = append(geoSubscription.Shapes, shapes...)
 
 	return &geoSubscription, nil
 }
 
 func (s *sqlStore) ListGeoSubscriptions(offset, limit int64) ([]model.GeoSubscription, error) {
-	var geoSubscriptions []model.GeoSubscription
-
-	rows, err := s.geoSubscriptionStatements.list.Query(
-		limit,
-		offset,
-	)
-
-	if err != nil {
-		return geoSubscriptions, errors.NewStorageErrorFromError(err)
-	}
-
-	for rows.Next() {
-		geoSubscription, err := scanGeoSubscriptionRow(rows)
-
-		if err != nil {
-			return geoSubscriptions, errors.NewStorageErrorFromError(err)
-		}
-
-		geoSubscriptions = append(geoSubscriptions, geoSubscription)
-	}
-
-	rows.Close()
-
-	for i := range geoSubscriptions {
-		movements, err := s.ListMovementsBySubscriptionID(geoSubscriptions[i].Subscription.ID, 0, 100)
-
-		if err != nil {
-			return geoSubscriptions, errors.NewStorageErrorFromError(err)
-		}
+	return s.listGeoSubscriptions(s.geoSubscriptionStatements.list, limit, offset)
+}
 
-		shapes, err := s.ListShapesByShapeCollectionID(geoSubscriptions[i].Subscription.ShapeCollectionID, true, 0, 1000000)
-		if err != nil {
-			return geoSubscriptions, errors.NewStorageErrorFromError(err)
-		}
+func (s *sqlStore) ListGeoSubscriptionsAfterID(subscriptionID int64, limit int64) ([]model.GeoSubscription, error) {
+	return s.listGeoSubscriptions(s.geoSubscriptionStatements.listAfterID, subscriptionID, limit)
+}
 
-		geoSubscriptions[i].TrackerMovements = append(geoSubscriptions[i].TrackerMovements, movements...)
-		geoSubscriptions[i].Shapes = append(geoSubscriptions[i].Shapes, shapes...)
-	}
+func (s *sqlStore) ListGeoSubscriptionsByShapeCollectionID(shapeCollectionID int64, offset int64, limit int64) ([]model.GeoSubscription, error) {
+	return s.listGeoSubscriptions(s.geoSubscriptionStatements.listByShapeCollectionID, shapeCollectionID, limit, offset)
+}
 
-	return geoSubscriptions, nil
+func (s *sqlStore) ListGeoSubscriptionsByShapeCollectionIDAfterID(shapeCollectionID int64, subscriptionID int64, limit int64) ([]model.GeoSubscription, error) {
+	return s.listGeoSubscriptions(s.geoSubscriptionStatements.listByShapeCollectionIDAfterID, shapeCollectionID, subscriptionID, limit)
 }
 
-func (s *sqlStore) ListGeoSubscriptionsByShapeCollectionID(shapeCollectionID int64, offset int64, limit int64) ([]model.GeoSubscription, error) {
+// listGeoSubscriptions lists the GeoSubscriptions of the statement along with the latest movements of
+// each subscription
+func (s *sqlStore) listGeoSubscriptions(statement *sql.Stmt, args ...interface{}) ([]model.GeoSubscription, error) {
 	var geoSubscriptions []model.GeoSubscription
 
-	rows, err := s.geoSubscriptionStatements.listByShapeCollectionID.Query(
-		shapeCollectionID,
-		limit,
-		offset,
-	)
+	rows, err := statement.Query(args...)
 
 	if err != nil {
 		return geoSubscriptions, errors.NewStorageErrorFromError(err)
@@ -202,6 +257,7 @@ func (s *sqlStore) ListGeoSubscriptionsByShapeCollectionID(shapeCollectionID int
 		geoSubscription, err := scanGeoSubscriptionRow(rows)
 
 		if err != nil {
+			rows.Close()
 			return geoSubscriptions, errors.NewStorageErrorFromError(err)
 		}
 
@@ -211,19 +267,13 @@ func (s *sqlStore) ListGeoSubscriptionsByShapeCollectionID(shapeCollectionID int
 	rows.Close()
 
 	for i := range geoSubscriptions {
-		movements, err := s.ListMovementsBySubscriptionID(geoSubscriptions[i].Subscription.ID, 0, 100)
-
-		if err != nil {
-			return geoSubscriptions, errors.NewStorageErrorFromError(err)
-		}
+		movements, err := s.ListLatestMovementsBySubscriptionID(geoSubscriptions[i].Subscription.ID)
 
-		shapes, err := s.ListShapesByShapeCollectionID(geoSubscriptions[i].Subscription.ShapeCollectionID, true, 0, 1000000)
 		if err != nil {
 			return geoSubscriptions, errors.NewStorageErrorFromError(err)
 		}
 
 		geoSubscriptions[i].TrackerMovements = append(geoSubscriptions[i].TrackerMovements, movements...)
-		geoSubscriptions[i].Shapes = append(geoSubscriptions[i].Shapes, shapes...)
 	}
 
 	return geoSubscriptions, nil
@@ -245,6 +295,10 @@ func scanGeoSubscriptionRow(row rowScanner) (model.GeoSubscription, error) {
 		&geoSubscription.Subscription.ShapeCollectionID,
 		&geoSubscription.Subscription.TrackableType,
 		&geoSubscription.Subscription.TrackableID,
+		&geoSubscription.Subscription.DwellTime,
+		&geoSubscription.Subscription.ConfirmPositions,
+		&geoSubscription.Subscription.ConfirmTime,
+		&geoSubscription.Subscription.BoundaryBuffer,
 
 		&geoSubscription.ShapeCollection.ID,
 		&geoSubscription.ShapeCollection.TeamID,
@@ -2,6 +2,7 @@ package postgresqlstore
 
 import (
 	"database/sql"
+	"math"
 
 	log "github.com/sirupsen/logrus"
 
@@ -10,8 +11,11 @@ import (
 )
 
 type movementStatements struct {
-	create               *sql.Stmt
-	listBySubscriptionID *sql.Stmt
+	create                      *sql.Stmt
+	listBySubscriptionID        *sql.Stmt
+	listLatestBySubscriptionID  *sql.Stmt
+	listHistoryBySubscriptionID *sql.Stmt
+	deleteByTrackerID           *sql.Stmt
 }
 
 func (s *sqlStore) initMovementStatements() error {
@@ -23,30 +27,162 @@ func (s *sqlStore) initMovementStatements() error {
 		subscription_id,
 		position_id,
 		shape_id,
-		movement
+		movement,
+		entered,
+		dwelled
 	) VALUES (
 		$1,
 		$2,
 		$3,
 		$4,
-		$5
+		$5,
+		$6,
+		$7
 	)
 	`); err != nil {
 		return err
 	}
 
 	if s.movementStatements.listBySubscriptionID, err = s.db.Prepare(`
+	SELECT
+		position_movements.tracker_id,
+		position_movements.subscription_id,
+		position_movements.position_id,
+		position_movements.shape_id,
+		position_movements.movement,
+		position_movements.entered,
+		position_movements.dwelled,
+		positions.ts
+	FROM
+		position_movements,
+		positions
+	WHERE
+		position_movements.position_id = positions.id
+		AND
+		position_movements.subscription_id = $1
+		AND
+		positions.ts >= $2
+		AND
+		positions.ts <= $3
+	ORDER BY
+		position_movements.id ASC
+	LIMIT $4
+	OFFSET $5
+	`); err != nil {
+		return err
+	}
+
+	if s.movementStatements.listLatestBySubscriptionID, err = s.db.Prepare(`
 	SELECT
 		tracker_id,
 		subscription_id,
 		position_id,
 		shape_id,
-		movement
+		movement,
+		entered,
+		dwelled,
+		ts
+	FROM (
+		SELECT
+			position_movements.tracker_id,
+			position_movements.subscription_id,
+			position_movements.position_id,
+			position_movements.shape_id,
+			position_movements.movement,
+			position_movements.entered,
+			position_movements.dwelled,
+			positions.ts,
+			ROW_NUMBER() OVER (
+				PARTITION BY
+					position_movements.tracker_id,
+					position_movements.shape_id
+				ORDER BY
+					positions.ts DESC,
+					position_movements.id DESC
+			) AS movement_rank
+		FROM
+			position_movements,
+			positions
+		WHERE
+			position_movements.position_id = positions.id
+			AND
+			position_movements.subscription_id = $1
+			AND
+			positions.ts < $2
+	) AS ranked_movements
+	WHERE
+		movement_rank = 1
+	ORDER BY
+		tracker_id ASC,
+		shape_id ASC
+	`); err != nil {
+		return err
+	}
+
+	if s.movementStatements.listHistoryBySubscriptionID, err = s.db.Prepare(`
+	SELECT
+		position_movements.id,
+		position_movements.tracker_id,
+		position_movements.subscription_id,
+		position_movements.position_id,
+		position_movements.shape_id,
+		position_movements.movement,
+		position_movements.entered,
+		position_movements.dwelled,
+		positions.id,
+		positions.tracker_id,
+		positions.ts,
+		positions.lat,
+		positions.lon,
+		positions.alt,
+		positions.heading,
+		positions.speed,
+		positions.payload,
+		positions.precision
 	FROM
+		position_movements,
+		positions
+	WHERE
+		position_movements.position_id = positions.id
+		AND
+		position_movements.subscription_id = $1
+		AND
+		($2::bigint = 0 OR position_movements.tracker_id = $2::bigint)
+		AND
+		($3::bigint = 0 OR position_movements.shape_id = $3::bigint)
+		AND
+		($4::text = '' OR position_movements.movement @> jsonb_build_array($4::text))
+		AND
+		positions.ts >= $5
+		AND
+		positions.ts <= $6
+	ORDER BY
+		positions.ts DESC,
+		position_movements.id DESC
+	LIMIT $7
+	OFFSET $8
+	`); err != nil {
+		return err
+	}
+
+	if s.movementStatements.deleteByTrackerID, err = s.db.Prepare(`
+	DELETE FROM
 		position_movements
-	WHERE subscription_id = $1
-	LIMIT $2
-	OFFSET $3
+	WHERE
+		subscription_id = $1
+		AND
+		tracker_id = $2
+		AND
+		position_id IN (
+			SELECT
+				id
+			FROM
+				positions
+			WHERE
+				ts >= $3
+				AND
+				ts <= $4
+		)
 	`); err != nil {
 		return err
 	}
@@ -61,6 +197,8 @@ func (s *sqlStore) InsertMovement(movement *model.TrackerMovement) error {
 		movement.PositionID,
 		movement.ShapeID,
 		movement.Movements,
+		movement.Entered,
+		movement.Dwelled,
 	)
 
 	return errors.NewStorageErrorFromError(err)
@@ -73,31 +211,65 @@ func (s *sqlStore) InsertMovements(movements []model.TrackerMovement) error {
 		return errors.NewStorageErrorFromError(err)
 	}
 
+	if err := s.insertMovements(tx, movements); err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil {
+			log.Error("Error on rollback for replace movements", rbErr)
+		}
+		return errors.NewStorageErrorFromError(err)
+	}
+
+	return errors.NewStorageErrorFromError(tx.Commit())
+}
+
+func (s *sqlStore) ReplaceMovementsByTrackerID(subscriptionID int64, trackerID int64, since int64, until int64, movements []model.TrackerMovement) error {
+	tx, err := s.db.Begin()
+
+	if err != nil {
+		return errors.NewStorageErrorFromError(err)
+	}
+
+	_, err = tx.Stmt(s.movementStatements.deleteByTrackerID).Exec(subscriptionID, trackerID, since, until)
+	if err == nil {
+		err = s.insertMovements(tx, movements)
+	}
+
+	if err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil {
+			log.Error("Error on rollback for replace movements", rbErr)
+		}
+		return errors.NewStorageErrorFromError(err)
+	}
+
+	return errors.NewStorageErrorFromError(tx.Commit())
+}
+
+func (s *sqlStore) insertMovements(tx *sql.Tx, movements []model.TrackerMovement) error {
 	for _, movement := range movements {
-		_, err = tx.Stmt(s.movementStatements.create).Exec(
+		_, err := tx.Stmt(s.movementStatements.create).Exec(
 			movement.TrackerID,
 			movement.SubscriptionID,
 			movement.PositionID,
 			movement.ShapeID,
 			movement.Movements,
+			movement.Entered,
+			movement.Dwelled,
 		)
 
 		if err != nil {
-			if rbErr := tx.Rollback(); rbErr != nil {
-				log.Error("Error on rollback for replace movements", rbErr)
-			}
-			return errors.NewStorageErrorFromError(err)
+			return err
 		}
 	}
 
-	return errors.NewStorageErrorFromError(tx.Commit())
+	return nil
 }
 
-func (s *sqlStore) ListMovementsBySubscriptionID(subscriptionID int64, offset int64, limit int64) ([]model.TrackerMovement, error) {
+func (s *sqlStore) ListMovementsBySubscriptionID(subscriptionID int64, since int64, until int64, offset int64, limit int64) ([]model.TrackerMovement, error) {
 	var positionMovements []model.TrackerMovement
 
 	rows, err := s.movementStatements.listBySubscriptionID.Query(
 		subscriptionID,
+		since,
+		until,
 		limit,
 		offset,
 	)
@@ -121,6 +293,93 @@ func (s *sqlStore) ListMovementsBySubscriptionID(subscriptionID int64, offset in
 	return positionMovements, nil
 }
 
+func (s *sqlStore) ListLatestMovementsBySubscriptionID(subscriptionID int64) ([]model.TrackerMovement, error) {
+	return s.listLatestMovements(subscriptionID, math.MaxInt64)
+}
+
+func (s *sqlStore) ListLatestMovementsBySubscriptionIDBefore(subscriptionID int64, before int64) ([]model.TrackerMovement, error) {
+	return s.listLatestMovements(subscriptionID, before)
+}
+
+func (s *sqlStore) listLatestMovements(subscriptionID int64, before int64) ([]model.TrackerMovement, error) {
+	var positionMovements []model.TrackerMovement
+
+	rows, err := s.movementStatements.listLatestBySubscriptionID.Query(subscriptionID, before)
+
+	if err != nil {
+		return positionMovements, errors.NewStorageErrorFromError(err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		movements, err := scanMovementRow(rows)
+
+		if err != nil {
+			return positionMovements, errors.NewStorageErrorFromError(err)
+		}
+
+		positionMovements = append(positionMovements, movements)
+	}
+
+	return positionMovements, nil
+}
+
+func (s *sqlStore) ListPositionMovementsBySubscriptionID(subscriptionID int64, filter model.MovementFilter, offset int64, limit int64) ([]model.PositionMovement, error) {
+	var positionMovements []model.PositionMovement
+
+	rows, err := s.movementStatements.listHistoryBySubscriptionID.Query(
+		subscriptionID,
+		filter.TrackerID,
+		filter.ShapeID,
+		filter.Movement,
+		filter.Since,
+		filter.Until,
+		limit,
+		offset,
+	)
+
+	if err != nil {
+		return positionMovements, errors.NewStorageErrorFromError(err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var positionMovement model.PositionMovement
+
+		err := rows.Scan(
+			&positionMovement.ID,
+			&positionMovement.Movement.TrackerID,
+			&positionMovement.Movement.SubscriptionID,
+			&positionMovement.Movement.PositionID,
+			&positionMovement.Movement.ShapeID,
+			&positionMovement.Movement.Movements,
+			&positionMovement.Movement.Entered,
+			&positionMovement.Movement.Dwelled,
+			&positionMovement.Position.ID,
+			&positionMovement.Position.TrackerID,
+			&positionMovement.Position.Timestamp,
+			&positionMovement.Position.Lat,
+			&positionMovement.Position.Lon,
+			&positionMovement.Position.Alt,
+			&positionMovement.Position.Heading,
+			&positionMovement.Position.Speed,
+			&positionMovement.Position.Payload,
+			&positionMovement.Position.Precision,
+		)
+
+		if err != nil {
+			return positionMovements, errors.NewStorageErrorFromError(err)
+		}
+		positionMovement.Movement.Timestamp = positionMovement.Position.Timestamp
+
+		positionMovements = append(positionMovements, positionMovement)
+	}
+
+	return positionMovements, nil
+}
+
 func scanMovementRow(row rowScanner) (model.TrackerMovement, error) {
 	trackerMovement := model.TrackerMovement{}
 
@@ -130,6 +389,9 @@ func scanMovementRow(row rowScanner) (model.TrackerMovement, error) {
 		&trackerMovement.PositionID,
 		&trackerMovement.ShapeID,
 		&trackerMovement.Movements,
+		&trackerMovement.Entered,
+		&trackerMovement.Dwelled,
+		&trackerMovement.Timestamp,
 	)
 
 	return trackerMovement, err
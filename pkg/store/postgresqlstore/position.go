@@ -2,18 +2,20 @@ package postgresqlstore
 
 import (
 	"database/sql"
+	"math"
 
 	"github.com/eesrc/geo/pkg/model"
 	"github.com/eesrc/geo/pkg/store/errors"
 )
 
 type positionStatements struct {
-	create          *sql.Stmt
-	get             *sql.Stmt
-	getByUserID     *sql.Stmt
-	delete          *sql.Stmt
-	list            *sql.Stmt
-	listByTrackerID *sql.Stmt
+	create               *sql.Stmt
+	get                  *sql.Stmt
+	getByUserID          *sql.Stmt
+	getDuplicateByUserID *sql.Stmt
+	delete               *sql.Stmt
+	list                 *sql.Stmt
+	listByTrackerID      *sql.Stmt
 }
 
 func (s *sqlStore) initPositionStatements() error {
@@ -96,6 +98,46 @@ func (s *sqlStore) initPositionStatements() error {
 		return err
 	}
 
+	if s.positionStatements.getDuplicateByUserID, err = s.db.Prepare(`
+	SELECT
+		positions.id,
+		positions.tracker_id,
+		positions.ts,
+		positions.lat,
+		positions.lon,
+		positions.alt,
+		positions.heading,
+		positions.speed,
+		positions.payload,
+		positions.precision
+	FROM
+		positions,
+		trackers,
+		collections,
+		team_members
+	WHERE
+		positions.tracker_id = trackers.id
+		AND
+		trackers.collection_id = collections.id
+		AND
+		collections.team_id = team_members.team_id
+		AND
+		positions.tracker_id = $1
+		AND
+		positions.ts = $2
+		AND
+		positions.lat = $3
+		AND
+		positions.lon = $4
+		AND
+		team_members.user_id = $5
+	ORDER BY
+		positions.id
+	LIMIT 1
+	`); err != nil {
+		return err
+	}
+
 	if s.positionStatements.delete, err = s.db.Prepare(`
 	DELETE FROM positions
 	WHERE id = $1
@@ -152,10 +194,17 @@ func (s *sqlStore) initPositionStatements() error {
 		tracker_id = $1
 		AND
 		team_members.user_id = $2
+		AND
+		positions.ts >= $3
+		AND
+		positions.ts <= $4
+		AND
+		(positions.ts < $4 OR positions.id < $5::bigint)
 	ORDER BY
-		ts DESC
-	LIMIT $3
-	OFFSET $4
+		positions.ts DESC,
+		positions.id DESC
+	LIMIT $6
+	OFFSET $7
 	`); err != nil {
 		return err
 	}
@@ -196,6 +245,45 @@ func (s *sqlStore) CreatePosition(position *model.Position, userID int64) (int64
 	return lastInsertID, errors.NewStorageErrorFromError(tx.Commit())
 }
 
+func (s *sqlStore) CreatePositions(positions []model.Position, userID int64) ([]int64, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return []int64{}, errors.NewStorageErrorFromError(err)
+	}
+
+	positionIDs := make([]int64, len(positions))
+	adminOfTracker := make(map[int64]bool)
+
+	for i, position := range positions {
+		if !adminOfTracker[position.TrackerID] {
+			if err := s.ensureAdminOfTracker(tx, userID, position.TrackerID); err != nil {
+				_ = tx.Rollback()
+				return []int64{}, errors.NewStorageError(errors.AccessDeniedError, err)
+			}
+			adminOfTracker[position.TrackerID] = true
+		}
+
+		positionIDs[i], err = scanIDRow(tx.Stmt(s.positionStatements.create).QueryRow(
+			position.TrackerID,
+			position.Timestamp,
+			position.Lat,
+			position.Lon,
+			position.Alt,
+			position.Heading,
+			position.Speed,
+			position.Payload,
+			position.Precision,
+		))
+
+		if err != nil {
+			_ = tx.Rollback()
+			return []int64{}, errors.NewStorageErrorFromError(err)
+		}
+	}
+
+	return positionIDs, errors.NewStorageErrorFromError(tx.Commit())
+}
+
 func (s *sqlStore) GetPosition(positionID int64) (*model.Position, error) {
 	row := s.positionStatements.get.QueryRow(
 		positionID,
@@ -220,6 +308,19 @@ func (s *sqlStore) GetPositionByUserID(positionID int64, userID int64) (*model.P
 	return &position, errors.NewStorageErrorFromError(err)
 }
 
+func (s *sqlStore) GetDuplicatePositionByUserID(position *model.Position, userID int64) (*model.Position, error) {
+	row := s.positionStatements.getDuplicateByUserID.QueryRow(
+		position.TrackerID,
+		position.Timestamp,
+		position.Lat,
+		position.Lon,
+		userID,
+	)
+
+	duplicate, err := scanPositionRow(row)
+	return &duplicate, errors.NewStorageErrorFromError(err)
+}
+
 func (s *sqlStore) DeletePosition(positionID int64, userID int64) error {
 	tx, err := s.db.Begin()
 	if err != nil {
@@ -267,7 +368,16 @@ func (s *sqlStore) ListPositions(offset int64, limit int64) ([]model.Position, e
 	return positions, nil
 }
 
-func (s *sqlStore) ListPositionsByTrackerID(trackerID int64, userID int64, offset int64, limit int64) ([]model.Position, error) {
+func (s *sqlStore) ListPositionsByTrackerID(trackerID int64, userID int64, since int64, until int64, offset int64, limit int64) ([]model.Position, error) {
+	// Without a cursor every position up to and including until is listed
+	return s.listPositionsByTrackerID(trackerID, userID, since, model.PositionCursor{Timestamp: until, ID: math.MaxInt64}, offset, limit)
+}
+
+func (s *sqlStore) ListPositionsByTrackerIDBeforeCursor(trackerID int64, userID int64, since int64, cursor model.PositionCursor, limit int64) ([]model.Position, error) {
+	return s.listPositionsByTrackerID(trackerID, userID, since, cursor, 0, limit)
+}
+
+func (s *sqlStore) listPositionsByTrackerID(trackerID int64, userID int64, since int64, cursor model.PositionCursor, offset int64, limit int64) ([]model.Position, error) {
 	tx, err := s.db.Begin()
 	if err != nil {
 		return []model.Position{}, errors.NewStorageErrorFromError(err)
@@ -282,6 +392,9 @@ func (s *sqlStore) ListPositionsByTrackerID(trackerID int64, userID int64, offse
 	rows, err := tx.Stmt(s.positionStatements.listByTrackerID).Query(
 		trackerID,
 		userID,
+		since,
+		cursor.Timestamp,
+		cursor.ID,
 		limit,
 		offset,
 	)
@@ -0,0 +1,132 @@
+package postgresqlstore
+
+import (
+	"database/sql"
+	"strconv"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/eesrc/geo/pkg/tria/geometry"
+)
+
+// postGISOption is the migration option of the migrations which need PostGIS
+const postGISOption = "postgis"
+
+// setShapeGeometry sets the PostGIS geometry of a shape. It does nothing unless PostGIS is enabled.
+func (s *sqlStore) setShapeGeometry(tx *sql.Tx, shapeID int64, shape geometry.Shape) error {
+	if !s.postGIS {
+		return nil
+	}
+
+	wkt, buffer := shapeToWKT(shape)
+	_, err := tx.Stmt(s.spatialStatements.setShapeGeometry).Exec(wkt, buffer, shapeID)
+	return err
+}
+
+// backfillShapeGeometries sets the PostGIS geometry of shapes stored before PostGIS was enabled
+func (s *sqlStore) backfillShapeGeometries() error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	rows, err := tx.Query(`
+	SELECT
+		id,
+		shape_collection_id,
+		name,
+		properties,
+		shape
+	FROM
+		shapes
+	WHERE
+		geom IS NULL
+	`)
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+
+	var shapeIDs []int64
+	var shapes []geometry.Shape
+	for rows.Next() {
+		shape, err := scanShapeRowWithShape(rows)
+		if err != nil {
+			_ = rows.Close()
+			_ = tx.Rollback()
+			return err
+		}
+		shapeIDs = append(shapeIDs, shape.ID)
+		shapes = append(shapes, shape.Shape)
+	}
+	_ = rows.Close()
+
+	for i, shape := range shapes {
+		if err := s.setShapeGeometry(tx, shapeIDs[i], shape); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+	}
+
+	if len(shapes) > 0 {
+		log.Infof("Added PostGIS geometry to %d shapes", len(shapes))
+	}
+
+	return tx.Commit()
+}
+
+// shapeToWKT returns the shape as Well-Known Text along with a buffer in metres. Circles and
+// corridors are stored as their point and line, buffered by the radius and half the width.
+func shapeToWKT(shape geometry.Shape) (string, float64) {
+	switch shape := shape.(type) {
+	case *geometry.Polygon:
+		return "POLYGON" + polygonToWKT(shape), 0
+	case *geometry.MultiPolygon:
+		polygons := make([]string, len(shape.Polygons))
+		for i := range shape.Polygons {
+			polygons[i] = polygonToWKT(&shape.Polygons[i])
+		}
+		return "MULTIPOLYGON(" + strings.Join(polygons, ",") + ")", 0
+	case *geometry.Circle:
+		return "POINT(" + pointToWKT(shape.Origo) + ")", shape.Radius
+	case *geometry.Corridor:
+		return lineToWKT(shape.Line), shape.Width / 2
+	case *geometry.Tripwire:
+		return lineToWKT(shape.Line), 0
+	default:
+		return "GEOMETRYCOLLECTION EMPTY", 0
+	}
+}
+
+func polygonToWKT(polygon *geometry.Polygon) string {
+	rings := polygon.Rings()
+	wktRings := make([]string, len(rings))
+	for i, ring := range rings {
+		// WKT rings must be closed
+		if len(ring) > 0 && ring[0] != ring[len(ring)-1] {
+			ring = append(ring[:len(ring):len(ring)], ring[0])
+		}
+		wktRings[i] = "(" + pointsToWKT(ring) + ")"
+	}
+	return "(" + strings.Join(wktRings, ",") + ")"
+}
+
+func lineToWKT(line []geometry.Point) string {
+	if len(line) == 1 {
+		return "POINT(" + pointToWKT(line[0]) + ")"
+	}
+	return "LINESTRING(" + pointsToWKT(line) + ")"
+}
+
+func pointsToWKT(points []geometry.Point) string {
+	wktPoints := make([]string, len(points))
+	for i, point := range points {
+		wktPoints[i] = pointToWKT(point)
+	}
+	return strings.Join(wktPoints, ",")
+}
+
+func pointToWKT(point geometry.Point) string {
+	return strconv.FormatFloat(point.X, 'f', -1, 64) + " " + strconv.FormatFloat(point.Y, 'f', -1, 64)
+}
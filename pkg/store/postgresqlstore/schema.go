@@ -1,6 +1,14 @@
 package postgresqlstore
 
-const postgresSchema = `
+import "github.com/eesrc/geo/pkg/store/migration"
+
+// migrations is the list of schema migrations for PostgreSQL. Never change a migration which has
+// been released, add a new migration at the end of the list instead.
+var migrations = []migration.Migration{
+	{
+		Version:     1,
+		Description: "Initial schema",
+		Up: `
 CREATE TABLE IF NOT EXISTS users (
     id              SERIAL PRIMARY KEY,
     name            VARCHAR(255),
@@ -131,4 +139,93 @@ CREATE TABLE IF NOT EXISTS position_movements(
 );
 
 CREATE INDEX IF NOT EXISTS idx_position_movements_subscription ON position_movements(subscription_id);
-`
+`,
+	},
+	{
+		Version:     2,
+		Description: "Add trigger criteria, movement state and deliveries",
+		Up: `
+ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS dwell_time BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS confirm_positions INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS confirm_time BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE subscriptions ADD COLUMN IF NOT EXISTS boundary_buffer DOUBLE PRECISION NOT NULL DEFAULT 0;
+
+ALTER TABLE position_movements ADD COLUMN IF NOT EXISTS entered BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE position_movements ADD COLUMN IF NOT EXISTS dwelled BOOL NOT NULL DEFAULT FALSE;
+
+CREATE TABLE IF NOT EXISTS deliveries(
+    id              SERIAL PRIMARY KEY,
+
+    subscription_id INTEGER NOT NULL,
+    output          VARCHAR(32),
+    status          VARCHAR(32),
+    payload         BYTEA,
+    attempts        INTEGER,
+    last_error      TEXT,
+    created         TIMESTAMPTZ,
+    updated         TIMESTAMPTZ,
+
+    FOREIGN KEY(subscription_id) REFERENCES subscriptions(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_deliveries_subscription_status ON deliveries(subscription_id, status);
+`,
+	},
+	{
+		Version:     3,
+		Description: "Add tracker and timestamp index to positions",
+		Up: `
+CREATE INDEX IF NOT EXISTS idx_positions_tracker_ts ON positions(tracker_id, ts);
+`,
+	},
+	{
+		Version:     4,
+		Description: "Set the tracker of movements stored for collection subscriptions",
+		Up: `
+UPDATE position_movements SET tracker_id = (
+    SELECT positions.tracker_id FROM positions WHERE positions.id = position_movements.position_id
+) WHERE EXISTS (
+    SELECT 1 FROM positions
+    WHERE positions.id = position_movements.position_id AND positions.tracker_id <> position_movements.tracker_id
+);
+`,
+	},
+	{
+		Version:     5,
+		Description: "Convert the radius of circles from degrees to metres",
+		UpFunc:      migrateCircleRadius,
+	},
+	{
+		Version:     6,
+		Description: "Add PostGIS geometry to positions and shapes",
+		Option:      postGISOption,
+		// The position geometry is generated from lat/lon by the database, while the shape geometry
+		// is set by the store whenever a shape is written. Generated columns requires PostgreSQL 12.
+		Up: `
+CREATE EXTENSION IF NOT EXISTS postgis;
+
+ALTER TABLE positions ADD COLUMN IF NOT EXISTS geom geometry(Point, 4326)
+    GENERATED ALWAYS AS (ST_SetSRID(ST_MakePoint(lon, lat), 4326)) STORED;
+CREATE INDEX IF NOT EXISTS idx_positions_geom ON positions USING GIST (geom);
+
+ALTER TABLE shapes ADD COLUMN IF NOT EXISTS geom geometry(Geometry, 4326);
+CREATE INDEX IF NOT EXISTS idx_shapes_geom ON shapes USING GIST (geom);
+`,
+	},
+	{
+		Version:     7,
+		Description: "Add a unique index on the tracker, timestamp and coordinates of positions",
+		// Stored positions aren't changed. If duplicates are already stored the index can't be created,
+		// and the duplicates have to be removed first as described in the README.
+		Up: `
+CREATE UNIQUE INDEX IF NOT EXISTS idx_positions_unique ON positions(tracker_id, ts, lat, lon);
+`,
+	},
+	{
+		Version:     8,
+		Description: "Add the recipient of deliveries",
+		Up: `
+ALTER TABLE deliveries ADD COLUMN IF NOT EXISTS recipient VARCHAR(255) NOT NULL DEFAULT '';
+`,
+	},
+}
@@ -311,6 +311,10 @@ func (s *sqlStore) CreateShape(shape *model.Shape, userID int64) (int64, error)
 
 	lastInsertID, err := scanIDRow(row)
 
+	if err == nil {
+		err = s.setShapeGeometry(tx, lastInsertID, shape.Shape)
+	}
+
 	if err != nil {
 		_ = tx.Rollback()
 		return -1, errors.NewStorageErrorFromError(err)
@@ -346,12 +350,15 @@ func (s *sqlStore) CreateShapes(shapes []*model.Shape, userID int64) error {
 		if err != nil {
 			return errors.NewStorageErrorFromError(err)
 		}
-		_, err = tx.Stmt(s.shapeStatements.create).Exec(
+		shapeID, err := scanIDRow(tx.Stmt(s.shapeStatements.create).QueryRow(
 			shape.ShapeCollectionID,
 			shape.Name,
 			shape.Properties,
 			shapeStorage,
-		)
+		))
+		if err == nil {
+			err = s.setShapeGeometry(tx, shapeID, shape.Shape)
+		}
 		if err != nil {
 			if rbErr := tx.Rollback(); rbErr != nil {
 				log.Error("Error on rollback for createShape", rbErr)
@@ -443,6 +450,10 @@ func (s *sqlStore) UpdateShape(shape *model.Shape, userID int64) error {
 		shape.ShapeCollectionID,
 	)
 
+	if err == nil {
+		err = s.setShapeGeometry(tx, shape.ID, shape.Shape)
+	}
+
 	if err != nil {
 		_ = tx.Rollback()
 		return errors.NewStorageErrorFromError(err)
@@ -641,12 +652,15 @@ func (s *sqlStore) ReplaceShapesInShapeCollection(shapeCollectionID int64, userI
 			return errors.NewStorageError(errors.InternalError, err)
 		}
 
-		_, err = tx.Stmt(s.shapeStatements.create).Exec(
+		shapeID, err := scanIDRow(tx.Stmt(s.shapeStatements.create).QueryRow(
 			shape.ShapeCollectionID,
 			shape.Name,
 			shape.Properties,
 			shapeStorage,
-		)
+		))
+		if err == nil {
+			err = s.setShapeGeometry(tx, shapeID, shape.Shape)
+		}
 		if err != nil {
 			if rbErr := tx.Rollback(); rbErr != nil {
 				log.Error("Error on rollback for replaceShapes", rbErr)
@@ -658,6 +672,56 @@ func (s *sqlStore) ReplaceShapesInShapeCollection(shapeCollectionID int64, userI
 	return errors.NewStorageErrorFromError(tx.Commit())
 }
 
+// migrateCircleRadius converts circles stored with the radius in degrees to a radius in metres.
+// It's applied once as a schema migration.
+func migrateCircleRadius(tx *sql.Tx) error {
+	rows, err := tx.Query(`
+	SELECT
+		id,
+		shape_collection_id,
+		name,
+		properties,
+		shape
+	FROM
+		shapes
+	WHERE
+		position(convert_to('"ShapeType":"circle"', 'UTF8') IN shape) > 0
+	`)
+	if err != nil {
+		return err
+	}
+
+	var shapes []model.Shape
+	for rows.Next() {
+		shape, err := scanShapeRowWithShape(rows)
+		if err != nil {
+			_ = rows.Close()
+			return err
+		}
+		shapes = append(shapes, shape)
+	}
+	_ = rows.Close()
+
+	for _, shape := range shapes {
+		migrateLegacyCircle(&shape)
+
+		shapeStorage, err := shapeStoragefromShapeModel(&shape)
+		if err != nil {
+			return err
+		}
+
+		if _, err := tx.Exec(`UPDATE shapes SET properties = $1, shape = $2 WHERE id = $3`, shape.Properties, shapeStorage, shape.ID); err != nil {
+			return err
+		}
+	}
+
+	if len(shapes) > 0 {
+		log.Infof("Migrated %d circles to a radius in metres", len(shapes))
+	}
+
+	return nil
+}
+
 func scanShapeRow(row rowScanner) (model.Shape, error) {
 	shape := model.Shape{
 		Properties: geometry.ShapeProperties{},
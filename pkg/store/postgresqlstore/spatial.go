@@ -0,0 +1,365 @@
+package postgresqlstore
+
+import (
+	"database/sql"
+
+	"github.com/eesrc/geo/pkg/model"
+	"github.com/eesrc/geo/pkg/store/errors"
+	"github.com/eesrc/geo/pkg/tria/geometry"
+)
+
+// Without PostGIS the spatial queries narrow down the rows by bounding box in the database and
+// check the actual shape with the tria geometry. With PostGIS the geometry columns are used.
+type spatialStatements struct {
+	listPositionsWithinBoundingBox *sql.Stmt
+
+	// Without PostGIS
+	listPositionsInBoundingBox           *sql.Stmt
+	listIncludeShapesByShapeCollectionID *sql.Stmt
+
+	// With PostGIS
+	listPositionsWithinShape         *sql.Stmt
+	listShapesContainingPoint        *sql.Stmt
+	listIncludeShapesContainingPoint *sql.Stmt
+	setShapeGeometry                 *sql.Stmt
+}
+
+func (s *sqlStore) initSpatialStatements() error {
+	if s.postGIS {
+		return s.initPostGISStatements()
+	}
+
+	var err error
+
+	if s.spatialStatements.listPositionsWithinBoundingBox, err = s.db.Prepare(`
+	SELECT
+		id,
+		tracker_id,
+		ts,
+		lat,
+		lon,
+		alt,
+		heading,
+		speed,
+		payload,
+		precision
+	FROM
+		positions
+	WHERE
+		tracker_id = $1
+		AND
+		lon BETWEEN $2 AND $3
+		AND
+		lat BETWEEN $4 AND $5
+	ORDER BY
+		ts DESC
+	LIMIT $6
+	OFFSET $7
+	`); err != nil {
+		return err
+	}
+
+	if s.spatialStatements.listPositionsInBoundingBox, err = s.db.Prepare(`
+	SELECT
+		id,
+		tracker_id,
+		ts,
+		lat,
+		lon,
+		alt,
+		heading,
+		speed,
+		payload,
+		precision
+	FROM
+		positions
+	WHERE
+		tracker_id = $1
+		AND
+		lon BETWEEN $2 AND $3
+		AND
+		lat BETWEEN $4 AND $5
+	ORDER BY
+		ts DESC
+	`); err != nil {
+		return err
+	}
+
+	if s.spatialStatements.listIncludeShapesByShapeCollectionID, err = s.db.Prepare(`
+	SELECT
+		id,
+		shape_collection_id,
+		name,
+		properties,
+		shape
+	FROM
+		shapes
+	WHERE
+		shape_collection_id = $1
+	ORDER BY
+		id
+	`); err != nil {
+		return err
+	}
+
+	return err
+}
+
+func (s *sqlStore) initPostGISStatements() error {
+	var err error
+
+	if s.spatialStatements.listPositionsWithinBoundingBox, err = s.db.Prepare(`
+	SELECT
+		id,
+		tracker_id,
+		ts,
+		lat,
+		lon,
+		alt,
+		heading,
+		speed,
+		payload,
+		precision
+	FROM
+		positions
+	WHERE
+		tracker_id = $1
+		AND
+		geom && ST_MakeEnvelope($2, $4, $3, $5, 4326)
+	ORDER BY
+		ts DESC
+	LIMIT $6
+	OFFSET $7
+	`); err != nil {
+		return err
+	}
+
+	if s.spatialStatements.listPositionsWithinShape, err = s.db.Prepare(`
+	SELECT
+		positions.id,
+		positions.tracker_id,
+		positions.ts,
+		positions.lat,
+		positions.lon,
+		positions.alt,
+		positions.heading,
+		positions.speed,
+		positions.payload,
+		positions.precision
+	FROM
+		positions,
+		shapes
+	WHERE
+		positions.tracker_id = $1
+		AND
+		shapes.shape_collection_id = $2
+		AND
+		shapes.id = $3
+		AND
+		ST_Covers(shapes.geom, positions.geom)
+	ORDER BY
+		positions.ts DESC
+	LIMIT $4
+	OFFSET $5
+	`); err != nil {
+		return err
+	}
+
+	if s.spatialStatements.listShapesContainingPoint, err = s.db.Prepare(`
+	SELECT
+		id,
+		shape_collection_id,
+		name,
+		properties
+	FROM
+		shapes
+	WHERE
+		shape_collection_id = $1
+		AND
+		ST_Covers(geom, ST_SetSRID(ST_MakePoint($2, $3), 4326))
+	ORDER BY
+		id
+	`); err != nil {
+		return err
+	}
+
+	if s.spatialStatements.listIncludeShapesContainingPoint, err = s.db.Prepare(`
+	SELECT
+		id,
+		shape_collection_id,
+		name,
+		properties,
+		shape
+	FROM
+		shapes
+	WHERE
+		shape_collection_id = $1
+		AND
+		ST_Covers(geom, ST_SetSRID(ST_MakePoint($2, $3), 4326))
+	ORDER BY
+		id
+	`); err != nil {
+		return err
+	}
+
+	if s.spatialStatements.setShapeGeometry, err = s.db.Prepare(`
+	UPDATE shapes
+	SET
+		geom = CASE
+			WHEN $2::float8 > 0 THEN ST_Buffer(ST_GeomFromText($1, 4326)::geography, $2::float8)::geometry
+			ELSE ST_GeomFromText($1, 4326)
+		END
+	WHERE
+		id = $3
+	`); err != nil {
+		return err
+	}
+
+	return err
+}
+
+func (s *sqlStore) ListPositionsWithinShape(trackerID int64, shapeCollectionID int64, shapeID int64, offset int64, limit int64) ([]model.Position, error) {
+	if s.postGIS {
+		rows, err := s.spatialStatements.listPositionsWithinShape.Query(
+			trackerID,
+			shapeCollectionID,
+			shapeID,
+			limit,
+			offset,
+		)
+		return scanPositionRows(rows, err)
+	}
+
+	var positions []model.Position
+
+	shape, err := scanShapeRowWithShape(s.shapeStatements.getIncludeShape.QueryRow(
+		shapeID,
+		shapeCollectionID,
+	))
+
+	if err != nil {
+		return positions, errors.NewStorageErrorFromError(err)
+	}
+
+	boundingBox := shape.Shape.GetBoundingBox()
+	rows, err := s.spatialStatements.listPositionsInBoundingBox.Query(
+		trackerID,
+		boundingBox.MinX,
+		boundingBox.MaxX,
+		boundingBox.MinY,
+		boundingBox.MaxY,
+	)
+
+	if err != nil {
+		return positions, errors.NewStorageErrorFromError(err)
+	}
+
+	defer rows.Close()
+
+	var skipped int64
+	for rows.Next() && int64(len(positions)) < limit {
+		position, err := scanPositionRow(rows)
+
+		if err != nil {
+			return positions, errors.NewStorageErrorFromError(err)
+		}
+
+		point := geometry.Point{X: position.Lon, Y: position.Lat}
+		if !shape.Shape.PointInside(&point) {
+			continue
+		}
+
+		if skipped < offset {
+			skipped++
+			continue
+		}
+
+		positions = append(positions, position)
+	}
+
+	return positions, nil
+}
+
+func (s *sqlStore) ListPositionsWithinBoundingBox(trackerID int64, boundingBox geometry.BoundingBox, offset int64, limit int64) ([]model.Position, error) {
+	rows, err := s.spatialStatements.listPositionsWithinBoundingBox.Query(
+		trackerID,
+		boundingBox.MinX,
+		boundingBox.MaxX,
+		boundingBox.MinY,
+		boundingBox.MaxY,
+		limit,
+		offset,
+	)
+
+	return scanPositionRows(rows, err)
+}
+
+func (s *sqlStore) ListShapesContainingPoint(shapeCollectionID int64, point geometry.Point, includeGeoJSON bool) ([]model.Shape, error) {
+	var shapes []model.Shape
+	var rows *sql.Rows
+	var err error
+
+	switch {
+	case s.postGIS && includeGeoJSON:
+		rows, err = s.spatialStatements.listIncludeShapesContainingPoint.Query(shapeCollectionID, point.X, point.Y)
+	case s.postGIS:
+		rows, err = s.spatialStatements.listShapesContainingPoint.Query(shapeCollectionID, point.X, point.Y)
+	default:
+		rows, err = s.spatialStatements.listIncludeShapesByShapeCollectionID.Query(shapeCollectionID)
+	}
+
+	if err != nil {
+		return shapes, errors.NewStorageErrorFromError(err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var shape model.Shape
+		if s.postGIS && !includeGeoJSON {
+			shape, err = scanShapeRow(rows)
+		} else {
+			shape, err = scanShapeRowWithShape(rows)
+		}
+
+		if err != nil {
+			return shapes, errors.NewStorageErrorFromError(err)
+		}
+
+		if !s.postGIS {
+			if !shape.Shape.PointInsideBoundingBox(&point) || !shape.Shape.PointInside(&point) {
+				continue
+			}
+
+			if !includeGeoJSON {
+				shape.Shape = nil
+			}
+		}
+
+		shapes = append(shapes, shape)
+	}
+
+	return shapes, nil
+}
+
+func scanPositionRows(rows *sql.Rows, err error) ([]model.Position, error) {
+	var positions []model.Position
+
+	if err != nil {
+		return positions, errors.NewStorageErrorFromError(err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		position, err := scanPositionRow(rows)
+
+		if err != nil {
+			return positions, errors.NewStorageErrorFromError(err)
+		}
+
+		positions = append(positions, position)
+	}
+
+	return positions, nil
+}
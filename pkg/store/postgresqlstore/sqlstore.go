@@ -3,7 +3,9 @@ package postgresqlstore
 import (
 	"database/sql"
 	"fmt"
-	"strings"
+
+	"github.com/eesrc/geo/pkg/store/migration"
+	log "github.com/sirupsen/logrus"
 
 	// PostgreSQL driver for production servers and Real Backends (tm)
 	_ "github.com/lib/pq"
@@ -14,13 +16,17 @@ import (
 // sqlStore is a generic implementation of the Store
 type sqlStore struct {
 	db *sql.DB
+	// postGIS is set when spatial queries use the PostGIS geometry columns
+	postGIS bool
 
 	collectionStatements
+	deliveryStatements
 	geoSubscriptionStatements
 	movementStatements
 	positionStatements
 	shapeCollectionStatements
 	shapeStatements
+	spatialStatements
 	subscriptionStatements
 	teamStatements
 	tokenStatements
@@ -32,6 +38,12 @@ type sqlStore struct {
 
 // New creates a new Store backed by given driver.
 func New(connectionString string, create bool) (*sqlStore, error) {
+	return NewWithPostGIS(connectionString, create, false)
+}
+
+// NewWithPostGIS creates a new Store like New. With postGIS set the positions and shapes get
+// PostGIS geometry columns which are used for spatial queries.
+func NewWithPostGIS(connectionString string, create bool, postGIS bool) (*sqlStore, error) {
 	var store *sqlStore
 
 	d, err := sql.Open("postgres", connectionString)
@@ -43,11 +55,11 @@ func New(connectionString string, create bool) (*sqlStore, error) {
 		return nil, err
 	}
 
-	if create {
-		createSchema(d)
+	if err := migrateSchema(d, create, postGIS); err != nil {
+		return nil, err
 	}
 
-	store = &sqlStore{db: d}
+	store = &sqlStore{db: d, postGIS: postGIS}
 
 	if err := store.initAuthStatements(); err != nil {
 		return store, fmt.Errorf("Failed to initialize auth statements: %v", err)
@@ -61,6 +73,10 @@ func New(connectionString string, create bool) (*sqlStore, error) {
 		return store, fmt.Errorf("Failed to initialize collection statements: %v", err)
 	}
 
+	if err := store.initDeliveryStatements(); err != nil {
+		return store, fmt.Errorf("Failed to initialize delivery statements: %v", err)
+	}
+
 	if err := store.initMovementStatements(); err != nil {
 		return store, fmt.Errorf("Failed to initialize movement statements: %v", err)
 	}
@@ -77,6 +93,10 @@ func New(connectionString string, create bool) (*sqlStore, error) {
 		return store, fmt.Errorf("Failed to initialize shape statements: %v", err)
 	}
 
+	if err := store.initSpatialStatements(); err != nil {
+		return store, fmt.Errorf("Failed to initialize spatial statements: %v", err)
+	}
+
 	if err := store.initSubscriptionStatements(); err != nil {
 		return store, fmt.Errorf("Failed to initialize subscription statements: %v", err)
 	}
@@ -97,6 +117,12 @@ func New(connectionString string, create bool) (*sqlStore, error) {
 		return store, fmt.Errorf("Failed to initialize user statements: %v", err)
 	}
 
+	if postGIS {
+		if err := store.backfillShapeGeometries(); err != nil {
+			return store, fmt.Errorf("Failed to add PostGIS geometry to shapes: %v", err)
+		}
+	}
+
 	return store, nil
 }
 
@@ -105,15 +131,53 @@ func (s *sqlStore) Close() error {
 	return s.db.Close()
 }
 
-// createSchema creates the database schema for Geo.  If this fails we
-// cannot meaningfully proceed so failure to create the schema will
-// cause a panic.
-func createSchema(db *sql.DB) {
-	for n, statement := range strings.Split(postgresSchema, ";") {
-		if _, err := db.Exec(statement); err != nil {
-			panic(fmt.Sprintf("Statement %d failed: \"%s\" : %s", n+1, statement, err))
+// NewMigrator returns a Migrator for the schema of the PostgreSQL database without
+// applying any migrations. The PostGIS migrations are only applied with postGIS set.
+func NewMigrator(connectionString string, postGIS bool) (*migration.Migrator, error) {
+	d, err := sql.Open("postgres", connectionString)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = d.Ping(); err != nil {
+		return nil, err
+	}
+
+	return newMigrator(d, postGIS)
+}
+
+func newMigrator(db *sql.DB, postGIS bool) (*migration.Migrator, error) {
+	if postGIS {
+		return migration.NewMigrator(db, migrations, postGISOption)
+	}
+
+	return migration.NewMigrator(db, migrations)
+}
+
+// migrateSchema applies any pending schema migrations to the database. If apply is false the
+// pending migrations are only logged, and must be applied with the migrate command.
+func migrateSchema(db *sql.DB, apply bool, postGIS bool) error {
+	migrator, err := newMigrator(db, postGIS)
+	if err != nil {
+		return fmt.Errorf("Failed to initialize schema migrations: %v", err)
+	}
+
+	if !apply {
+		pending, err := migrator.Pending()
+		if err != nil {
+			return fmt.Errorf("Failed to check schema migrations: %v", err)
 		}
+		if len(pending) > 0 {
+			log.Warnf("The database schema has %d pending migrations", len(pending))
+		}
+		return nil
+	}
+
+	if _, err := migrator.Up(); err != nil {
+		return fmt.Errorf("Failed to migrate schema: %v", err)
 	}
+
+	return nil
 }
 
 // rowScanner implements Scan - ie read from both sql.Row and sql.Rows. i'm not sure
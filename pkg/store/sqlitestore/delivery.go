@@ -0,0 +1,230 @@
+package sqlitestore
+
+import (
+	"database/sql"
+
+	"github.com/eesrc/geo/pkg/model"
+	"github.com/eesrc/geo/pkg/store/errors"
+)
+
+type deliveryStatements struct {
+	create               *sql.Stmt
+	get                  *sql.Stmt
+	update               *sql.Stmt
+	listBySubscriptionID *sql.Stmt
+}
+
+func (s *sqliteStore) initDeliveryStatements() error {
+	var err error
+
+	if s.deliveryStatements.create, err = s.db.Prepare(`
+	INSERT INTO deliveries (
+		subscription_id,
+		output,
+		status,
+		recipient,
+		payload,
+		attempts,
+		last_error,
+		created,
+		updated
+	) VALUES (
+		$1,
+		$2,
+		$3,
+		$4,
+		$5,
+		$6,
+		$7,
+		$8,
+		$9
+	)
+	`); err != nil {
+		return err
+	}
+
+	if s.deliveryStatements.get, err = s.db.Prepare(`
+	SELECT
+		id,
+		subscription_id,
+		output,
+		status,
+		recipient,
+		payload,
+		attempts,
+		last_error,
+		created,
+		updated
+	FROM
+		deliveries
+	WHERE
+		subscription_id = $1
+		AND
+		id = $2
+	`); err != nil {
+		return err
+	}
+
+	if s.deliveryStatements.update, err = s.db.Prepare(`
+	UPDATE deliveries
+	SET
+		status = $1,
+		attempts = $2,
+		last_error = $3,
+		updated = $4
+	WHERE
+		id = $5
+	`); err != nil {
+		return err
+	}
+
+	if s.deliveryStatements.listBySubscriptionID, err = s.db.Prepare(`
+	SELECT
+		id,
+		subscription_id,
+		output,
+		status,
+		recipient,
+		payload,
+		attempts,
+		last_error,
+		created,
+		updated
+	FROM
+		deliveries
+	WHERE
+		subscription_id = $1
+		AND
+		($2 = '' OR status = $2)
+	ORDER BY
+		id DESC
+	LIMIT $3
+	OFFSET $4
+	`); err != nil {
+		return err
+	}
+
+	return err
+}
+
+func (s *sqliteStore) CreateDelivery(delivery *model.Delivery) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r, err := s.deliveryStatements.create.Exec(
+		delivery.SubscriptionID,
+		delivery.Output,
+		delivery.Status,
+		delivery.Recipient,
+		delivery.Payload,
+		delivery.Attempts,
+		delivery.LastError,
+		delivery.Created,
+		delivery.Updated,
+	)
+
+	if err != nil {
+		return -1, errors.NewStorageErrorFromError(err)
+	}
+
+	lastInsertID, err := r.LastInsertId()
+
+	if err != nil {
+		return -1, errors.NewStorageErrorFromError(err)
+	}
+
+	return lastInsertID, nil
+}
+
+func (s *sqliteStore) GetDelivery(subscriptionID int64, deliveryID int64) (*model.Delivery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	row := s.deliveryStatements.get.QueryRow(
+		subscriptionID,
+		deliveryID,
+	)
+
+	delivery, err := scanDeliveryRow(row)
+	return &delivery, errors.NewStorageErrorFromError(err)
+}
+
+func (s *sqliteStore) UpdateDelivery(delivery *model.Delivery) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r, err := s.deliveryStatements.update.Exec(
+		delivery.Status,
+		delivery.Attempts,
+		delivery.LastError,
+		delivery.Updated,
+		delivery.ID,
+	)
+
+	if err != nil {
+		return errors.NewStorageErrorFromError(err)
+	}
+
+	rowsAffected, err := r.RowsAffected()
+
+	if err != nil {
+		return errors.NewStorageErrorFromError(err)
+	}
+
+	if rowsAffected == 0 {
+		return errors.NewStorageError(errors.NotFoundError, sql.ErrNoRows)
+	}
+
+	return nil
+}
+
+func (s *sqliteStore) ListDeliveriesBySubscriptionID(subscriptionID int64, status string, offset int64, limit int64) ([]model.Delivery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var deliveries []model.Delivery
+
+	rows, err := s.deliveryStatements.listBySubscriptionID.Query(
+		subscriptionID,
+		status,
+		limit,
+		offset,
+	)
+
+	if err != nil {
+		return deliveries, errors.NewStorageErrorFromError(err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		delivery, err := scanDeliveryRow(rows)
+
+		if err != nil {
+			return deliveries, errors.NewStorageErrorFromError(err)
+		}
+
+		deliveries = append(deliveries, delivery)
+	}
+
+	return deliveries, nil
+}
+
+func scanDeliveryRow(row rowScanner) (model.Delivery, error) {
+	delivery := model.Delivery{}
+
+	err := row.Scan(
+		&delivery.ID,
+		&delivery.SubscriptionID,
+		&delivery.Output,
+		&delivery.Status,
+		&delivery.Recipient,
+		&delivery.Payload,
+		&delivery.Attempts,
+		&delivery.LastError,
+		&delivery.Created,
+		&delivery.Updated,
+	)
+
+	return delivery, err
+}
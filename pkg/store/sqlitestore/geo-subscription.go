@@ -2,18 +2,17 @@ package sqlitestore
 
 import (
 	"database/sql"
-	"time"
 
 	"github.com/eesrc/geo/pkg/model"
 	"github.com/eesrc/geo/pkg/store/errors"
-
-	log "github.com/sirupsen/logrus"
 )
 
 type geoSubscriptionStatements struct {
-	getBySubscription       *sql.Stmt
-	list                    *sql.Stmt
-	listByShapeCollectionID *sql.Stmt
+	getBySubscription              *sql.Stmt
+	list                           *sql.Stmt
+	listAfterID                    *sql.Stmt
+	listByShapeCollectionID        *sql.Stmt
+	listByShapeCollectionIDAfterID *sql.Stmt
 }
 
 func (s *sqliteStore) initGeoSubscriptionStatements() error {
@@ -33,6 +32,10 @@ func (s *sqliteStore) initGeoSubscriptionStatements() error {
 		subscriptions.shape_collection_id,
 		subscriptions.trackable_type,
 		subscriptions.trackable_id,
+		subscriptions.dwell_time,
+		subscriptions.confirm_positions,
+		subscriptions.confirm_time,
+		subscriptions.boundary_buffer,
 
 		shape_collections.id,
 		shape_collections.team_id,
@@ -63,6 +66,10 @@ func (s *sqliteStore) initGeoSubscriptionStatements() error {
 		subscriptions.shape_collection_id,
 		subscriptions.trackable_type,
 		subscriptions.trackable_id,
+		subscriptions.dwell_time,
+		subscriptions.confirm_positions,
+		subscriptions.confirm_time,
+		subscriptions.boundary_buffer,
 
 		shape_collections.id,
 		shape_collections.team_id,
@@ -81,6 +88,43 @@ func (s *sqliteStore) initGeoSubscriptionStatements() error {
 		return err
 	}
 
+	if s.geoSubscriptionStatements.listAfterID, err = s.db.Prepare(`
+	SELECT
+		subscriptions.id,
+		subscriptions.team_id,
+		subscriptions.name,
+		subscriptions.description,
+		subscriptions.active,
+		subscriptions.output,
+		subscriptions.output_config,
+		subscriptions.types,
+		subscriptions.confidences,
+		subscriptions.shape_collection_id,
+		subscriptions.trackable_type,
+		subscriptions.trackable_id,
+		subscriptions.dwell_time,
+		subscriptions.confirm_positions,
+		subscriptions.confirm_time,
+		subscriptions.boundary_buffer,
+
+		shape_collections.id,
+		shape_collections.team_id,
+		shape_collections.name,
+		shape_collections.description
+	FROM subscriptions
+	LEFT JOIN
+		shape_collections
+	ON
+		shape_collections.id=subscriptions.shape_collection_id
+	WHERE
+		subscriptions.id > $1
+	ORDER BY
+		subscriptions.id ASC
+	LIMIT $2
+	`); err != nil {
+		return err
+	}
+
 	if s.geoSubscriptionStatements.listByShapeCollectionID, err = s.db.Prepare(`
 	SELECT
 		subscriptions.id,
@@ -95,6 +139,10 @@ func (s *sqliteStore) initGeoSubscriptionStatements() error {
 		subscriptions.shape_collection_id,
 		subscriptions.trackable_type,
 		subscriptions.trackable_id,
+		subscriptions.dwell_time,
+		subscriptions.confirm_positions,
+		subscriptions.confirm_time,
+		subscriptions.boundary_buffer,
 
 		shape_collections.id,
 		shape_collections.team_id,
@@ -115,6 +163,45 @@ func (s *sqliteStore) initGeoSubscriptionStatements() error {
 		return err
 	}
 
+	if s.geoSubscriptionStatements.listByShapeCollectionIDAfterID, err = s.db.Prepare(`
+	SELECT
+		subscriptions.id,
+		subscriptions.team_id,
+		subscriptions.name,
+		subscriptions.description,
+		subscriptions.active,
+		subscriptions.output,
+		subscriptions.output_config,
+		subscriptions.types,
+		subscriptions.confidences,
+		subscriptions.shape_collection_id,
+		subscriptions.trackable_type,
+		subscriptions.trackable_id,
+		subscriptions.dwell_time,
+		subscriptions.confirm_positions,
+		subscriptions.confirm_time,
+		subscriptions.boundary_buffer,
+
+		shape_collections.id,
+		shape_collections.team_id,
+		shape_collections.name,
+		shape_collections.description
+	FROM subscriptions
+	LEFT JOIN
+		shape_collections
+	ON
+		shape_collections.id=subscriptions.shape_collection_id
+	WHERE
+		subscriptions.shape_collection_id = $1
+		AND
+		subscriptions.id > $2
+	ORDER BY
+		subscriptions.id ASC
+	LIMIT $3
+	`); err != nil {
+		return err
+	}
+
 	return err
 }
 
@@ -128,78 +215,39 @@ func (s *sqliteStore) GetGeoSubscriptionBySubscription(subscriptionID int64) (*m
 		return &geoSubscription, errors.NewStorageErrorFromError(err)
 	}
 
-	movements, err := s.ListMovementsBySubscriptionID(geoSubscription.Subscription.ID, 0, 100)
+	movements, err := s.ListLatestMovementsBySubscriptionID(geoSubscription.Subscription.ID)
 
 	if err != nil {
 		return &geoSubscription, errors.NewStorageErrorFromError(err)
 	}
 
-	shapes, err := s.ListShapesByShapeCollectionID(geoSubscription.Subscription.ShapeCollectionID, true, 0, 1000000)
-	if err != nil {
-		return &geoSubscription, errors.NewStorageErrorFromError(err)
-	}
-
 	geoSubscription.TrackerMovements = append(geoSubscription.TrackerMovements, movements...)
-	geoSubscription.Shapes = append(geoSubscription.Shapes, shapes...)
 
 	return &geoSubscription, nil
 }
 
 func (s *sqliteStore) ListGeoSubscriptions(offset, limit int64) ([]model.GeoSubscription, error) {
-	var geoSubscriptions []model.GeoSubscription
-
-	rows, err := s.geoSubscriptionStatements.list.Query(
-		limit,
-		offset,
-	)
-
-	if err != nil {
-		return geoSubscriptions, errors.NewStorageErrorFromError(err)
-	}
-
-	for rows.Next() {
-		geoSubscription, err := scanGeoSubscriptionRow(rows)
-
-		if err != nil {
-			return geoSubscriptions, errors.NewStorageErrorFromError(err)
-		}
-
-		geoSubscriptions = append(geoSubscriptions, geoSubscription)
-	}
-
-	rows.Close()
-
-	for i := range geoSubscriptions {
-		then := time.Now()
-		movements, err := s.ListMovementsBySubscriptionID(geoSubscriptions[i].Subscription.ID, 0, 100)
-		log.Infof("Fetching movement for geoSub %d took %s", geoSubscriptions[i].Subscription.ID, time.Since(then))
-
-		if err != nil {
-			return geoSubscriptions, errors.NewStorageErrorFromError(err)
-		}
+	return s.listGeoSubscriptions(s.geoSubscriptionStatements.list, limit, offset)
+}
 
-		then = time.Now()
-		shapes, err := s.ListShapesByShapeCollectionID(geoSubscriptions[i].Subscription.ShapeCollectionID, true, 0, 1000000)
-		if err != nil {
-			return geoSubscriptions, errors.NewStorageErrorFromError(err)
-		}
-		log.Infof("Listing shapes for for geoSub %d took %s with %d shapes", geoSubscriptions[i].Subscription.ID, time.Since(then), len(shapes))
+func (s *sqliteStore) ListGeoSubscriptionsAfterID(subscriptionID int64, limit int64) ([]model.GeoSubscription, error) {
+	return s.listGeoSubscriptions(s.geoSubscriptionStatements.listAfterID, subscriptionID, limit)
+}
 
-		geoSubscriptions[i].TrackerMovements = append(geoSubscriptions[i].TrackerMovements, movements...)
-		geoSubscriptions[i].Shapes = append(geoSubscriptions[i].Shapes, shapes...)
-	}
+func (s *sqliteStore) ListGeoSubscriptionsByShapeCollectionID(shapeCollectionID int64, offset int64, limit int64) ([]model.GeoSubscription, error) {
+	return s.listGeoSubscriptions(s.geoSubscriptionStatements.listByShapeCollectionID, shapeCollectionID, limit, offset)
+}
 
-	return geoSubscriptions, nil
+func (s *sqliteStore) ListGeoSubscriptionsByShapeCollectionIDAfterID(shapeCollectionID int64, subscriptionID int64, limit int64) ([]model.GeoSubscription, error) {
+	return s.listGeoSubscriptions(s.geoSubscriptionStatements.listByShapeCollectionIDAfterID, shapeCollectionID, subscriptionID, limit)
 }
 
-func (s *sqliteStore) ListGeoSubscriptionsByShapeCollectionID(shapeCollectionID int64, offset int64, limit int64) ([]model.GeoSubscription, error) {
+// listGeoSubscriptions lists the GeoSubscriptions of the statement along with the latest movements of
+// each subscription
+func (s *sqliteStore) listGeoSubscriptions(statement *sql.Stmt, args ...interface{}) ([]model.GeoSubscription, error) {
 	var geoSubscriptions []model.GeoSubscription
 
-	rows, err := s.geoSubscriptionStatements.listByShapeCollectionID.Query(
-		shapeCollectionID,
-		limit,
-		offset,
-	)
+	rows, err := statement.Query(args...)
 
 	if err != nil {
 		return geoSubscriptions, errors.NewStorageErrorFromError(err)
@@ -209,6 +257,7 @@ func (s *sqliteStore) ListGeoSubscriptionsByShapeCollectionID(shapeCollectionID
 		geoSubscription, err := scanGeoSubscriptionRow(rows)
 
 		if err != nil {
+			rows.Close()
 			return geoSubscriptions, errors.NewStorageErrorFromError(err)
 		}
 
@@ -218,19 +267,13 @@ func (s *sqliteStore) ListGeoSubscriptionsByShapeCollectionID(shapeCollectionID
 	rows.Close()
 
 	for i := range geoSubscriptions {
-		movements, err := s.ListMovementsBySubscriptionID(geoSubscriptions[i].Subscription.ID, 0, 100)
-
-		if err != nil {
-			return geoSubscriptions, errors.NewStorageErrorFromError(err)
-		}
+		movements, err := s.ListLatestMovementsBySubscriptionID(geoSubscriptions[i].Subscription.ID)
 
-		shapes, err := s.ListShapesByShapeCollectionID(geoSubscriptions[i].Subscription.ShapeCollectionID, true, 0, 1000000)
 		if err != nil {
 			return geoSubscriptions, errors.NewStorageErrorFromError(err)
 		}
 
 		geoSubscriptions[i].TrackerMovements = append(geoSubscriptions[i].TrackerMovements, movements...)
-		geoSubscriptions[i].Shapes = append(geoSubscriptions[i].Shapes, shapes...)
 	}
 
 	return geoSubscriptions, nil
@@ -252,6 +295,10 @@ func scanGeoSubscriptionRow(row rowScanner) (model.GeoSubscription, error) {
 		&geoSubscription.Subscription.ShapeCollectionID,
 		&geoSubscription.Subscription.TrackableType,
 		&geoSubscription.Subscription.TrackableID,
+		&geoSubscription.Subscription.DwellTime,
+		&geoSubscription.Subscription.ConfirmPositions,
+		&geoSubscription.Subscription.ConfirmTime,
+		&geoSubscription.Subscription.BoundaryBuffer,
 
 		&geoSubscription.ShapeCollection.ID,
 		&geoSubscription.ShapeCollection.TeamID,
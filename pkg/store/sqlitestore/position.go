@@ -2,18 +2,20 @@ package sqlitestore
 
 import (
 	"database/sql"
+	"math"
 
 	"github.com/eesrc/geo/pkg/model"
 	"github.com/eesrc/geo/pkg/store/errors"
 )
 
 type positionStatements struct {
-	create          *sql.Stmt
-	get             *sql.Stmt
-	getByUserID     *sql.Stmt
-	delete          *sql.Stmt
-	list            *sql.Stmt
-	listByTrackerID *sql.Stmt
+	create               *sql.Stmt
+	get                  *sql.Stmt
+	getByUserID          *sql.Stmt
+	getDuplicateByUserID *sql.Stmt
+	delete               *sql.Stmt
+	list                 *sql.Stmt
+	listByTrackerID      *sql.Stmt
 }
 
 func (s *sqliteStore) initPositionStatements() error {
@@ -95,6 +97,46 @@ func (s *sqliteStore) initPositionStatements() error {
 		return err
 	}
 
+	if s.positionStatements.getDuplicateByUserID, err = s.db.Prepare(`
+	SELECT
+		positions.id,
+		positions.tracker_id,
+		positions.ts,
+		positions.lat,
+		positions.lon,
+		positions.alt,
+		positions.heading,
+		positions.speed,
+		positions.payload,
+		positions.precision
+	FROM
+		positions,
+		trackers,
+		collections,
+		team_members
+	WHERE
+		positions.tracker_id = trackers.id
+		AND
+		trackers.collection_id = collections.id
+		AND
+		collections.team_id = team_members.team_id
+		AND
+		positions.tracker_id = $1
+		AND
+		positions.ts = $2
+		AND
+		positions.lat = $3
+		AND
+		positions.lon = $4
+		AND
+		team_members.user_id = $5
+	ORDER BY
+		positions.id
+	LIMIT 1
+	`); err != nil {
+		return err
+	}
+
 	if s.positionStatements.delete, err = s.db.Prepare(`
 	DELETE FROM positions
 	WHERE id = $1
@@ -151,10 +193,17 @@ func (s *sqliteStore) initPositionStatements() error {
 		tracker_id = $1
 		AND
 		team_members.user_id = $2
+		AND
+		positions.ts >= $3
+		AND
+		positions.ts <= $4
+		AND
+		(positions.ts < $4 OR positions.id < $5)
 	ORDER BY
-		ts DESC
-	LIMIT $3
-	OFFSET $4
+		positions.ts DESC,
+		positions.id DESC
+	LIMIT $6
+	OFFSET $7
 	`); err != nil {
 		return err
 	}
@@ -203,6 +252,52 @@ func (s *sqliteStore) CreatePosition(position *model.Position, userID int64) (in
 	return lastInsertID, errors.NewStorageErrorFromError(tx.Commit())
 }
 
+func (s *sqliteStore) CreatePositions(positions []model.Position, userID int64) ([]int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return []int64{}, errors.NewStorageErrorFromError(err)
+	}
+
+	positionIDs := make([]int64, len(positions))
+	adminOfTracker := make(map[int64]bool)
+
+	for i, position := range positions {
+		if !adminOfTracker[position.TrackerID] {
+			if err := s.ensureAdminOfTracker(tx, userID, position.TrackerID); err != nil {
+				_ = tx.Rollback()
+				return []int64{}, errors.NewStorageError(errors.AccessDeniedError, err)
+			}
+			adminOfTracker[position.TrackerID] = true
+		}
+
+		r, err := tx.Stmt(s.positionStatements.create).Exec(
+			position.TrackerID,
+			position.Timestamp,
+			position.Lat,
+			position.Lon,
+			position.Alt,
+			position.Heading,
+			position.Speed,
+			position.Payload,
+			position.Precision,
+		)
+
+		if err == nil {
+			positionIDs[i], err = r.LastInsertId()
+		}
+
+		if err != nil {
+			_ = tx.Rollback()
+			return []int64{}, errors.NewStorageErrorFromError(err)
+		}
+	}
+
+	return positionIDs, errors.NewStorageErrorFromError(tx.Commit())
+}
+
 func (s *sqliteStore) GetPosition(positionID int64) (*model.Position, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -233,6 +328,22 @@ func (s *sqliteStore) GetPositionByUserID(positionID int64, userID int64) (*mode
 	return &position, errors.NewStorageErrorFromError(err)
 }
 
+func (s *sqliteStore) GetDuplicatePositionByUserID(position *model.Position, userID int64) (*model.Position, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	row := s.positionStatements.getDuplicateByUserID.QueryRow(
+		position.TrackerID,
+		position.Timestamp,
+		position.Lat,
+		position.Lon,
+		userID,
+	)
+
+	duplicate, err := scanPositionRow(row)
+	return &duplicate, errors.NewStorageErrorFromError(err)
+}
+
 func (s *sqliteStore) DeletePosition(positionID int64, userID int64) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -286,10 +397,22 @@ func (s *sqliteStore) ListPositions(offset int64, limit int64) ([]model.Position
 	return positions, nil
 }
 
-func (s *sqliteStore) ListPositionsByTrackerID(trackerID int64, userID int64, offset int64, limit int64) ([]model.Position, error) {
+func (s *sqliteStore) ListPositionsByTrackerID(trackerID int64, userID int64, since int64, until int64, offset int64, limit int64) ([]model.Position, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	// Without a cursor every position up to and including until is listed
+	return s.listPositionsByTrackerID(trackerID, userID, since, model.PositionCursor{Timestamp: until, ID: math.MaxInt64}, offset, limit)
+}
+
+func (s *sqliteStore) ListPositionsByTrackerIDBeforeCursor(trackerID int64, userID int64, since int64, cursor model.PositionCursor, limit int64) ([]model.Position, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.listPositionsByTrackerID(trackerID, userID, since, cursor, 0, limit)
+}
+
+func (s *sqliteStore) listPositionsByTrackerID(trackerID int64, userID int64, since int64, cursor model.PositionCursor, offset int64, limit int64) ([]model.Position, error) {
 	tx, err := s.db.Begin()
 	if err != nil {
 		return []model.Position{}, errors.NewStorageErrorFromError(err)
@@ -304,6 +427,9 @@ func (s *sqliteStore) ListPositionsByTrackerID(trackerID int64, userID int64, of
 	rows, err := tx.Stmt(s.positionStatements.listByTrackerID).Query(
 		trackerID,
 		userID,
+		since,
+		cursor.Timestamp,
+		cursor.ID,
 		limit,
 		offset,
 	)
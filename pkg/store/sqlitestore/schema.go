@@ -1,9 +1,20 @@
 package sqlitestore
 
-const schema = `
+import "github.com/eesrc/geo/pkg/store/migration"
+
+// pragmas are set on the database before any migrations are applied
+const pragmas = `
 PRAGMA foreign_keys = ON;
 PRAGMA defer_foreign_keys = FALSE;
+`
 
+// migrations is the list of schema migrations for SQLite. Never change a migration which has
+// been released, add a new migration at the end of the list instead.
+var migrations = []migration.Migration{
+	{
+		Version:     1,
+		Description: "Initial schema",
+		Up: `
 CREATE TABLE IF NOT EXISTS users (
     id              INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
     name            VARCHAR(255),
@@ -133,4 +144,75 @@ CREATE TABLE IF NOT EXISTS position_movements(
 );
 
 CREATE INDEX IF NOT EXISTS idx_position_movements_subscription ON position_movements(subscription_id);
-`
+`,
+	},
+	{
+		Version:     2,
+		Description: "Add trigger criteria, movement state and deliveries",
+		Up: `
+ALTER TABLE subscriptions ADD COLUMN dwell_time BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE subscriptions ADD COLUMN confirm_positions INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE subscriptions ADD COLUMN confirm_time BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE subscriptions ADD COLUMN boundary_buffer REAL NOT NULL DEFAULT 0;
+
+ALTER TABLE position_movements ADD COLUMN entered BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE position_movements ADD COLUMN dwelled BOOL NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS deliveries(
+    id              INTEGER NOT NULL PRIMARY KEY AUTOINCREMENT,
+    subscription_id INTEGER NOT NULL,
+    output          VARCHAR(32),
+    status          VARCHAR(32),
+    payload         BLOB,
+    attempts        INTEGER,
+    last_error      TEXT,
+    created         TIMESTAMP,
+    updated         TIMESTAMP,
+
+    FOREIGN KEY(subscription_id) REFERENCES subscriptions(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_deliveries_subscription_status ON deliveries(subscription_id, status);
+`,
+	},
+	{
+		Version:     3,
+		Description: "Add tracker and timestamp index to positions",
+		Up: `
+CREATE INDEX IF NOT EXISTS idx_positions_tracker_ts ON positions(tracker_id, ts);
+`,
+	},
+	{
+		Version:     4,
+		Description: "Set the tracker of movements stored for collection subscriptions",
+		Up: `
+UPDATE position_movements SET tracker_id = (
+    SELECT positions.tracker_id FROM positions WHERE positions.id = position_movements.position_id
+) WHERE EXISTS (
+    SELECT 1 FROM positions
+    WHERE positions.id = position_movements.position_id AND positions.tracker_id <> position_movements.tracker_id
+);
+`,
+	},
+	{
+		Version:     5,
+		Description: "Convert the radius of circles from degrees to metres",
+		UpFunc:      migrateCircleRadius,
+	},
+	{
+		Version:     6,
+		Description: "Add a unique index on the tracker, timestamp and coordinates of positions",
+		// Stored positions aren't changed. If duplicates are already stored the index can't be created,
+		// and the duplicates have to be removed first as described in the README.
+		Up: `
+CREATE UNIQUE INDEX IF NOT EXISTS idx_positions_unique ON positions(tracker_id, ts, lat, lon);
+`,
+	},
+	{
+		Version:     7,
+		Description: "Add the recipient of deliveries",
+		Up: `
+ALTER TABLE deliveries ADD COLUMN recipient VARCHAR(255) NOT NULL DEFAULT '';
+`,
+	},
+}
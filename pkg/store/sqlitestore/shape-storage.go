@@ -21,14 +21,28 @@ type shapeType string
 
 const (
 	polygonShape shapeType = "polygon"
-	circleShape  shapeType = "circle"
+	// circleShape is a circle with the radius in degrees. Circles are no longer stored with
+	// this type, see migrateCircleRadius
+	circleShape shapeType = "circle"
+	// metricCircleShape is a circle with the radius in metres
+	metricCircleShape shapeType = "metric-circle"
+	multiPolygonShape shapeType = "multipolygon"
+	corridorShape     shapeType = "corridor"
+	tripwireShape     shapeType = "tripwire"
 )
 
+// legacyMetresPerDegree is the factor used to convert a circle radius in metres to degrees
+// before circles were stored with the radius in metres
+const legacyMetresPerDegree = 111111.0
+
 func init() {
 	gob.Register([]interface{}{})
 	gob.Register(map[string]interface{}{})
 	gob.Register(&geometry.Polygon{})
 	gob.Register(&geometry.Circle{})
+	gob.Register(&geometry.MultiPolygon{})
+	gob.Register(&geometry.Corridor{})
+	gob.Register(&geometry.Tripwire{})
 }
 
 func shapeStoragefromShapeModel(shapeModel *model.Shape) (shapeStorageModel, error) {
@@ -48,7 +62,13 @@ func shapeStoragefromShapeModel(shapeModel *model.Shape) (shapeStorageModel, err
 	case *geometry.Polygon:
 		storageModel.ShapeType = polygonShape
 	case *geometry.Circle:
-		storageModel.ShapeType = circleShape
+		storageModel.ShapeType = metricCircleShape
+	case *geometry.MultiPolygon:
+		storageModel.ShapeType = multiPolygonShape
+	case *geometry.Corridor:
+		storageModel.ShapeType = corridorShape
+	case *geometry.Tripwire:
+		storageModel.ShapeType = tripwireShape
 	}
 
 	return storageModel, nil
@@ -65,6 +85,22 @@ func shapeModelFromShapeStorage(storageModel *shapeStorageModel) (geometry.Shape
 	return shape, nil
 }
 
+// migrateLegacyCircle converts a circle shape stored with the radius in degrees to a radius in
+// metres, including the radius in the shape properties
+func migrateLegacyCircle(shape *model.Shape) {
+	if circle, ok := shape.Shape.(*geometry.Circle); ok {
+		circle.Radius *= legacyMetresPerDegree
+		migrateLegacyRadiusProperty(circle.Properties)
+	}
+	migrateLegacyRadiusProperty(shape.Properties)
+}
+
+func migrateLegacyRadiusProperty(properties geometry.ShapeProperties) {
+	if radius, ok := properties["radius"].(float64); ok {
+		properties["radius"] = radius * legacyMetresPerDegree
+	}
+}
+
 // Value implements SQL value driver
 func (shapeStore shapeStorageModel) Value() (driver.Value, error) {
 	return serializing.ValueJSON(shapeStore)
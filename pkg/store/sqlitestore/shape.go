@@ -683,6 +683,56 @@ func (s *sqliteStore) ReplaceShapesInShapeCollection(shapeCollectionID int64, us
 	return errors.NewStorageErrorFromError(tx.Commit())
 }
 
+// migrateCircleRadius converts circles stored with the radius in degrees to a radius in metres.
+// It's applied once as a schema migration.
+func migrateCircleRadius(tx *sql.Tx) error {
+	rows, err := tx.Query(`
+	SELECT
+		id,
+		shape_collection_id,
+		name,
+		properties,
+		shape
+	FROM
+		shapes
+	WHERE
+		shape LIKE '%"ShapeType":"circle"%'
+	`)
+	if err != nil {
+		return err
+	}
+
+	var shapes []model.Shape
+	for rows.Next() {
+		shape, err := scanShapeRowWithShape(rows)
+		if err != nil {
+			_ = rows.Close()
+			return err
+		}
+		shapes = append(shapes, shape)
+	}
+	_ = rows.Close()
+
+	for _, shape := range shapes {
+		migrateLegacyCircle(&shape)
+
+		shapeStorage, err := shapeStoragefromShapeModel(&shape)
+		if err != nil {
+			return err
+		}
+
+		if _, err := tx.Exec(`UPDATE shapes SET properties = $1, shape = $2 WHERE id = $3`, shape.Properties, shapeStorage, shape.ID); err != nil {
+			return err
+		}
+	}
+
+	if len(shapes) > 0 {
+		log.Infof("Migrated %d circles to a radius in metres", len(shapes))
+	}
+
+	return nil
+}
+
 func scanShapeRow(row rowScanner) (model.Shape, error) {
 	shape := model.Shape{
 		Properties: geometry.ShapeProperties{},
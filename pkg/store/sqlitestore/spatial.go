@@ -0,0 +1,218 @@
+package sqlitestore
+
+import (
+	"database/sql"
+
+	"github.com/eesrc/geo/pkg/model"
+	"github.com/eesrc/geo/pkg/store/errors"
+	"github.com/eesrc/geo/pkg/tria/geometry"
+)
+
+// SQLite has no spatial support, so the spatial queries narrow down the rows by bounding box
+// in the database and check the actual shape with the tria geometry.
+type spatialStatements struct {
+	listPositionsInBoundingBox           *sql.Stmt
+	listPositionsWithinBoundingBox       *sql.Stmt
+	listIncludeShapesByShapeCollectionID *sql.Stmt
+}
+
+func (s *sqliteStore) initSpatialStatements() error {
+	var err error
+
+	if s.spatialStatements.listPositionsInBoundingBox, err = s.db.Prepare(`
+	SELECT
+		id,
+		tracker_id,
+		ts,
+		lat,
+		lon,
+		alt,
+		heading,
+		speed,
+		payload,
+		precision
+	FROM
+		positions
+	WHERE
+		tracker_id = $1
+		AND
+		lon BETWEEN $2 AND $3
+		AND
+		lat BETWEEN $4 AND $5
+	ORDER BY
+		ts DESC
+	`); err != nil {
+		return err
+	}
+
+	if s.spatialStatements.listPositionsWithinBoundingBox, err = s.db.Prepare(`
+	SELECT
+		id,
+		tracker_id,
+		ts,
+		lat,
+		lon,
+		alt,
+		heading,
+		speed,
+		payload,
+		precision
+	FROM
+		positions
+	WHERE
+		tracker_id = $1
+		AND
+		lon BETWEEN $2 AND $3
+		AND
+		lat BETWEEN $4 AND $5
+	ORDER BY
+		ts DESC
+	LIMIT $6
+	OFFSET $7
+	`); err != nil {
+		return err
+	}
+
+	if s.spatialStatements.listIncludeShapesByShapeCollectionID, err = s.db.Prepare(`
+	SELECT
+		id,
+		shape_collection_id,
+		name,
+		properties,
+		shape
+	FROM
+		shapes
+	WHERE
+		shape_collection_id = $1
+	ORDER BY
+		id
+	`); err != nil {
+		return err
+	}
+
+	return err
+}
+
+func (s *sqliteStore) ListPositionsWithinShape(trackerID int64, shapeCollectionID int64, shapeID int64, offset int64, limit int64) ([]model.Position, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var positions []model.Position
+
+	shape, err := scanShapeRowWithShape(s.shapeStatements.getIncludeShape.QueryRow(
+		shapeID,
+		shapeCollectionID,
+	))
+
+	if err != nil {
+		return positions, errors.NewStorageErrorFromError(err)
+	}
+
+	boundingBox := shape.Shape.GetBoundingBox()
+	rows, err := s.spatialStatements.listPositionsInBoundingBox.Query(
+		trackerID,
+		boundingBox.MinX,
+		boundingBox.MaxX,
+		boundingBox.MinY,
+		boundingBox.MaxY,
+	)
+
+	if err != nil {
+		return positions, errors.NewStorageErrorFromError(err)
+	}
+
+	defer rows.Close()
+
+	var skipped int64
+	for rows.Next() && int64(len(positions)) < limit {
+		position, err := scanPositionRow(rows)
+
+		if err != nil {
+			return positions, errors.NewStorageErrorFromError(err)
+		}
+
+		point := geometry.Point{X: position.Lon, Y: position.Lat}
+		if !shape.Shape.PointInside(&point) {
+			continue
+		}
+
+		if skipped < offset {
+			skipped++
+			continue
+		}
+
+		positions = append(positions, position)
+	}
+
+	return positions, nil
+}
+
+func (s *sqliteStore) ListPositionsWithinBoundingBox(trackerID int64, boundingBox geometry.BoundingBox, offset int64, limit int64) ([]model.Position, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var positions []model.Position
+	rows, err := s.spatialStatements.listPositionsWithinBoundingBox.Query(
+		trackerID,
+		boundingBox.MinX,
+		boundingBox.MaxX,
+		boundingBox.MinY,
+		boundingBox.MaxY,
+		limit,
+		offset,
+	)
+
+	if err != nil {
+		return positions, errors.NewStorageErrorFromError(err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		position, err := scanPositionRow(rows)
+
+		if err != nil {
+			return positions, errors.NewStorageErrorFromError(err)
+		}
+
+		positions = append(positions, position)
+	}
+
+	return positions, nil
+}
+
+func (s *sqliteStore) ListShapesContainingPoint(shapeCollectionID int64, point geometry.Point, includeGeoJSON bool) ([]model.Shape, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var shapes []model.Shape
+	rows, err := s.spatialStatements.listIncludeShapesByShapeCollectionID.Query(
+		shapeCollectionID,
+	)
+
+	if err != nil {
+		return shapes, errors.NewStorageErrorFromError(err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		shape, err := scanShapeRowWithShape(rows)
+
+		if err != nil {
+			return shapes, errors.NewStorageErrorFromError(err)
+		}
+
+		if !shape.Shape.PointInsideBoundingBox(&point) || !shape.Shape.PointInside(&point) {
+			continue
+		}
+
+		if !includeGeoJSON {
+			shape.Shape = nil
+		}
+
+		shapes = append(shapes, shape)
+	}
+
+	return shapes, nil
+}
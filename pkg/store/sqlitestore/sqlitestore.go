@@ -16,6 +16,7 @@ import (
 	"strings"
 	"sync"
 
+	"github.com/eesrc/geo/pkg/store/migration"
 	_ "github.com/mattn/go-sqlite3" // loads the SQLite3 driver
 )
 
@@ -25,11 +26,13 @@ type sqliteStore struct {
 	db *sql.DB
 
 	collectionStatements
+	deliveryStatements
 	geoSubscriptionStatements
 	movementStatements
 	positionStatements
 	shapeCollectionStatements
 	shapeStatements
+	spatialStatements
 	subscriptionStatements
 	teamStatements
 	tokenStatements
@@ -57,7 +60,9 @@ func New(dbFile string, create bool) (*sqliteStore, error) {
 	}
 
 	if !databaseFileExisted || create {
-		createSchema(d, dbFile)
+		if err := migrateSchema(d); err != nil {
+			return nil, err
+		}
 	}
 
 	store := &sqliteStore{db: d}
@@ -74,6 +79,10 @@ func New(dbFile string, create bool) (*sqliteStore, error) {
 		return store, fmt.Errorf("Failed to initialize collection statements: %v", err)
 	}
 
+	if err := store.initDeliveryStatements(); err != nil {
+		return store, fmt.Errorf("Failed to initialize delivery statements: %v", err)
+	}
+
 	if err := store.initMovementStatements(); err != nil {
 		return store, fmt.Errorf("Failed to initialize movement statements: %v", err)
 	}
@@ -90,6 +99,10 @@ func New(dbFile string, create bool) (*sqliteStore, error) {
 		return store, fmt.Errorf("Failed to initialize shape statements: %v", err)
 	}
 
+	if err := store.initSpatialStatements(); err != nil {
+		return store, fmt.Errorf("Failed to initialize spatial statements: %v", err)
+	}
+
 	if err := store.initSubscriptionStatements(); err != nil {
 		return store, fmt.Errorf("Failed to initialize subscription statements: %v", err)
 	}
@@ -118,15 +131,46 @@ func (s *sqliteStore) Close() error {
 	return s.db.Close()
 }
 
-// createSchema creates the database schema for Geo.  If this fails we
-// cannot meaningfully proceed so failure to create the schema will
-// cause a panic.
-func createSchema(db *sql.DB, fileName string) {
-	for n, statement := range strings.Split(schema, ";") {
+// NewMigrator returns a Migrator for the schema of the SQLite database without
+// applying any migrations
+func NewMigrator(dbFile string) (*migration.Migrator, error) {
+	d, err := sql.Open("sqlite3", dbFile)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = d.Ping(); err != nil {
+		return nil, err
+	}
+
+	return newMigrator(d)
+}
+
+func newMigrator(db *sql.DB) (*migration.Migrator, error) {
+	for n, statement := range strings.Split(pragmas, ";") {
+		if strings.TrimSpace(statement) == "" {
+			continue
+		}
 		if _, err := db.Exec(statement); err != nil {
-			panic(fmt.Sprintf("Statement %d failed: \"%s\" : %s", n+1, statement, err))
+			return nil, fmt.Errorf("Statement %d failed: \"%s\" : %v", n+1, statement, err)
 		}
 	}
+
+	return migration.NewMigrator(db, migrations)
+}
+
+// migrateSchema applies any pending schema migrations to the database
+func migrateSchema(db *sql.DB) error {
+	migrator, err := newMigrator(db)
+	if err != nil {
+		return fmt.Errorf("Failed to initialize schema migrations: %v", err)
+	}
+
+	if _, err := migrator.Up(); err != nil {
+		return fmt.Errorf("Failed to migrate schema: %v", err)
+	}
+
+	return nil
 }
 
 // rowScanner implements Scan - ie read from both sql.Row and sql.Rows. i'm not sure
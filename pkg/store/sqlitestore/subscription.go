@@ -37,7 +37,11 @@ func (s *sqliteStore) initSubscriptionStatements() error {
 		confidences,
 		shape_collection_id,
 		trackable_type,
-		trackable_id
+		trackable_id,
+		dwell_time,
+		confirm_positions,
+		confirm_time,
+		boundary_buffer
 	) VALUES (
 		$1,
 		$2,
@@ -49,7 +53,11 @@ func (s *sqliteStore) initSubscriptionStatements() error {
 		$8,
 		$9,
 		$10,
-		$11
+		$11,
+		$12,
+		$13,
+		$14,
+		$15
 	)
 	`); err != nil {
 		return err
@@ -68,7 +76,11 @@ func (s *sqliteStore) initSubscriptionStatements() error {
 		confidences,
 		shape_collection_id,
 		trackable_type,
-		trackable_id
+		trackable_id,
+		dwell_time,
+		confirm_positions,
+		confirm_time,
+		boundary_buffer
 	FROM subscriptions
 	WHERE id = $1
 	`); err != nil {
@@ -88,7 +100,11 @@ func (s *sqliteStore) initSubscriptionStatements() error {
 		subscriptions.confidences,
 		subscriptions.shape_collection_id,
 		subscriptions.trackable_type,
-		subscriptions.trackable_id
+		subscriptions.trackable_id,
+		subscriptions.dwell_time,
+		subscriptions.confirm_positions,
+		subscriptions.confirm_time,
+		subscriptions.boundary_buffer
 	FROM
 		subscriptions,
 		team_members
@@ -115,8 +131,12 @@ func (s *sqliteStore) initSubscriptionStatements() error {
 		confidences = $8,
 		shape_collection_id = $9,
 		trackable_type = $10,
-		trackable_id = $11
-	WHERE id = $12
+		trackable_id = $11,
+		dwell_time = $12,
+		confirm_positions = $13,
+		confirm_time = $14,
+		boundary_buffer = $15
+	WHERE id = $16
 	`); err != nil {
 		return err
 	}
@@ -141,7 +161,11 @@ func (s *sqliteStore) initSubscriptionStatements() error {
 		confidences,
 		shape_collection_id,
 		trackable_type,
-		trackable_id
+		trackable_id,
+		dwell_time,
+		confirm_positions,
+		confirm_time,
+		boundary_buffer
 	FROM
 		subscriptions
 	ORDER BY
@@ -165,7 +189,11 @@ func (s *sqliteStore) initSubscriptionStatements() error {
 		confidences,
 		shape_collection_id,
 		trackable_type,
-		trackable_id
+		trackable_id,
+		dwell_time,
+		confirm_positions,
+		confirm_time,
+		boundary_buffer
 	FROM
 		subscriptions
 	WHERE
@@ -193,7 +221,11 @@ func (s *sqliteStore) initSubscriptionStatements() error {
 		confidences,
 		shape_collection_id,
 		trackable_type,
-		trackable_id
+		trackable_id,
+		dwell_time,
+		confirm_positions,
+		confirm_time,
+		boundary_buffer
 	FROM
 		subscriptions
 	WHERE
@@ -219,7 +251,11 @@ func (s *sqliteStore) initSubscriptionStatements() error {
 		subscriptions.confidences,
 		subscriptions.shape_collection_id,
 		subscriptions.trackable_type,
-		subscriptions.trackable_id
+		subscriptions.trackable_id,
+		subscriptions.dwell_time,
+		subscriptions.confirm_positions,
+		subscriptions.confirm_time,
+		subscriptions.boundary_buffer
 	FROM
 		subscriptions,
 		team_members
@@ -264,6 +300,10 @@ func (s *sqliteStore) CreateSubscription(subscription *model.Subscription, userI
 		subscription.ShapeCollectionID,
 		subscription.TrackableType,
 		subscription.TrackableID,
+		subscription.DwellTime,
+		subscription.ConfirmPositions,
+		subscription.ConfirmTime,
+		subscription.BoundaryBuffer,
 	)
 	if err != nil {
 		_ = tx.Rollback()
@@ -340,6 +380,10 @@ func (s *sqliteStore) UpdateSubscription(subscription *model.Subscription, userI
 		subscription.ShapeCollectionID,
 		subscription.TrackableType,
 		subscription.TrackableID,
+		subscription.DwellTime,
+		subscription.ConfirmPositions,
+		subscription.ConfirmTime,
+		subscription.BoundaryBuffer,
 		subscription.ID,
 	)
 
@@ -577,6 +621,10 @@ func scanSubscriptionRow(row rowScanner) (model.Subscription, error) {
 		&subscription.ShapeCollectionID,
 		&subscription.TrackableType,
 		&subscription.TrackableID,
+		&subscription.DwellTime,
+		&subscription.ConfirmPositions,
+		&subscription.ConfirmTime,
+		&subscription.BoundaryBuffer,
 	)
 
 	return subscription, err
@@ -2,11 +2,15 @@
 package store
 
 import (
+	"fmt"
+
 	log "github.com/sirupsen/logrus"
 
 	"github.com/eesrc/geo/pkg/model"
+	"github.com/eesrc/geo/pkg/store/migration"
 	"github.com/eesrc/geo/pkg/store/postgresqlstore"
 	"github.com/eesrc/geo/pkg/store/sqlitestore"
+	"github.com/eesrc/geo/pkg/tria/geometry"
 )
 
 // Store defines the persistence layer API
@@ -91,18 +95,55 @@ type Store interface {
 
 	// Position
 	CreatePosition(position *model.Position, userID int64) (int64, error)
+	// CreatePositions creates the positions in one transaction and returns their IDs in the same order.
+	// Either every position is created or none of them.
+	CreatePositions(positions []model.Position, userID int64) ([]int64, error)
 	GetPosition(id int64) (*model.Position, error)
 	GetPositionByUserID(id int64, userID int64) (*model.Position, error)
+	// GetDuplicatePositionByUserID gets a stored position with the same tracker, timestamp and coordinates as
+	// the given position. Returns a NotFoundError if there's no such position.
+	GetDuplicatePositionByUserID(position *model.Position, userID int64) (*model.Position, error)
 	DeletePosition(positionID int64, userID int64) error
 
 	ListPositions(offset int64, limit int64) ([]model.Position, error)
-	ListPositionsByTrackerID(trackerID int64, userID int64, offset int64, limit int64) ([]model.Position, error)
+	// ListPositionsByTrackerID lists the positions of a tracker with a timestamp from since to until, both
+	// inclusive and in nanoseconds, newest first
+	ListPositionsByTrackerID(trackerID int64, userID int64, since int64, until int64, offset int64, limit int64) ([]model.Position, error)
+	// ListPositionsByTrackerIDBeforeCursor lists the positions of a tracker older than the cursor and no
+	// older than since, newest first. The cursor of the last position is where the next page starts.
+	ListPositionsByTrackerIDBeforeCursor(trackerID int64, userID int64, since int64, cursor model.PositionCursor, limit int64) ([]model.Position, error)
+
+	// Spatial queries. These are answered by PostGIS when enabled, otherwise by the tria geometry
+	ListPositionsWithinShape(trackerID int64, shapeCollectionID int64, shapeID int64, offset int64, limit int64) ([]model.Position, error)
+	ListPositionsWithinBoundingBox(trackerID int64, boundingBox geometry.BoundingBox, offset int64, limit int64) ([]model.Position, error)
+	ListShapesContainingPoint(shapeCollectionID int64, point geometry.Point, includeGeoJSON bool) ([]model.Shape, error)
 
 	// Position movement
 	InsertMovement(*model.TrackerMovement) error
 	InsertMovements([]model.TrackerMovement) error
-
-	ListMovementsBySubscriptionID(subscriptionID int64, offset int64, limit int64) ([]model.TrackerMovement, error)
+	// ReplaceMovementsByTrackerID replaces the movements of a tracker in a subscription for positions with a
+	// timestamp from since to until, both inclusive, with the given movements in one transaction
+	ReplaceMovementsByTrackerID(subscriptionID int64, trackerID int64, since int64, until int64, movements []model.TrackerMovement) error
+
+	// ListMovementsBySubscriptionID lists the movements of a subscription where the timestamp of the position
+	// is from since to until, both inclusive and in nanoseconds
+	ListMovementsBySubscriptionID(subscriptionID int64, since int64, until int64, offset int64, limit int64) ([]model.TrackerMovement, error)
+	// ListLatestMovementsBySubscriptionID lists the latest movement of each tracker and shape of a subscription,
+	// which is the movement of the position with the latest timestamp
+	ListLatestMovementsBySubscriptionID(subscriptionID int64) ([]model.TrackerMovement, error)
+	// ListLatestMovementsBySubscriptionIDBefore lists the latest movement of each tracker and shape of a
+	// subscription for positions with a timestamp before the given one, in nanoseconds
+	ListLatestMovementsBySubscriptionIDBefore(subscriptionID int64, before int64) ([]model.TrackerMovement, error)
+	// ListPositionMovementsBySubscriptionID lists the movement history of a subscription along with the
+	// positions which triggered the movements, newest first
+	ListPositionMovementsBySubscriptionID(subscriptionID int64, filter model.MovementFilter, offset int64, limit int64) ([]model.PositionMovement, error)
+
+	// Delivery
+	CreateDelivery(delivery *model.Delivery) (int64, error)
+	GetDelivery(subscriptionID int64, deliveryID int64) (*model.Delivery, error)
+	UpdateDelivery(delivery *model.Delivery) error
+
+	ListDeliveriesBySubscriptionID(subscriptionID int64, status string, offset int64, limit int64) ([]model.Delivery, error)
 
 	// Subscription
 	CreateSubscription(subscription *model.Subscription, userID int64) (int64, error)
@@ -123,18 +164,38 @@ type Store interface {
 
 	// List GeoSubscriptions
 	ListGeoSubscriptions(offset, limit int64) ([]model.GeoSubscription, error)
+	// ListGeoSubscriptionsAfterID lists GeoSubscriptions with an ID above the given ID in ascending order,
+	// so every subscription is listed once when paging even if subscriptions are added or deleted meanwhile
+	ListGeoSubscriptionsAfterID(subscriptionID int64, limit int64) ([]model.GeoSubscription, error)
 	ListGeoSubscriptionsByShapeCollectionID(shapeCollectionID int64, offset int64, limit int64) ([]model.GeoSubscription, error)
+	// ListGeoSubscriptionsByShapeCollectionIDAfterID lists the GeoSubscriptions of a shape collection like
+	// ListGeoSubscriptionsAfterID
+	ListGeoSubscriptionsByShapeCollectionIDAfterID(shapeCollectionID int64, subscriptionID int64, limit int64) ([]model.GeoSubscription, error)
 
 	Close() error
 }
 
 // New creates a new Store backed by given driver.
 func New(dbDriver string, connectionString string, create bool) (Store, error) {
+	return NewWithParams(StorageParams{
+		DBDriver:           dbDriver,
+		DBConnectionString: connectionString,
+		CreateDBSchema:     create,
+	})
+}
+
+// NewWithParams creates a new Store backed by the driver given in params. PostGIS is only used
+// with PostgreSQL, the other drivers fall back to the tria geometry for spatial queries.
+func NewWithParams(params StorageParams) (Store, error) {
 	var store Store
 
-	switch dbDriver {
+	switch params.DBDriver {
 	case "sqlite3":
-		sqliteStore, err := sqlitestore.New(connectionString, create)
+		if params.PostGIS {
+			log.Warn("PostGIS is only supported with PostgreSQL, spatial queries will use the tria geometry")
+		}
+
+		sqliteStore, err := sqlitestore.New(params.DBConnectionString, params.CreateDBSchema)
 
 		if err != nil {
 			return nil, err
@@ -142,7 +203,7 @@ func New(dbDriver string, connectionString string, create bool) (Store, error) {
 
 		store = sqliteStore
 	case "postgres":
-		postgresStore, err := postgresqlstore.New(connectionString, create)
+		postgresStore, err := postgresqlstore.NewWithPostGIS(params.DBConnectionString, params.CreateDBSchema, params.PostGIS)
 
 		if err != nil {
 			return nil, err
@@ -150,8 +211,21 @@ func New(dbDriver string, connectionString string, create bool) (Store, error) {
 
 		store = postgresStore
 	default:
-		log.Fatalf("Unsupported DB driver %s", dbDriver)
+		log.Fatalf("Unsupported DB driver %s", params.DBDriver)
 	}
 
 	return store, nil
 }
+
+// NewMigrator creates a Migrator for the schema of the driver given in params without applying
+// any migrations. The Migrator must be closed when done.
+func NewMigrator(params StorageParams) (*migration.Migrator, error) {
+	switch params.DBDriver {
+	case "sqlite3":
+		return sqlitestore.NewMigrator(params.DBConnectionString)
+	case "postgres":
+		return postgresqlstore.NewMigrator(params.DBConnectionString, params.PostGIS)
+	default:
+		return nil, fmt.Errorf("unsupported DB driver %s", params.DBDriver)
+	}
+}
@@ -2,6 +2,7 @@ package store
 
 import (
 	"fmt"
+	"math"
 	"strconv"
 	"testing"
 	"time"
@@ -563,14 +564,107 @@ func TestPosition(t *testing.T) {
 		assert.Nil(t, err)
 	}
 
-	trackers, err := db.ListPositionsByTrackerID(trackerID, userID, 0, 100)
+	trackers, err := db.ListPositionsByTrackerID(trackerID, userID, 0, math.MaxInt64, 0, 100)
 	assert.Nil(t, err)
 	assert.Equal(t, 100, len(trackers))
 
-	trackers, err = db.ListPositionsByTrackerID(trackerID, negativeUserID, 0, 100)
+	trackers, err = db.ListPositionsByTrackerID(trackerID, negativeUserID, 0, math.MaxInt64, 0, 100)
 	assert.NotNil(t, err)
 	assert.True(t, isStorageError(errors.AccessDeniedError, err), "Should return an access denied error")
 	assert.NotEqual(t, 100, len(trackers))
+
+	// Create positions in a batch
+	batchPositions := []model.Position{
+		{TrackerID: trackerID, Timestamp: 2, Lat: 2, Lon: 2, Payload: []uint8{}},
+		{TrackerID: trackerID, Timestamp: 1, Lat: 1, Lon: 1, Payload: []uint8{}},
+	}
+	positionIDs, err := db.CreatePositions(batchPositions, userID)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(positionIDs))
+
+	for i, positionID := range positionIDs {
+		batchPositions[i].ID = positionID
+		readPosition, err := db.GetPositionByUserID(positionID, userID)
+		assert.Nil(t, err)
+		assert.Equal(t, &batchPositions[i], readPosition)
+	}
+
+	_, err = db.CreatePositions(batchPositions, negativeUserID)
+	assert.NotNil(t, err, "Should not be able to create positions")
+	assert.True(t, isStorageError(errors.AccessDeniedError, err), "Should return an access denied error")
+
+	// Find duplicates by tracker, timestamp and coordinates
+	duplicate, err := db.GetDuplicatePositionByUserID(&model.Position{TrackerID: trackerID, Timestamp: 2, Lat: 2, Lon: 2}, userID)
+	assert.Nil(t, err)
+	assert.Equal(t, &batchPositions[0], duplicate)
+
+	_, err = db.GetDuplicatePositionByUserID(&model.Position{TrackerID: trackerID, Timestamp: 2, Lat: 2, Lon: 2.5}, userID)
+	assert.True(t, isStorageError(errors.NotFoundError, err), "Should return a not found error")
+
+	_, err = db.GetDuplicatePositionByUserID(&model.Position{TrackerID: trackerID, Timestamp: 2, Lat: 2, Lon: 2}, negativeUserID)
+	assert.True(t, isStorageError(errors.NotFoundError, err), "Should not find positions of other teams")
+
+	// Exact duplicates can't be stored, neither alone nor in a batch
+	_, err = db.CreatePosition(&model.Position{TrackerID: trackerID, Timestamp: 2, Lat: 2, Lon: 2}, userID)
+	assert.True(t, isStorageError(errors.AlreadyExistsError, err), "Should return an already exists error")
+
+	_, err = db.CreatePositions([]model.Position{
+		{TrackerID: trackerID, Timestamp: 3},
+		{TrackerID: trackerID, Timestamp: 1, Lat: 1, Lon: 1},
+	}, userID)
+	assert.True(t, isStorageError(errors.AlreadyExistsError, err), "Should return an already exists error")
+
+	// Either all positions are created or none of them
+	_, err = db.CreatePositions([]model.Position{
+		{TrackerID: trackerID, Timestamp: 3},
+		{TrackerID: -1, Timestamp: 3},
+	}, userID)
+	assert.NotNil(t, err, "Should not be able to create positions for a nonexistent tracker")
+
+	positions, err := db.ListPositionsByTrackerID(trackerID, userID, 3, 3, 0, 100)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(positions))
+
+	// List positions within a time range
+	tracker.Name = "Time range tracker"
+	timeRangeTrackerID, err := db.CreateTracker(tracker, userID)
+	assert.Nil(t, err)
+
+	// Two positions per timestamp to have ties on the timestamp when paging
+	for i := 0; i < 20; i++ {
+		_, err := db.CreatePosition(&model.Position{
+			TrackerID: timeRangeTrackerID,
+			Timestamp: int64(i/2 + 1),
+			Lat:       float64(i % 2),
+		}, userID)
+		assert.Nil(t, err)
+	}
+
+	positions, err = db.ListPositionsByTrackerID(timeRangeTrackerID, userID, 3, 6, 0, 100)
+	assert.Nil(t, err)
+	assert.Equal(t, 8, len(positions))
+	assert.Equal(t, int64(6), positions[0].Timestamp)
+	assert.Equal(t, int64(3), positions[7].Timestamp)
+
+	// Page through by cursor
+	positions, err = db.ListPositionsByTrackerID(timeRangeTrackerID, userID, 2, 10, 0, 3)
+	assert.Nil(t, err)
+	pagedPositions := positions
+	for len(positions) > 0 {
+		last := positions[len(positions)-1]
+		positions, err = db.ListPositionsByTrackerIDBeforeCursor(timeRangeTrackerID, userID, 2, model.PositionCursor{Timestamp: last.Timestamp, ID: last.ID}, 3)
+		assert.Nil(t, err)
+		pagedPositions = append(pagedPositions, positions...)
+	}
+
+	allPositions, err := db.ListPositionsByTrackerID(timeRangeTrackerID, userID, 2, 10, 0, 100)
+	assert.Nil(t, err)
+	assert.Equal(t, 18, len(allPositions))
+	assert.Equal(t, allPositions, pagedPositions)
+
+	_, err = db.ListPositionsByTrackerIDBeforeCursor(timeRangeTrackerID, negativeUserID, 0, model.PositionCursor{Timestamp: 10, ID: math.MaxInt64}, 100)
+	assert.NotNil(t, err)
+	assert.True(t, isStorageError(errors.AccessDeniedError, err), "Should return an access denied error")
 }
 
 func TestShapeCollections(t *testing.T) {
@@ -738,6 +832,56 @@ func TestShapes(t *testing.T) {
 	assert.NotNil(t, err, "Should not be able to get shape")
 	assert.True(t, isStorageError(errors.AccessDeniedError, err), "Should return an access denied error")
 
+	// Multi polygons are stored as a single shape
+	square := geometry.NewPolygonFromPoints([]geometry.Point{{X: 0, Y: 0}, {X: 1, Y: 0}, {X: 1, Y: 1}, {X: 0, Y: 1}})
+	square.Triangles = [][]geometry.Point{{{X: 0, Y: 0}, {X: 1, Y: 0}, {X: 1, Y: 1}}, {{X: 0, Y: 0}, {X: 1, Y: 1}, {X: 0, Y: 1}}}
+	island := geometry.NewPolygonFromPoints([]geometry.Point{{X: 5, Y: 5}, {X: 6, Y: 5}, {X: 6, Y: 6}})
+	island.Triangles = [][]geometry.Point{island.AlphaShape}
+
+	multiPolygon := geometry.NewMultiPolygonFromPolygons([]geometry.Polygon{square, island})
+	multiPolygon.Name = "Multi polygonson"
+
+	multiPolygonShape := model.Shape{
+		ShapeCollectionID: shapeCollectionID,
+		Name:              "Multi polygonson",
+		Properties:        geometry.ShapeProperties{},
+		Shape:             &multiPolygon,
+	}
+
+	multiPolygonShape.ID, err = db.CreateShape(&multiPolygonShape, userID)
+	assert.Nil(t, err)
+	multiPolygonShape.Shape.SetID(multiPolygonShape.ID)
+
+	retrievedMultiPolygonShape, err := db.GetShapeByUserID(shapeCollectionID, multiPolygonShape.ID, userID, true)
+	assert.Nil(t, err)
+	assert.Equal(t, &multiPolygonShape, retrievedMultiPolygonShape)
+	assert.True(t, retrievedMultiPolygonShape.Shape.PointInside(&geometry.Point{X: 5.9, Y: 5.1}))
+
+	err = db.DeleteShape(shapeCollectionID, multiPolygonShape.ID, userID)
+	assert.Nil(t, err)
+
+	// Corridors are stored with their line and width
+	corridor := geometry.NewCorridorFromPoints([]geometry.Point{{X: 10.40, Y: 63.40}, {X: 10.42, Y: 63.41}}, 100)
+	corridor.Name = "Corridor corridorson"
+
+	corridorShape := model.Shape{
+		ShapeCollectionID: shapeCollectionID,
+		Name:              "Corridor corridorson",
+		Properties:        geometry.ShapeProperties{},
+		Shape:             &corridor,
+	}
+
+	corridorShape.ID, err = db.CreateShape(&corridorShape, userID)
+	assert.Nil(t, err)
+	corridorShape.Shape.SetID(corridorShape.ID)
+
+	retrievedCorridorShape, err := db.GetShapeByUserID(shapeCollectionID, corridorShape.ID, userID, true)
+	assert.Nil(t, err)
+	assert.Equal(t, &corridorShape, retrievedCorridorShape)
+
+	err = db.DeleteShape(shapeCollectionID, corridorShape.ID, userID)
+	assert.Nil(t, err)
+
 	// Update
 	retrievedShape.Name = "New name"
 
@@ -776,6 +920,132 @@ func TestShapes(t *testing.T) {
 	assert.NotEqual(t, 100, len(shapes))
 }
 
+func TestSpatialQueries(t *testing.T) {
+	db := getTestDB()
+	defer db.Close()
+
+	// Prep data in DB
+	team := &model.Team{
+		Name:        "my team",
+		Description: "some description",
+	}
+	teamID, err := db.CreateTeam(team)
+	assert.Nil(t, err)
+
+	u := generateTestUser()
+	userID, err := db.CreateUser(u)
+	assert.Nil(t, err)
+
+	err = db.SetTeamMember(userID, teamID, true)
+	assert.Nil(t, err)
+
+	collectionID, err := db.CreateCollection(&model.Collection{
+		TeamID: teamID,
+		Name:   "collection name",
+	}, userID)
+	assert.Nil(t, err)
+
+	trackerID, err := db.CreateTracker(&model.Tracker{
+		CollectionID: collectionID,
+		Name:         "Some tracker",
+	}, userID)
+	assert.Nil(t, err)
+
+	shapeCollectionID, err := db.CreateShapeCollection(&model.ShapeCollection{
+		TeamID: teamID,
+		Name:   "ShapeCollection man",
+	}, userID)
+	assert.Nil(t, err)
+
+	// A triangle covering the lower right half of the unit square, so the bounding box alone
+	// isn't enough to tell which positions are inside
+	triangle := geometry.NewPolygonFromPoints([]geometry.Point{{X: 0, Y: 0}, {X: 1, Y: 0}, {X: 1, Y: 1}})
+	triangle.Triangles = [][]geometry.Point{triangle.AlphaShape}
+	triangleShape := model.Shape{
+		ShapeCollectionID: shapeCollectionID,
+		Name:              "Triangle",
+		Properties:        geometry.ShapeProperties{},
+		Shape:             &triangle,
+	}
+	triangleShape.ID, err = db.CreateShape(&triangleShape, userID)
+	assert.Nil(t, err)
+
+	circleShape := model.Shape{
+		ShapeCollectionID: shapeCollectionID,
+		Name:              "Circle",
+		Properties:        geometry.ShapeProperties{},
+		Shape: &geometry.Circle{
+			Name:   "Circle",
+			Origo:  geometry.Point{X: 10, Y: 10},
+			Radius: 1000,
+		},
+	}
+	circleShape.ID, err = db.CreateShape(&circleShape, userID)
+	assert.Nil(t, err)
+
+	// Five positions inside the triangle, three in the other half of the unit square and two in the circle
+	points := []geometry.Point{
+		{X: 0.9, Y: 0.1}, {X: 0.8, Y: 0.2}, {X: 0.7, Y: 0.3}, {X: 0.6, Y: 0.4}, {X: 0.5, Y: 0.1},
+		{X: 0.1, Y: 0.9}, {X: 0.2, Y: 0.8}, {X: 0.3, Y: 0.7},
+		{X: 10, Y: 10}, {X: 10.001, Y: 10.001},
+	}
+	for i, point := range points {
+		_, err := db.CreatePosition(&model.Position{
+			TrackerID: trackerID,
+			Timestamp: int64(i + 1),
+			Lat:       point.Y,
+			Lon:       point.X,
+			Payload:   []uint8{},
+		}, userID)
+		assert.Nil(t, err)
+	}
+
+	// Positions within a shape
+	positions, err := db.ListPositionsWithinShape(trackerID, shapeCollectionID, triangleShape.ID, 0, 100)
+	assert.Nil(t, err)
+	assert.Equal(t, 5, len(positions))
+	assert.Equal(t, int64(5), positions[0].Timestamp, "Should list newest positions first")
+
+	positions, err = db.ListPositionsWithinShape(trackerID, shapeCollectionID, triangleShape.ID, 1, 2)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(positions))
+	assert.Equal(t, int64(4), positions[0].Timestamp)
+	assert.Equal(t, int64(3), positions[1].Timestamp)
+
+	positions, err = db.ListPositionsWithinShape(trackerID, shapeCollectionID, circleShape.ID, 0, 100)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(positions))
+
+	_, err = db.ListPositionsWithinShape(trackerID, shapeCollectionID, -1, 0, 100)
+	assert.NotNil(t, err, "Should not list positions within a nonexistent shape")
+
+	// Positions within a bounding box
+	positions, err = db.ListPositionsWithinBoundingBox(trackerID, geometry.BoundingBox{MinX: 0, MinY: 0, MaxX: 1, MaxY: 1}, 0, 100)
+	assert.Nil(t, err)
+	assert.Equal(t, 8, len(positions))
+
+	positions, err = db.ListPositionsWithinBoundingBox(trackerID, geometry.BoundingBox{MinX: 0, MinY: 0, MaxX: 1, MaxY: 1}, 6, 100)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(positions))
+
+	// Shapes containing a point
+	shapes, err := db.ListShapesContainingPoint(shapeCollectionID, geometry.Point{X: 0.9, Y: 0.1}, true)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(shapes))
+	assert.Equal(t, triangleShape.ID, shapes[0].ID)
+	assert.NotNil(t, shapes[0].Shape)
+
+	shapes, err = db.ListShapesContainingPoint(shapeCollectionID, geometry.Point{X: 10.001, Y: 10}, false)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(shapes))
+	assert.Equal(t, circleShape.ID, shapes[0].ID)
+	assert.Nil(t, shapes[0].Shape)
+
+	shapes, err = db.ListShapesContainingPoint(shapeCollectionID, geometry.Point{X: 0.1, Y: 0.9}, true)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(shapes))
+}
+
 func TestSubscriptions(t *testing.T) {
 	db := getTestDB()
 	defer db.Close()
@@ -858,11 +1128,15 @@ func TestSubscriptions(t *testing.T) {
 		OutputConfig: model.OutputConfig{
 			"configParam": "bar",
 		},
-		Types:             model.MovementList{"inside", "outside"},
+		Types:             model.MovementList{"inside", "outside", "dwell"},
 		Confidences:       model.ConfidenceList{"high", "medium"},
 		ShapeCollectionID: shapeCollectionID,
 		TrackableType:     "tracker",
 		TrackableID:       trackerID,
+		DwellTime:         15 * time.Minute,
+		ConfirmPositions:  3,
+		ConfirmTime:       time.Minute,
+		BoundaryBuffer:    25.5,
 	}
 	subscriptionTrackerID, err := db.CreateSubscription(&subscriptionTracker, userID)
 	assert.Nil(t, err)
@@ -1008,6 +1282,35 @@ func TestGeoSubscriptions(t *testing.T) {
 	assert.Equal(t, 1, len(geoSubscriptions))
 	assert.Equal(t, subscription, geoSubscriptions[0].Subscription)
 	assert.Equal(t, shapeCollection, geoSubscriptions[0].ShapeCollection)
+
+	// List after ID
+	secondSubscription := subscription
+	secondSubscriptionID, err := db.CreateSubscription(&secondSubscription, userID)
+	assert.Nil(t, err)
+
+	geoSubscriptions, err = db.ListGeoSubscriptionsAfterID(0, 1)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(geoSubscriptions))
+	assert.Equal(t, subscriptionID, geoSubscriptions[0].Subscription.ID)
+
+	geoSubscriptions, err = db.ListGeoSubscriptionsAfterID(subscriptionID, 10)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(geoSubscriptions))
+	assert.Equal(t, secondSubscriptionID, geoSubscriptions[0].Subscription.ID)
+
+	geoSubscriptions, err = db.ListGeoSubscriptionsAfterID(secondSubscriptionID, 10)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(geoSubscriptions))
+
+	// List by shape collection after ID
+	geoSubscriptions, err = db.ListGeoSubscriptionsByShapeCollectionIDAfterID(shapeCollectionID, subscriptionID, 10)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(geoSubscriptions))
+	assert.Equal(t, secondSubscriptionID, geoSubscriptions[0].Subscription.ID)
+
+	geoSubscriptions, err = db.ListGeoSubscriptionsByShapeCollectionIDAfterID(shapeCollectionID+1, 0, 10)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(geoSubscriptions))
 }
 
 func TestMovement(t *testing.T) {
@@ -1115,6 +1418,8 @@ func TestMovement(t *testing.T) {
 			"inside",
 			"entered",
 		},
+		Entered: time.Now().UnixNano(),
+		Dwelled: true,
 	}
 
 	err = db.InsertMovement(&trackerMovement)
@@ -1150,7 +1455,268 @@ func TestMovement(t *testing.T) {
 	assert.Nil(t, err)
 
 	// List by subscription
-	lastMovements, err := db.ListMovementsBySubscriptionID(subscriptionID, 0, 1000)
+	lastMovements, err := db.ListMovementsBySubscriptionID(subscriptionID, 0, math.MaxInt64, 0, 1000)
 	assert.Nil(t, err)
 	assert.Equal(t, 101, len(lastMovements))
+
+	// The dwell state is kept
+	assert.Equal(t, trackerMovement.Entered, lastMovements[0].Entered)
+	assert.True(t, lastMovements[0].Dwelled)
+	assert.False(t, lastMovements[1].Dwelled)
+
+	// List by subscription within a time range
+	lastMovements, err = db.ListMovementsBySubscriptionID(subscriptionID, time.Now().Add(time.Hour).UnixNano(), math.MaxInt64, 0, 1000)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(lastMovements))
+
+	// Movement history with the triggering positions, newest first
+	exitedPositionID, err := db.CreatePosition(&model.Position{
+		TrackerID: trackerID,
+		Timestamp: time.Now().UnixNano(),
+		Lat:       2.0,
+		Lon:       3.0,
+	}, userID)
+	assert.Nil(t, err)
+
+	err = db.InsertMovement(&model.TrackerMovement{
+		TrackerID:      trackerID,
+		SubscriptionID: subscriptionID,
+		ShapeID:        shapeID,
+		PositionID:     exitedPositionID,
+		Movements:      model.MovementList{"exited", "outside"},
+	})
+	assert.Nil(t, err)
+
+	history, err := db.ListPositionMovementsBySubscriptionID(subscriptionID, model.MovementFilter{Until: math.MaxInt64}, 0, 1000)
+	assert.Nil(t, err)
+	assert.Equal(t, 102, len(history))
+	assert.Equal(t, exitedPositionID, history[0].Position.ID)
+	assert.Equal(t, 2.0, history[0].Position.Lat)
+	assert.Equal(t, 3.0, history[0].Position.Lon)
+	assert.Equal(t, model.MovementList{"exited", "outside"}, history[0].Movement.Movements)
+
+	history, err = db.ListPositionMovementsBySubscriptionID(subscriptionID, model.MovementFilter{Movement: "exited", Until: math.MaxInt64}, 0, 1000)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(history))
+
+	history, err = db.ListPositionMovementsBySubscriptionID(subscriptionID, model.MovementFilter{Movement: "entered", Until: math.MaxInt64}, 0, 10)
+	assert.Nil(t, err)
+	assert.Equal(t, 10, len(history))
+
+	history, err = db.ListPositionMovementsBySubscriptionID(subscriptionID, model.MovementFilter{TrackerID: trackerID, ShapeID: shapeID, Until: math.MaxInt64}, 0, 1000)
+	assert.Nil(t, err)
+	assert.Equal(t, 102, len(history))
+
+	history, err = db.ListPositionMovementsBySubscriptionID(subscriptionID, model.MovementFilter{ShapeID: shapeID + 1, Until: math.MaxInt64}, 0, 1000)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(history))
+
+	history, err = db.ListPositionMovementsBySubscriptionID(subscriptionID, model.MovementFilter{Since: time.Now().Add(time.Hour).UnixNano(), Until: math.MaxInt64}, 0, 1000)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(history))
+
+	// Replace the movements of the latest position only
+	exitedPosition, err := db.GetPosition(exitedPositionID)
+	assert.Nil(t, err)
+
+	err = db.ReplaceMovementsByTrackerID(subscriptionID, trackerID, exitedPosition.Timestamp, math.MaxInt64, []model.TrackerMovement{{
+		TrackerID:      trackerID,
+		SubscriptionID: subscriptionID,
+		ShapeID:        shapeID,
+		PositionID:     exitedPositionID,
+		Movements:      model.MovementList{"inside"},
+	}})
+	assert.Nil(t, err)
+
+	history, err = db.ListPositionMovementsBySubscriptionID(subscriptionID, model.MovementFilter{Until: math.MaxInt64}, 0, 1000)
+	assert.Nil(t, err)
+	assert.Equal(t, 102, len(history))
+	assert.Equal(t, model.MovementList{"inside"}, history[0].Movement.Movements)
+
+	// The latest movement of each tracker and shape is the one of the latest position, not the latest stored
+	err = db.InsertMovements([]model.TrackerMovement{{
+		TrackerID:      trackerID,
+		SubscriptionID: subscriptionID,
+		ShapeID:        shapeID,
+		PositionID:     positionID,
+		Movements:      model.MovementList{"outside"},
+	}, {
+		TrackerID:      trackerID,
+		SubscriptionID: subscriptionID,
+		ShapeID:        shapeID + 1,
+		PositionID:     positionID,
+		Movements:      model.MovementList{"entered", "inside"},
+	}})
+	assert.Nil(t, err)
+
+	lastMovements, err = db.ListLatestMovementsBySubscriptionID(subscriptionID)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(lastMovements))
+	assert.Equal(t, shapeID, lastMovements[0].ShapeID)
+	assert.Equal(t, exitedPositionID, lastMovements[0].PositionID)
+	assert.Equal(t, model.MovementList{"inside"}, lastMovements[0].Movements)
+	assert.Equal(t, exitedPosition.Timestamp, lastMovements[0].Timestamp)
+	assert.Equal(t, shapeID+1, lastMovements[1].ShapeID)
+	assert.Equal(t, positionID, lastMovements[1].PositionID)
+
+	// The latest movements before a timestamp leave out the movements of later positions
+	lastMovements, err = db.ListLatestMovementsBySubscriptionIDBefore(subscriptionID, exitedPosition.Timestamp)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(lastMovements))
+	assert.NotEqual(t, exitedPositionID, lastMovements[0].PositionID)
+	assert.True(t, lastMovements[0].Timestamp < exitedPosition.Timestamp)
+	assert.Equal(t, model.MovementList{"inside", "entered"}, lastMovements[0].Movements)
+
+	// Replacing the movements of another tracker keeps the movements of the tracker
+	err = db.ReplaceMovementsByTrackerID(subscriptionID, trackerID+1, 0, math.MaxInt64, []model.TrackerMovement{})
+	assert.Nil(t, err)
+
+	history, err = db.ListPositionMovementsBySubscriptionID(subscriptionID, model.MovementFilter{Until: math.MaxInt64}, 0, 1000)
+	assert.Nil(t, err)
+	assert.Equal(t, 104, len(history))
+
+	// Replace every movement of the tracker
+	err = db.ReplaceMovementsByTrackerID(subscriptionID, trackerID, 0, math.MaxInt64, []model.TrackerMovement{})
+	assert.Nil(t, err)
+
+	history, err = db.ListPositionMovementsBySubscriptionID(subscriptionID, model.MovementFilter{Until: math.MaxInt64}, 0, 1000)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(history))
+}
+
+func TestDelivery(t *testing.T) {
+	db := getTestDB()
+	defer db.Close()
+
+	// Prep data in DB
+	team := &model.Team{
+		Name:        "my team",
+		Description: "some description",
+	}
+	teamID, err := db.CreateTeam(team)
+	assert.Nil(t, err)
+
+	// Create test user
+	u := generateTestUser()
+	userID, err := db.CreateUser(u)
+	assert.Nil(t, err)
+
+	err = db.SetTeamMember(userID, teamID, true)
+	assert.Nil(t, err)
+
+	collection := &model.Collection{
+		TeamID:      teamID,
+		Name:        "collection name",
+		Description: "collection description",
+	}
+	collectionID, err := db.CreateCollection(collection, userID)
+	assert.Nil(t, err)
+
+	shapeCollection := model.ShapeCollection{
+		TeamID:      teamID,
+		Name:        "ShapeCollection man",
+		Description: "Some description",
+	}
+	shapeCollectionID, err := db.CreateShapeCollection(&shapeCollection, userID)
+	assert.Nil(t, err)
+
+	subscription := model.Subscription{
+		TeamID:            teamID,
+		Name:              "Subscription",
+		Description:       "Some description",
+		Active:            true,
+		Output:            "webhook",
+		OutputConfig:      model.OutputConfig{},
+		Types:             model.MovementList{"entered"},
+		Confidences:       model.ConfidenceList{"high"},
+		ShapeCollectionID: shapeCollectionID,
+		TrackableType:     "collection",
+		TrackableID:       collectionID,
+	}
+	subscriptionID, err := db.CreateSubscription(&subscription, userID)
+	assert.Nil(t, err)
+
+	// Actual test
+
+	// Create
+	now := time.Now()
+	delivery := model.Delivery{
+		SubscriptionID: subscriptionID,
+		Output:         "sms",
+		Status:         "pending",
+		Recipient:      "+4712345678",
+		Payload:        []byte(`{"type":"trigger"}`),
+		Created:        now,
+		Updated:        now,
+	}
+
+	deliveryID, err := db.CreateDelivery(&delivery)
+	assert.Nil(t, err)
+	delivery.ID = deliveryID
+
+	// Get
+	storedDelivery, err := db.GetDelivery(subscriptionID, deliveryID)
+	assert.Nil(t, err)
+	assert.Equal(t, delivery.ID, storedDelivery.ID)
+	assert.Equal(t, delivery.Output, storedDelivery.Output)
+	assert.Equal(t, delivery.Status, storedDelivery.Status)
+	assert.Equal(t, delivery.Recipient, storedDelivery.Recipient)
+	assert.Equal(t, delivery.Payload, storedDelivery.Payload)
+	assert.True(t, delivery.Created.Equal(storedDelivery.Created))
+
+	// Deliveries belong to their subscription
+	_, err = db.GetDelivery(subscriptionID+1, deliveryID)
+	assert.True(t, isStorageError(errors.NotFoundError, err))
+
+	// Update
+	delivery.Status = "deadletter"
+	delivery.Attempts = 1
+	delivery.LastError = "Connection refused"
+	delivery.Updated = time.Now()
+
+	err = db.UpdateDelivery(&delivery)
+	assert.Nil(t, err)
+
+	storedDelivery, err = db.GetDelivery(subscriptionID, deliveryID)
+	assert.Nil(t, err)
+	assert.Equal(t, "deadletter", storedDelivery.Status)
+	assert.Equal(t, int64(1), storedDelivery.Attempts)
+	assert.Equal(t, "Connection refused", storedDelivery.LastError)
+
+	err = db.UpdateDelivery(&model.Delivery{ID: deliveryID + 1000})
+	assert.True(t, isStorageError(errors.NotFoundError, err))
+
+	// List
+	for i := 0; i < 10; i++ {
+		_, err := db.CreateDelivery(&model.Delivery{
+			SubscriptionID: subscriptionID,
+			Output:         "webhook",
+			Status:         "delivered",
+			Payload:        []byte(`{}`),
+			Created:        now,
+			Updated:        now,
+		})
+		assert.Nil(t, err)
+	}
+
+	deliveries, err := db.ListDeliveriesBySubscriptionID(subscriptionID, "", 0, 100)
+	assert.Nil(t, err)
+	assert.Len(t, deliveries, 11)
+
+	deadLetters, err := db.ListDeliveriesBySubscriptionID(subscriptionID, "deadletter", 0, 100)
+	assert.Nil(t, err)
+	assert.Len(t, deadLetters, 1)
+	assert.Equal(t, deliveryID, deadLetters[0].ID)
+
+	deliveries, err = db.ListDeliveriesBySubscriptionID(subscriptionID, "delivered", 5, 100)
+	assert.Nil(t, err)
+	assert.Len(t, deliveries, 5)
+
+	// Deliveries are removed along with the subscription
+	err = db.DeleteSubscription(subscriptionID, userID)
+	assert.Nil(t, err)
+
+	deliveries, err = db.ListDeliveriesBySubscriptionID(subscriptionID, "", 0, 100)
+	assert.Nil(t, err)
+	assert.Len(t, deliveries, 0)
 }
@@ -0,0 +1,16 @@
+package sub
+
+// DeliveryStatus is the status of a trigger event delivered through an output
+type DeliveryStatus string
+
+const (
+	// Pending is a delivery which is stored but not yet delivered by the output
+	Pending DeliveryStatus = "pending"
+	// Delivered is a delivery which the output has delivered
+	Delivered DeliveryStatus = "delivered"
+	// DeadLetter is a delivery the output failed to deliver or discarded
+	DeadLetter DeliveryStatus = "deadletter"
+)
+
+// ValidDeliveryStatuses is a list of valid DeliveryStatuses
+var ValidDeliveryStatuses = []DeliveryStatus{Pending, Delivered, DeadLetter}
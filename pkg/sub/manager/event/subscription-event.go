@@ -29,6 +29,8 @@ type TriggerDetails struct {
 	Movements         []string `json:"movements"`
 	ShapecollectionID int64    `json:"shapecollectionId"`
 	ShapeID           int64    `json:"shapeId"`
+	// Direction is the direction a tripwire was crossed in, ie "left-to-right"
+	Direction string `json:"direction,omitempty"`
 }
 
 // NewSubscriptionEvent returns a new wrapped PositionEvent
@@ -1,26 +1,50 @@
 package manager
 
 import (
+	"github.com/eesrc/geo/pkg/model"
+	"github.com/eesrc/geo/pkg/store"
 	"github.com/eesrc/geo/pkg/sub/manager/event"
 	"github.com/eesrc/geo/pkg/sub/manager/topic"
 	"github.com/eesrc/geo/pkg/sub/output"
+	"github.com/eesrc/geo/pkg/tria/index"
 )
 
 // Manager is responsible for keeping track of events and event subscriptions along with handling
-// GeoSubscriptions which contains geo indexes for lookup and calculations
+// GeoSubscriptions which contains geo indexes for lookup and calculations. The GeoSubscriptions given
+// to the manager hand over their reference to the shared shape index, which the manager releases once
+// the subscription stops or if it's never launched.
 type Manager interface {
-	// Load loads GeoSubscriptions from backend store and launches the ones that aren't
-	// up and running yet. The Load call might be performed multiple times to
-	// update the list.
+	// Load loads every GeoSubscription from the backend store and launches the ones that
+	// aren't up and running yet, typically when the server starts. The subscriptions are
+	// loaded a page at a time along with the latest movement of each tracker and shape.
+	// Deliveries left pending from before the restart are redelivered, or moved to the dead
+	// letters if the subscription isn't running.
+	Load(store.Store) error
+
+	// Refresh launches the GeoSubscriptions that aren't up and running yet. The Refresh
+	// call might be performed multiple times to update the list.
 	Refresh([]output.GeoSubscription)
 
 	// Update refreshes the GeoSubscription. If it isn't launched yet it will be
 	// launched. If it is already running the new configuration will be applied.
 	Update(output.GeoSubscription) error
 
-	// Stop stops a single subscription, typically if they have been deleted.
+	// Reload replaces the running GeoSubscriptions with the given ones in one go, typically when the
+	// shapes they share have changed. The movement state of shapes which still exist is carried over
+	// from the running subscriptions. If any of the subscriptions can't be launched none of them are
+	// replaced.
+	Reload([]output.GeoSubscription) error
+
+	// Stop stops a single subscription, typically if they have been deleted. Messages held for a
+	// suspended subscription are discarded.
 	Stop(subscriptionID int64) error
 
+	// Suspend stops the output of a running subscription, typically while its movements are rebuilt.
+	// Messages published to the subscription are held until it's resumed by Update, which launches
+	// the new output with the held messages first. If the subscription isn't running it will return
+	// an error.
+	Suspend(subscriptionID int64) error
+
 	// Shutdown shuts down all of the running subscriptions.
 	Shutdown()
 
@@ -28,10 +52,52 @@ type Manager interface {
 	// will return an error.
 	Get(SubscriptionID int64) (output.GeoSubscription, error)
 
+	// Redeliver re-sends a stored delivery through the output of the subscription. If the
+	// subscription isn't running or the output can't re-send deliveries it will return an error.
+	Redeliver(subscriptionID int64, delivery *model.Delivery) error
+
 	// Publish publishes an event to the event bus on the given topic. If there's no
 	// subscriptions subscribing to the topic it will be discarded.
 	Publish(topic topic.Topic, event event.PublishableEvent)
 
 	// Subscribe subscribes to a topic
 	Subscribe(topic.Topic) (Subscription, error)
+
+	// ShapeIndexes returns the cache of shape indexes shared by the subscriptions
+	ShapeIndexes() *index.Cache
+}
+
+// NewSharedGeoSubscription creates a new GeoSubscription from a model using the shared index of its shape
+// collection. The shapes are only listed from the store and indexed if the index isn't cached.
+func NewSharedGeoSubscription(geoSubscription model.GeoSubscription, manager Manager, store store.Store) (output.GeoSubscription, error) {
+	shapeIndex, err := AcquireShapeIndex(geoSubscription.Subscription.ShapeCollectionID, manager, store)
+	if err != nil {
+		return output.GeoSubscription{}, err
+	}
+
+	return output.NewGeoSubscriptionWithMovements(geoSubscription.Subscription, shapeIndex, store, geoSubscription.TrackerMovements), nil
+}
+
+// shapePageSize is the number of shapes listed from the store at a time when indexing a shape collection
+const shapePageSize = 1000
+
+// AcquireShapeIndex returns the shared index of the shape collection, indexing its shapes if the index
+// isn't cached. The index must either be handed over to the manager with a GeoSubscription or be released.
+func AcquireShapeIndex(shapeCollectionID int64, manager Manager, store store.Store) (index.TriaIndex, error) {
+	return manager.ShapeIndexes().Acquire(shapeCollectionID, func() (index.TriaIndex, error) {
+		var shapes []model.Shape
+
+		for offset := int64(0); ; offset += shapePageSize {
+			page, err := store.ListShapesByShapeCollectionID(shapeCollectionID, true, offset, shapePageSize)
+			if err != nil {
+				return nil, err
+			}
+
+			shapes = append(shapes, page...)
+
+			if len(page) < shapePageSize {
+				return index.NewRTreeIndexFromModel(shapes), nil
+			}
+		}
+	})
 }
@@ -8,9 +8,13 @@ import (
 
 	log "github.com/sirupsen/logrus"
 
+	"github.com/eesrc/geo/pkg/model"
+	"github.com/eesrc/geo/pkg/store"
+	"github.com/eesrc/geo/pkg/sub"
 	"github.com/eesrc/geo/pkg/sub/manager/event"
 	"github.com/eesrc/geo/pkg/sub/manager/topic"
 	"github.com/eesrc/geo/pkg/sub/output"
+	"github.com/eesrc/geo/pkg/tria/index"
 	stand "github.com/nats-io/nats-streaming-server/server"
 	"github.com/nats-io/nats-streaming-server/stores"
 	nats "github.com/nats-io/nats.go"
@@ -19,11 +23,15 @@ import (
 // natsManager is a manager running on the local instance. It will only keep
 // track of outputs launched locally.
 type natsManager struct {
-	running    map[int64]subscriberEntry
+	running map[int64]subscriberEntry
+	// suspended holds the topic subscriptions of suspended subscriptions, which hold on to the
+	// messages until the subscription is resumed
+	suspended  map[int64]Subscription
 	publisher  *nats.EncodedConn
 	natsServer *stand.StanServer
 	natsConn   *nats.Conn
 	mutex      *sync.Mutex
+	indexes    *index.Cache
 }
 
 type subscriberEntry struct {
@@ -75,10 +83,120 @@ func NewNatsManager(config NATSManagerConfig) Manager {
 
 	return &natsManager{
 		running:    make(map[int64]subscriberEntry),
+		suspended:  make(map[int64]Subscription),
 		natsServer: server,
 		natsConn:   natsConnection,
 		publisher:  encodedConnection,
 		mutex:      &sync.Mutex{},
+		indexes:    index.NewCache(),
+	}
+}
+
+// loadPageSize is the number of GeoSubscriptions loaded from the store at a time
+const loadPageSize = 100
+
+// deliveryPageSize is the number of pending deliveries listed from the store at a time
+const deliveryPageSize = 100
+
+func (manager *natsManager) Load(store store.Store) error {
+	var lastID int64
+	loaded := 0
+
+	// Paging by ID doesn't skip subscriptions when subscriptions are created or deleted while loading
+	for {
+		geoSubscriptionModels, err := store.ListGeoSubscriptionsAfterID(lastID, loadPageSize)
+		if err != nil {
+			return err
+		}
+
+		geoSubscriptions := make([]output.GeoSubscription, 0, len(geoSubscriptionModels))
+		for _, geoSubscriptionModel := range geoSubscriptionModels {
+			// Inactive subscriptions aren't launched, so their shapes aren't indexed
+			if !geoSubscriptionModel.Subscription.Active {
+				continue
+			}
+
+			// A subscription whose shapes can't be loaded doesn't hold up the others
+			geoSubscription, err := NewSharedGeoSubscription(geoSubscriptionModel, manager, store)
+			if err != nil {
+				log.WithError(err).Errorf("Unable to load subscription with ID %d. Ignoring", geoSubscriptionModel.Subscription.ID)
+				continue
+			}
+
+			geoSubscriptions = append(geoSubscriptions, geoSubscription)
+		}
+
+		// Pending deliveries are listed before the outputs start, so deliveries of new trigger
+		// events aren't mistaken for deliveries left over from before the restart. The deliveries of
+		// subscriptions which aren't launched are listed as well, so they end up as dead letters.
+		deliveries, err := pendingDeliveries(store, geoSubscriptionModels)
+		if err != nil {
+			for _, geoSubscription := range geoSubscriptions {
+				manager.releaseIndex(geoSubscription)
+			}
+			return err
+		}
+
+		manager.Refresh(geoSubscriptions)
+
+		// Redeliveries might have to wait for the remote ends, so they don't hold up the startup
+		go manager.recoverDeliveries(store, deliveries)
+
+		loaded += len(geoSubscriptionModels)
+		if len(geoSubscriptionModels) < loadPageSize {
+			log.Infof("Loaded %d geo subscriptions", loaded)
+			return nil
+		}
+
+		lastID = geoSubscriptionModels[len(geoSubscriptionModels)-1].Subscription.ID
+	}
+}
+
+// pendingDeliveries lists the deliveries of the subscriptions which are still pending, ie if the service
+// was stopped in the middle of a delivery. The deliveries are listed oldest first.
+func pendingDeliveries(store store.Store, geoSubscriptions []model.GeoSubscription) ([]model.Delivery, error) {
+	var deliveries []model.Delivery
+
+	for _, geoSubscription := range geoSubscriptions {
+		var subscriptionDeliveries []model.Delivery
+
+		for offset := int64(0); ; offset += deliveryPageSize {
+			page, err := store.ListDeliveriesBySubscriptionID(geoSubscription.Subscription.ID, string(sub.Pending), offset, deliveryPageSize)
+			if err != nil {
+				return nil, err
+			}
+
+			subscriptionDeliveries = append(subscriptionDeliveries, page...)
+
+			if len(page) < deliveryPageSize {
+				break
+			}
+		}
+
+		// The deliveries are listed newest first
+		for i := len(subscriptionDeliveries) - 1; i >= 0; i-- {
+			deliveries = append(deliveries, subscriptionDeliveries[i])
+		}
+	}
+
+	return deliveries, nil
+}
+
+// recoverDeliveries re-queues pending deliveries through the outputs of their subscriptions. Deliveries
+// which can't be redelivered, ie as the subscription isn't active, are moved to the dead letters.
+func (manager *natsManager) recoverDeliveries(store store.Store, deliveries []model.Delivery) {
+	for i := range deliveries {
+		delivery := &deliveries[i]
+
+		err := manager.Redeliver(delivery.SubscriptionID, delivery)
+		if err != nil {
+			log.WithError(err).Warnf("Unable to redeliver pending delivery %d of subscription %d. Moving to dead letters", delivery.ID, delivery.SubscriptionID)
+			output.DeadLetter(store, delivery, fmt.Errorf("The delivery was pending when the service stopped and couldn't be redelivered: %v", err))
+		}
+	}
+
+	if len(deliveries) > 0 {
+		log.Infof("Recovered %d pending deliveries", len(deliveries))
 	}
 }
 
@@ -88,15 +206,19 @@ func (manager *natsManager) Refresh(geoSubscriptions []output.GeoSubscription) {
 
 	for _, geoSubscription := range geoSubscriptions {
 		_, exists := manager.running[geoSubscription.Subscription.ID]
-		if exists {
+		_, suspended := manager.suspended[geoSubscription.Subscription.ID]
+		if exists || suspended {
+			manager.releaseIndex(geoSubscription)
 			continue
 		}
 		if !geoSubscription.Subscription.Active {
+			manager.releaseIndex(geoSubscription)
 			continue
 		}
 		newOutput, err := output.NewOutput(geoSubscription, manager.Publish)
 		if err != nil {
 			log.WithError(err).Errorf("Unable to launch subscription with ID %d. Ignoring", geoSubscription.Subscription.ID)
+			manager.releaseIndex(geoSubscription)
 			continue
 		}
 
@@ -107,6 +229,7 @@ func (manager *natsManager) Refresh(geoSubscriptions []output.GeoSubscription) {
 				"Unable to subscribe to topic '%s'",
 				topic.GetTopicFromSubscription(geoSubscription.Subscription, topic.DataEvents).TopicString(),
 			)
+			manager.releaseIndex(geoSubscription)
 			continue
 		}
 
@@ -124,22 +247,33 @@ func (manager *natsManager) Update(geoSubscription output.GeoSubscription) error
 
 	v, exists := manager.running[subscription.ID]
 	if exists {
-		unsubscribeSubscription(v.sub)
-		v.output.Stop(stopTimeout)
+		delete(manager.running, subscription.ID)
+		manager.stopEntry(v)
 	}
 
 	if !subscription.Active {
+		manager.dropSuspended(subscription.ID)
+		manager.releaseIndex(geoSubscription)
 		return nil
 	}
 
 	newOutput, err := output.NewOutput(geoSubscription, manager.Publish)
 	if err != nil {
+		manager.dropSuspended(subscription.ID)
+		manager.releaseIndex(geoSubscription)
 		return err
 	}
 
-	sub, err := manager.Subscribe(topic.GetTopicFromSubscription(subscription, topic.DataEvents))
-	if err != nil {
-		return err
+	// A suspended subscription is resumed with the messages held meanwhile
+	sub, suspended := manager.suspended[subscription.ID]
+	if suspended {
+		delete(manager.suspended, subscription.ID)
+	} else {
+		sub, err = manager.Subscribe(topic.GetTopicFromSubscription(subscription, topic.DataEvents))
+		if err != nil {
+			manager.releaseIndex(geoSubscription)
+			return err
+		}
 	}
 
 	newOutput.Start(output.Config(subscription.OutputConfig), sub.GetChan())
@@ -152,17 +286,101 @@ func (manager *natsManager) Update(geoSubscription output.GeoSubscription) error
 	return nil
 }
 
+func (manager *natsManager) Reload(geoSubscriptions []output.GeoSubscription) error {
+	manager.mutex.Lock()
+	defer manager.mutex.Unlock()
+
+	// Launch everything that can fail before any of the running subscriptions are stopped. The new
+	// subscriptions hold on to the messages until their outputs are started.
+	outputs := make([]output.Output, len(geoSubscriptions))
+	subs := make([]Subscription, len(geoSubscriptions))
+	for i, geoSubscription := range geoSubscriptions {
+		// Suspended subscriptions pick up the current shapes once they're resumed
+		if _, suspended := manager.suspended[geoSubscription.Subscription.ID]; suspended || !geoSubscription.Subscription.Active {
+			continue
+		}
+
+		newOutput, err := output.NewOutput(geoSubscription, manager.Publish)
+		if err == nil {
+			subs[i], err = manager.Subscribe(topic.GetTopicFromSubscription(geoSubscription.Subscription, topic.DataEvents))
+		}
+
+		if err != nil {
+			for _, sub := range subs[:i] {
+				if sub != nil {
+					unsubscribeSubscription(sub)
+				}
+			}
+			for _, geoSubscription := range geoSubscriptions {
+				manager.releaseIndex(geoSubscription)
+			}
+			return err
+		}
+
+		outputs[i] = newOutput
+	}
+
+	for i := range geoSubscriptions {
+		subscriptionID := geoSubscriptions[i].Subscription.ID
+
+		// The output holds a copy of the GeoSubscription, which shares the movement state with it
+		if v, exists := manager.running[subscriptionID]; exists {
+			delete(manager.running, subscriptionID)
+			manager.stopEntry(v)
+
+			geoSubscriptions[i].KeepMovements(&v.geoSubscription)
+		}
+
+		if outputs[i] == nil {
+			manager.releaseIndex(geoSubscriptions[i])
+			continue
+		}
+
+		outputs[i].Start(output.Config(geoSubscriptions[i].Subscription.OutputConfig), subs[i].GetChan())
+		manager.running[subscriptionID] = subscriberEntry{
+			sub:             subs[i],
+			output:          outputs[i],
+			geoSubscription: geoSubscriptions[i],
+		}
+	}
+
+	return nil
+}
+
 func (manager *natsManager) Stop(subscriptionID int64) error {
 	manager.mutex.Lock()
 	defer manager.mutex.Unlock()
 
+	if _, suspended := manager.suspended[subscriptionID]; suspended {
+		manager.dropSuspended(subscriptionID)
+		return nil
+	}
+
 	v, exists := manager.running[subscriptionID]
 	if !exists {
 		return errors.New("Unknown subscription")
 	}
 	delete(manager.running, subscriptionID)
-	unsubscribeSubscription(v.sub)
+	manager.stopEntry(v)
+	return nil
+}
+
+func (manager *natsManager) Suspend(subscriptionID int64) error {
+	manager.mutex.Lock()
+	defer manager.mutex.Unlock()
+
+	v, exists := manager.running[subscriptionID]
+	if !exists {
+		return errors.New("Unknown subscription")
+	}
+	delete(manager.running, subscriptionID)
+
+	// The output is stopped while the topic subscription is kept, so messages published from now on
+	// are held by the topic subscription instead of being read by the output
 	v.output.Stop(stopTimeout)
+	manager.releaseIndex(v.geoSubscription)
+	manager.suspended[subscriptionID] = v.sub
+
 	return nil
 }
 
@@ -173,13 +391,18 @@ func (manager *natsManager) Shutdown() {
 	defer manager.mutex.Unlock()
 
 	for i, subscription := range manager.running {
-		unsubscribeSubscription(subscription.sub)
-		subscription.output.Stop(stopTimeout)
+		manager.stopEntry(subscription)
 		delete(manager.running, i)
 	}
 
+	for subscriptionID := range manager.suspended {
+		manager.dropSuspended(subscriptionID)
+	}
+
 	err := manager.publisher.Drain()
-	log.Error("Failed to drain publisher", err)
+	if err != nil {
+		log.WithError(err).Error("Failed to drain publisher")
+	}
 
 	err = manager.publisher.Conn.Drain()
 	if err != nil {
@@ -200,6 +423,23 @@ func (manager *natsManager) Get(subscriptionID int64) (output.GeoSubscription, e
 	return ret.geoSubscription, nil
 }
 
+func (manager *natsManager) Redeliver(subscriptionID int64, delivery *model.Delivery) error {
+	manager.mutex.Lock()
+	entry, exists := manager.running[subscriptionID]
+	manager.mutex.Unlock()
+
+	if !exists {
+		return errors.New("unknown output")
+	}
+
+	redeliverer, ok := entry.output.(output.Redeliverer)
+	if !ok {
+		return errors.New("output doesn't support redelivery")
+	}
+
+	return redeliverer.Redeliver(delivery)
+}
+
 func (manager *natsManager) Publish(topic topic.Topic, event event.PublishableEvent) {
 	err := manager.publisher.Publish(topic.TopicString(), event)
 	if err != nil {
@@ -211,6 +451,30 @@ func (manager *natsManager) Subscribe(topic topic.Topic) (Subscription, error) {
 	return NewNatsSubscription(topic, manager.publisher)
 }
 
+func (manager *natsManager) ShapeIndexes() *index.Cache {
+	return manager.indexes
+}
+
+// stopEntry stops a running subscription and releases its shape index
+func (manager *natsManager) stopEntry(entry subscriberEntry) {
+	unsubscribeSubscription(entry.sub)
+	entry.output.Stop(stopTimeout)
+	manager.releaseIndex(entry.geoSubscription)
+}
+
+// dropSuspended discards the messages held for the subscription if it's suspended
+func (manager *natsManager) dropSuspended(subscriptionID int64) {
+	if sub, suspended := manager.suspended[subscriptionID]; suspended {
+		unsubscribeSubscription(sub)
+		delete(manager.suspended, subscriptionID)
+	}
+}
+
+// releaseIndex releases the reference the GeoSubscription holds to its shape index
+func (manager *natsManager) releaseIndex(geoSubscription output.GeoSubscription) {
+	manager.indexes.Release(geoSubscription.Subscription.ShapeCollectionID, geoSubscription.Index)
+}
+
 // createNATSStreamingServer will attempt to start up a NATS streaming server
 func createNATSStreamingServer(config NATSManagerConfig) (*stand.StanServer, error) {
 	// NATS streaming server options
@@ -0,0 +1,378 @@
+package manager
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/eesrc/geo/pkg/model"
+	"github.com/eesrc/geo/pkg/store"
+	"github.com/eesrc/geo/pkg/store/sqlitestore"
+	"github.com/eesrc/geo/pkg/sub"
+	"github.com/eesrc/geo/pkg/sub/manager/event"
+	"github.com/eesrc/geo/pkg/sub/manager/topic"
+	"github.com/eesrc/geo/pkg/sub/output"
+	"github.com/eesrc/geo/pkg/tria/geometry"
+	"github.com/eesrc/geo/pkg/tria/index"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// testTeam holds the entities a subscription of the test store depends on
+type testTeam struct {
+	userID            int64
+	teamID            int64
+	collectionID      int64
+	shapeCollectionID int64
+}
+
+func newTestManager(t *testing.T) Manager {
+	// Reserve a port for the NATS server
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to reserve port: %v", err)
+	}
+	port := listener.Addr().(*net.TCPAddr).Port
+	listener.Close()
+
+	return NewNatsManager(NATSManagerConfig{StoreType: "memory", Host: "127.0.0.1", Port: port})
+}
+
+func newTestStore(t *testing.T) (store.Store, testTeam) {
+	db, err := sqlitestore.New(":memory:", true)
+	if err != nil {
+		t.Fatalf("Failed to create store: %v", err)
+	}
+
+	var team testTeam
+
+	team.userID, err = db.CreateUser(&model.User{GithubID: "manager-test"})
+	assert.Nil(t, err)
+
+	team.teamID, err = db.CreateTeam(&model.Team{Name: "Team"})
+	assert.Nil(t, err)
+	assert.Nil(t, db.SetTeamMember(team.userID, team.teamID, true))
+
+	team.collectionID, err = db.CreateCollection(&model.Collection{TeamID: team.teamID, Name: "Collection"}, team.userID)
+	assert.Nil(t, err)
+
+	team.shapeCollectionID, err = db.CreateShapeCollection(&model.ShapeCollection{TeamID: team.teamID, Name: "Shapes"}, team.userID)
+	assert.Nil(t, err)
+
+	return db, team
+}
+
+func createTestSubscription(t *testing.T, db store.Store, team testTeam, active bool, url string) int64 {
+	subscriptionID, err := db.CreateSubscription(&model.Subscription{
+		TeamID:            team.teamID,
+		Name:              "Subscription",
+		Active:            active,
+		Output:            string(sub.Webhook),
+		OutputConfig:      model.OutputConfig{"url": url, "retries": float64(0)},
+		Types:             model.MovementList{string(sub.Entered)},
+		Confidences:       model.ConfidenceList{string(sub.ConfidenceLow)},
+		ConfirmPositions:  1,
+		ShapeCollectionID: team.shapeCollectionID,
+		TrackableType:     string(sub.Collection),
+		TrackableID:       team.collectionID,
+	}, team.userID)
+	assert.Nil(t, err)
+
+	return subscriptionID
+}
+
+func createPendingDelivery(t *testing.T, db store.Store, subscriptionID int64) int64 {
+	now := time.Now()
+
+	deliveryID, err := db.CreateDelivery(&model.Delivery{
+		SubscriptionID: subscriptionID,
+		Output:         string(sub.Webhook),
+		Status:         string(sub.Pending),
+		Payload:        []byte(`{"type":"trigger"}`),
+		Created:        now,
+		Updated:        now,
+	})
+	assert.Nil(t, err)
+
+	return deliveryID
+}
+
+// waitFor polls the condition until it's met or a few seconds have passed
+func waitFor(condition func() bool) bool {
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		if condition() {
+			return true
+		}
+	}
+
+	return false
+}
+
+func TestLoadRecoversPendingDeliveries(t *testing.T) {
+	var received int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&received, 1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	db, team := newTestStore(t)
+	defer db.Close()
+
+	activeID := createTestSubscription(t, db, team, true, server.URL)
+	inactiveID := createTestSubscription(t, db, team, false, server.URL)
+
+	activeDeliveryID := createPendingDelivery(t, db, activeID)
+	inactiveDeliveryID := createPendingDelivery(t, db, inactiveID)
+
+	manager := newTestManager(t)
+	defer manager.Shutdown()
+
+	assert.Nil(t, manager.Load(db))
+
+	// Deliveries left pending are redelivered by the output of the subscription, while the ones
+	// of subscriptions which aren't running end up as dead letters
+	assert.True(t, waitFor(func() bool {
+		delivery, err := db.GetDelivery(activeID, activeDeliveryID)
+		return err == nil && delivery.Status == string(sub.Delivered)
+	}), "Should redeliver the pending delivery")
+	assert.Equal(t, int32(1), atomic.LoadInt32(&received))
+
+	assert.True(t, waitFor(func() bool {
+		delivery, err := db.GetDelivery(inactiveID, inactiveDeliveryID)
+		return err == nil && delivery.Status == string(sub.DeadLetter)
+	}), "Should move the pending delivery to the dead letters")
+}
+
+func TestLoadPagesThroughSubscriptions(t *testing.T) {
+	db, team := newTestStore(t)
+	defer db.Close()
+
+	var subscriptionIDs []int64
+	for i := 0; i < loadPageSize+1; i++ {
+		subscriptionIDs = append(subscriptionIDs, createTestSubscription(t, db, team, true, "http://localhost"))
+	}
+
+	// Store a movement for the last subscription, which is on the second page
+	trackerID, err := db.CreateTracker(&model.Tracker{CollectionID: team.collectionID, Name: "Tracker"}, team.userID)
+	assert.Nil(t, err)
+
+	shapeID, err := db.CreateShape(&model.Shape{
+		ShapeCollectionID: team.shapeCollectionID,
+		Name:              "Shape",
+		Shape:             &geometry.Circle{Origo: geometry.Point{X: 0, Y: 0}, Radius: 1000},
+	}, team.userID)
+	assert.Nil(t, err)
+
+	timestamp := time.Now().UnixNano()
+	positionID, err := db.CreatePosition(&model.Position{TrackerID: trackerID, Timestamp: timestamp}, team.userID)
+	assert.Nil(t, err)
+
+	lastSubscriptionID := subscriptionIDs[len(subscriptionIDs)-1]
+	assert.Nil(t, db.InsertMovement(&model.TrackerMovement{
+		TrackerID:      trackerID,
+		SubscriptionID: lastSubscriptionID,
+		ShapeID:        shapeID,
+		PositionID:     positionID,
+		Movements:      model.MovementList{string(sub.Inside), string(sub.Entered)},
+		Entered:        timestamp,
+	}))
+
+	manager := newTestManager(t)
+	defer manager.Shutdown()
+
+	assert.Nil(t, manager.Load(db))
+
+	for _, subscriptionID := range subscriptionIDs {
+		_, err := manager.Get(subscriptionID)
+		assert.Nil(t, err, "Should launch every subscription")
+	}
+
+	// The stored movement is applied, so positions older than it are regarded as out of order
+	geoSubscription, err := manager.Get(lastSubscriptionID)
+	assert.Nil(t, err)
+	assert.Nil(t, geoSubscription.SetAndDiffMovement(model.Position{TrackerID: trackerID, Timestamp: timestamp - 1}, nil))
+}
+
+// failingShapesStore fails to list the shapes of a shape collection and counts the shape collections listed
+type failingShapesStore struct {
+	store.Store
+	failingShapeCollectionID int64
+	listed                   map[int64]int
+}
+
+func (s *failingShapesStore) ListShapesByShapeCollectionID(shapeCollectionID int64, includeShape bool, offset int64, limit int64) ([]model.Shape, error) {
+	s.listed[shapeCollectionID]++
+	if shapeCollectionID == s.failingShapeCollectionID {
+		return nil, errors.New("failed to list shapes")
+	}
+
+	return s.Store.ListShapesByShapeCollectionID(shapeCollectionID, includeShape, offset, limit)
+}
+
+func TestLoadSkipsSubscriptionsWhichCantBeLaunched(t *testing.T) {
+	db, team := newTestStore(t)
+	defer db.Close()
+
+	failingTeam, inactiveTeam := team, team
+
+	var err error
+	failingTeam.shapeCollectionID, err = db.CreateShapeCollection(&model.ShapeCollection{TeamID: team.teamID, Name: "Failing"}, team.userID)
+	assert.Nil(t, err)
+	inactiveTeam.shapeCollectionID, err = db.CreateShapeCollection(&model.ShapeCollection{TeamID: team.teamID, Name: "Inactive"}, team.userID)
+	assert.Nil(t, err)
+
+	failingID := createTestSubscription(t, db, failingTeam, true, "http://localhost")
+	createTestSubscription(t, db, inactiveTeam, false, "http://localhost")
+	activeID := createTestSubscription(t, db, team, true, "http://localhost")
+
+	failingStore := &failingShapesStore{Store: db, failingShapeCollectionID: failingTeam.shapeCollectionID, listed: make(map[int64]int)}
+
+	manager := newTestManager(t)
+	defer manager.Shutdown()
+
+	assert.Nil(t, manager.Load(failingStore))
+
+	_, err = manager.Get(failingID)
+	assert.NotNil(t, err, "Should skip the subscription whose shapes can't be listed")
+	_, err = manager.Get(activeID)
+	assert.Nil(t, err, "Should launch the subscriptions after the failing one")
+
+	assert.Equal(t, 0, failingStore.listed[inactiveTeam.shapeCollectionID], "Should not index the shapes of inactive subscriptions")
+}
+
+func TestManagerReleasesShapeIndexes(t *testing.T) {
+	db, team := newTestStore(t)
+	defer db.Close()
+
+	subscription, err := db.GetSubscription(createTestSubscription(t, db, team, true, "http://localhost"))
+	assert.Nil(t, err)
+
+	manager := newTestManager(t)
+	defer manager.Shutdown()
+
+	builds := 0
+	newGeoSubscription := func(subscription model.Subscription) output.GeoSubscription {
+		shapeIndex, err := manager.ShapeIndexes().Acquire(team.shapeCollectionID, func() (index.TriaIndex, error) {
+			builds++
+			return index.NewRTreeIndex(), nil
+		})
+		assert.Nil(t, err)
+
+		return output.NewGeoSubscription(subscription, shapeIndex, db)
+	}
+
+	// The index is evicted once every reference is released, so acquiring it again builds a new one
+	isEvicted := func() bool {
+		previousBuilds := builds
+		geoSubscription := newGeoSubscription(*subscription)
+		manager.ShapeIndexes().Release(team.shapeCollectionID, geoSubscription.Index)
+
+		return builds > previousBuilds
+	}
+
+	// Subscriptions which are already running are ignored, releasing the index of the new one
+	manager.Refresh([]output.GeoSubscription{newGeoSubscription(*subscription)})
+	manager.Refresh([]output.GeoSubscription{newGeoSubscription(*subscription)})
+	assert.False(t, isEvicted(), "Should keep the index of the running subscription")
+
+	// The replaced subscriptions release their index while the new ones keep theirs
+	assert.Nil(t, manager.Update(newGeoSubscription(*subscription)))
+	assert.False(t, isEvicted(), "Should keep the index of the updated subscription")
+
+	assert.Nil(t, manager.Reload([]output.GeoSubscription{newGeoSubscription(*subscription)}))
+	assert.False(t, isEvicted(), "Should keep the index of the reloaded subscription")
+
+	assert.Nil(t, manager.Stop(subscription.ID))
+	assert.True(t, isEvicted(), "Should release the index of the stopped subscription")
+
+	// Inactive subscriptions aren't launched, so their index is released right away
+	inactive := *subscription
+	inactive.Active = false
+	assert.Nil(t, manager.Update(newGeoSubscription(inactive)))
+	assert.True(t, isEvicted(), "Should release the index of the inactive subscription")
+}
+
+func TestSuspendHoldsMessages(t *testing.T) {
+	var received int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&received, 1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	db, team := newTestStore(t)
+	defer db.Close()
+
+	subscriptionID := createTestSubscription(t, db, team, true, server.URL)
+
+	trackerID, err := db.CreateTracker(&model.Tracker{CollectionID: team.collectionID, Name: "Tracker"}, team.userID)
+	assert.Nil(t, err)
+
+	_, err = db.CreateShape(&model.Shape{
+		ShapeCollectionID: team.shapeCollectionID,
+		Name:              "Shape",
+		Shape:             &geometry.Circle{Origo: geometry.Point{X: 0, Y: 0}, Radius: 1000},
+	}, team.userID)
+	assert.Nil(t, err)
+
+	manager := newTestManager(t)
+	defer manager.Shutdown()
+
+	assert.Nil(t, manager.Load(db))
+	assert.Nil(t, manager.Suspend(subscriptionID))
+
+	_, err = manager.Get(subscriptionID)
+	assert.NotNil(t, err, "Should stop the suspended subscription")
+
+	// The position entering the shape is held while the subscription is suspended
+	manager.Publish(
+		topic.NewEntityTopic(topic.Collection, team.collectionID, topic.DataEvents),
+		event.NewPositionEvent(team.collectionID, model.Position{TrackerID: trackerID, Timestamp: time.Now().UnixNano()}),
+	)
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, int32(0), atomic.LoadInt32(&received))
+
+	geoSubscriptionModel, err := db.GetGeoSubscriptionBySubscription(subscriptionID)
+	assert.Nil(t, err)
+	geoSubscription, err := NewSharedGeoSubscription(*geoSubscriptionModel, manager, db)
+	assert.Nil(t, err)
+	assert.Nil(t, manager.Update(geoSubscription))
+
+	assert.True(t, waitFor(func() bool {
+		return atomic.LoadInt32(&received) == 1
+	}), "Should deliver the held position once resumed")
+}
+
+func TestAcquireShapeIndexPagesThroughShapes(t *testing.T) {
+	db, team := newTestStore(t)
+	defer db.Close()
+
+	var shapeIDs []int64
+	for i := 0; i < shapePageSize+1; i++ {
+		shapeID, err := db.CreateShape(&model.Shape{
+			ShapeCollectionID: team.shapeCollectionID,
+			Name:              "Shape",
+			Shape:             &geometry.Circle{Origo: geometry.Point{X: float64(i % 180), Y: 0}, Radius: 1000},
+		}, team.userID)
+		assert.Nil(t, err)
+
+		shapeIDs = append(shapeIDs, shapeID)
+	}
+
+	manager := newTestManager(t)
+	defer manager.Shutdown()
+
+	shapeIndex, err := AcquireShapeIndex(team.shapeCollectionID, manager, db)
+	assert.Nil(t, err)
+	defer manager.ShapeIndexes().Release(team.shapeCollectionID, shapeIndex)
+
+	for _, shapeID := range []int64{shapeIDs[0], shapeIDs[len(shapeIDs)-1]} {
+		_, err := shapeIndex.(*index.RTreeIndex).GetShapeByID(shapeID)
+		assert.Nil(t, err, "Should index every shape of the shape collection")
+	}
+}
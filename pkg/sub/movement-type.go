@@ -81,7 +81,12 @@ const (
 	Exited MovementType = "exited"
 	// Outside is the state when a tracker has exited a subscribed shape
 	Outside MovementType = "outside"
+	// Dwell is the state when a tracker has stayed inside a subscribed shape for the dwell time
+	// of the subscription. It's only set once per visit to the shape.
+	Dwell MovementType = "dwell"
+	// Crossed is the state when a tracker has crossed a subscribed tripwire between two positions
+	Crossed MovementType = "crossed"
 )
 
 // ValidMovementTypes is a list of valid movement types
-var ValidMovementTypes = []MovementType{Entered, Inside, Exited, Outside}
+var ValidMovementTypes = []MovementType{Entered, Inside, Exited, Outside, Dwell, Crossed}
@@ -12,7 +12,9 @@ const (
 	Webhook OutputType = "webhook"
 	// WebSocket enables simple streaming through a subscription
 	WebSocket OutputType = "websocket"
+	// MQTT publishes triggers to a MQTT broker
+	MQTT OutputType = "mqtt"
 )
 
 // ValidOutputTypes is a list of valid OutputTypes for the subscriptions
-var ValidOutputTypes = []OutputType{SMS, Webhook, WebSocket}
+var ValidOutputTypes = []OutputType{SMS, Webhook, WebSocket, MQTT}
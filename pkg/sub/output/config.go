@@ -24,7 +24,26 @@ func (config *Config) GetIntWithDefault(key string, def int64) int64 {
 		return def
 	}
 
-	v, ok := (*config)[key].(int64)
+	// Configs decoded from JSON will represent numbers as float64
+	switch v := (*config)[key].(type) {
+	case int64:
+		return v
+	case int:
+		return int64(v)
+	case float64:
+		return int64(v)
+	}
+
+	return def
+}
+
+// GetBoolWithDefault gets a bool value from output if present, otherwise returns default value
+func (config *Config) GetBoolWithDefault(key string, def bool) bool {
+	if (*config)[key] == nil {
+		return def
+	}
+
+	v, ok := (*config)[key].(bool)
 
 	if !ok {
 		return def
@@ -32,3 +51,49 @@ func (config *Config) GetIntWithDefault(key string, def int64) int64 {
 
 	return v
 }
+
+// GetStringMapWithDefault gets a map of strings from output if present, otherwise returns default value.
+// Values in the map which aren't strings are ignored.
+func (config *Config) GetStringMapWithDefault(key string, def map[string]string) map[string]string {
+	if (*config)[key] == nil {
+		return def
+	}
+
+	switch v := (*config)[key].(type) {
+	case map[string]string:
+		return v
+	case map[string]interface{}:
+		stringMap := make(map[string]string)
+		for mapKey, mapValue := range v {
+			if stringValue, ok := mapValue.(string); ok {
+				stringMap[mapKey] = stringValue
+			}
+		}
+		return stringMap
+	}
+
+	return def
+}
+
+// GetStringSliceWithDefault gets a list of strings from output if present, otherwise returns default value.
+// Values in the list which aren't strings are ignored.
+func (config *Config) GetStringSliceWithDefault(key string, def []string) []string {
+	if (*config)[key] == nil {
+		return def
+	}
+
+	switch v := (*config)[key].(type) {
+	case []string:
+		return v
+	case []interface{}:
+		stringSlice := make([]string, 0, len(v))
+		for _, value := range v {
+			if stringValue, ok := value.(string); ok {
+				stringSlice = append(stringSlice, stringValue)
+			}
+		}
+		return stringSlice
+	}
+
+	return def
+}
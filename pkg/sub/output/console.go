@@ -100,6 +100,7 @@ func (consoleOutput *ConsoleOutput) printToConsole(payloads []outputPayload) {
 							Movements:         movement.lastMovements.ToStringSlice(),
 							ShapecollectionID: consoleOutput.geoSubscription.Subscription.ShapeCollectionID,
 							ShapeID:           movement.shapeID,
+							Direction:         movement.direction,
 						},
 					),
 				)
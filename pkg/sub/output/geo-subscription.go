@@ -1,6 +1,9 @@
 package output
 
 import (
+	"fmt"
+	"sort"
+
 	"github.com/eesrc/geo/pkg/model"
 	"github.com/eesrc/geo/pkg/store"
 	"github.com/eesrc/geo/pkg/sub"
@@ -15,15 +18,35 @@ type GeoSubscription struct {
 	Index         index.TriaIndex
 	MovementIndex movementIndex
 	movementStore movementStore
+	store         store.Store
+	// trackerPositions holds the previous position of each tracker to find tripwire crossings
+	trackerPositions trackerPositions
+}
+
+// shapeIDIndex is implemented by the indexes which can look up their shapes by ID
+type shapeIDIndex interface {
+	GetShapeByID(int64) (geometry.Shape, error)
+}
+
+// getShapeByID returns the shape with the given ID from the index. Indexes which can't look up their
+// shapes by ID don't find any shape.
+func getShapeByID(shapeIndex index.TriaIndex, shapeID int64) (geometry.Shape, error) {
+	if shapeIndex, ok := shapeIndex.(shapeIDIndex); ok {
+		return shapeIndex.GetShapeByID(shapeID)
+	}
+
+	return nil, fmt.Errorf("No shape found with ID %d", shapeID)
 }
 
 // NewGeoSubscription returns an initialized GeoSubscription with given params
 func NewGeoSubscription(subscription model.Subscription, index index.TriaIndex, store store.Store) GeoSubscription {
 	return GeoSubscription{
-		Subscription:  subscription,
-		Index:         index,
-		MovementIndex: newMovementIndex(),
-		movementStore: newMovementStore(store),
+		Subscription:     subscription,
+		Index:            index,
+		MovementIndex:    newMovementIndex(),
+		movementStore:    newMovementStore(store),
+		store:            store,
+		trackerPositions: newTrackerPositions(),
 	}
 }
 
@@ -37,13 +60,15 @@ func NewGeoSubscriptionWithMovements(subscription model.Subscription, index inde
 		Index:         index,
 		MovementIndex: movementIndex,
 
-		movementStore: newMovementStore(store),
+		movementStore:    newMovementStore(store),
+		store:            store,
+		trackerPositions: newTrackerPositions(),
 	}
 }
 
-// NewGeoSubscriptionFromModel creates a new GeoSubscription from a model. This will create both the movement index and general index for lookup
-func NewGeoSubscriptionFromModel(geoSubscription model.GeoSubscription, store store.Store) GeoSubscription {
-	index := index.NewRTreeIndexFromModel(geoSubscription.Shapes)
+// NewGeoSubscriptionFromModel creates a new GeoSubscription from a model and the shapes of its shape collection. This will create both the movement index and general index for lookup
+func NewGeoSubscriptionFromModel(geoSubscription model.GeoSubscription, shapes []model.Shape, store store.Store) GeoSubscription {
+	index := index.NewRTreeIndexFromModel(shapes)
 
 	movementIndex := newMovementIndex()
 	movementIndex.addMovements(NewTrackerMovementListFromModel(geoSubscription.TrackerMovements))
@@ -53,10 +78,53 @@ func NewGeoSubscriptionFromModel(geoSubscription model.GeoSubscription, store st
 		Index:         index,
 		MovementIndex: movementIndex,
 
-		movementStore: newMovementStore(store),
+		movementStore:    newMovementStore(store),
+		store:            store,
+		trackerPositions: newTrackerPositions(),
 	}
 }
 
+// NewSimulatedGeoSubscription returns a GeoSubscription which starts without any movements and doesn't
+// store the movements it finds, so positions can be run through it without affecting the stored state
+// of the subscription
+func NewSimulatedGeoSubscription(subscription model.Subscription, index index.TriaIndex) GeoSubscription {
+	return GeoSubscription{
+		Subscription:     subscription,
+		Index:            index,
+		MovementIndex:    newMovementIndex(),
+		movementStore:    movementStore{},
+		trackerPositions: newTrackerPositions(),
+	}
+}
+
+// NewSimulatedGeoSubscriptionWithMovements returns a GeoSubscription which starts from the given movements
+// and doesn't store the movements it finds, like NewSimulatedGeoSubscription
+func NewSimulatedGeoSubscriptionWithMovements(subscription model.Subscription, index index.TriaIndex, movements []model.TrackerMovement) GeoSubscription {
+	geoSubscription := NewSimulatedGeoSubscription(subscription, index)
+	geoSubscription.MovementIndex.addMovements(NewTrackerMovementListFromModel(movements))
+
+	return geoSubscription
+}
+
+// NewReplayedGeoSubscription returns a GeoSubscription which starts from the given movements and doesn't
+// store the movements it finds, like NewSimulatedGeoSubscriptionWithMovements. The outputs of it keep an
+// outbox in the store, so the triggers emitted when replaying positions are recorded like the triggers of
+// the running output.
+func NewReplayedGeoSubscription(subscription model.Subscription, index index.TriaIndex, store store.Store, movements []model.TrackerMovement) GeoSubscription {
+	geoSubscription := NewSimulatedGeoSubscriptionWithMovements(subscription, index, movements)
+	geoSubscription.store = store
+
+	return geoSubscription
+}
+
+// KeepMovements carries the movement state of the trackers over from the previous GeoSubscription of the
+// subscription, ie when the shapes of the subscription have changed. Movements of shapes which no longer
+// exist are dropped, so a tracker inside a shape which is kept won't enter it again.
+func (geoSubscription *GeoSubscription) KeepMovements(previous *GeoSubscription) {
+	geoSubscription.MovementIndex.keepMovements(&previous.MovementIndex, geoSubscription.Index)
+	geoSubscription.trackerPositions.keepPositions(&previous.trackerPositions)
+}
+
 // FindShapesWhichContainsPoint checks internal index if it contains a position
 func (geoSubscription *GeoSubscription) FindShapesWhichContainsPoint(position model.Position) []geometry.Shape {
 	return geoSubscription.Index.FindShapesWhichContainsPoint(geometry.Point{X: position.Lon, Y: position.Lat})
@@ -75,16 +143,20 @@ func (geoSubscription *GeoSubscription) ContainsAnyMovements(movementTypes sub.M
 }
 
 // SetAndDiffMovement diffs and updates the movements with the given shapes and returns a list of movements
-// based on given input
+// based on given input. The movements are kept and stored per tracker, so a collection subscription
+// has separate movements for every tracker in the collection.
 func (geoSubscription *GeoSubscription) SetAndDiffMovement(position model.Position, shapes []geometry.Shape) []*TrackerMovement {
-	diffedMovements := geoSubscription.MovementIndex.setAndDiffMovement(position, shapes)
+	diffedMovements := geoSubscription.MovementIndex.setAndDiffMovement(position, shapes, newTriggerCriteria(geoSubscription))
 	for _, movement := range diffedMovements {
 		geoSubscription.movementStore.storeMovement(&model.TrackerMovement{
 			SubscriptionID: geoSubscription.Subscription.ID,
-			TrackerID:      geoSubscription.Subscription.TrackableID,
+			TrackerID:      position.TrackerID,
 			ShapeID:        movement.shapeID,
 			PositionID:     position.ID,
 			Movements:      movement.lastMovements.ToModel(),
+			Entered:        movement.entered,
+			Dwelled:        movement.dwelled,
+			Timestamp:      position.Timestamp,
 		})
 	}
 	return diffedMovements
@@ -109,20 +181,75 @@ func (geoSubscription *GeoSubscription) GetOutputPayloadFromEvent(message interf
 		return outputPayload{}, err
 	}
 
-	position := decodedEvent.(event.PositionEvent).Data.Position
+	return geoSubscription.getOutputPayloadFromPosition(decodedEvent.(event.PositionEvent).Data.Position), nil
+}
 
+// getOutputPayloadFromPosition applies the movements of the position to the subscription
+func (geoSubscription *GeoSubscription) getOutputPayloadFromPosition(position model.Position) outputPayload {
 	// If the precision of the position is not within the subscription parameters we must not
 	// propagate movements to subscription to avoid false positive notifications
 	if !geoSubscription.SubscribedToPrecision(position.Precision) {
-		return outputPayload{}, nil
+		return outputPayload{}
 	}
 
-	// Search for shapes and movements for position
+	// Search for shapes and movements for position, along with any tripwires crossed since the last position
 	shapes := geoSubscription.FindShapesWhichContainsPoint(position)
 	movements := geoSubscription.SetAndDiffMovement(position, shapes)
+	movements = append(movements, geoSubscription.DiffCrossings(position)...)
 
 	return outputPayload{
 		position:  position,
 		movements: movements,
-	}, nil
+	}
+}
+
+// Replay runs the positions through the subscription like Simulate, but keeps the movements. The movements
+// are returned in the order they were found instead of being stored, along with the trigger events.
+func (geoSubscription *GeoSubscription) Replay(positions []model.Position) ([]model.TrackerMovement, []*event.SubscriptionEvent) {
+	movements := make([]model.TrackerMovement, 0)
+
+	store := geoSubscription.movementStore
+	geoSubscription.movementStore = movementStore{replayed: &movements}
+	defer func() {
+		geoSubscription.movementStore = store
+	}()
+
+	triggerEvents := geoSubscription.Simulate(positions)
+
+	return movements, triggerEvents
+}
+
+// Simulate runs the positions through the subscription in order of their timestamps and returns the
+// trigger events the subscription would have produced
+func (geoSubscription *GeoSubscription) Simulate(positions []model.Position) []*event.SubscriptionEvent {
+	sortedPositions := make([]model.Position, len(positions))
+	copy(sortedPositions, positions)
+	sort.SliceStable(sortedPositions, func(i, j int) bool {
+		return sortedPositions[i].Timestamp < sortedPositions[j].Timestamp
+	})
+
+	triggerEvents := make([]*event.SubscriptionEvent, 0)
+
+	for _, position := range sortedPositions {
+		payload := geoSubscription.getOutputPayloadFromPosition(position)
+
+		for _, movement := range payload.movements {
+			if !geoSubscription.ContainsAnyMovements(movement.lastMovements) {
+				continue
+			}
+
+			triggerEvents = append(triggerEvents, event.NewSubscriptionEvent(
+				geoSubscription.Subscription.ID,
+				payload.position,
+				event.TriggerDetails{
+					Movements:         movement.lastMovements.ToStringSlice(),
+					ShapecollectionID: geoSubscription.Subscription.ShapeCollectionID,
+					ShapeID:           movement.shapeID,
+					Direction:         movement.direction,
+				},
+			))
+		}
+	}
+
+	return triggerEvents
 }
@@ -0,0 +1,244 @@
+package output
+
+import (
+	"testing"
+	"time"
+
+	"github.com/eesrc/geo/pkg/model"
+	"github.com/eesrc/geo/pkg/sub"
+	"github.com/eesrc/geo/pkg/tria/geometry"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// storedMovements returns the movements sent to the movement store of the subscription since last time
+func storedMovements(geoSubscription *GeoSubscription) []model.TrackerMovement {
+	movements := make([]model.TrackerMovement, 0)
+	for len(geoSubscription.movementStore.channel) > 0 {
+		movements = append(movements, <-geoSubscription.movementStore.channel)
+	}
+	return movements
+}
+
+func TestCollectionSubscriptionRestart(t *testing.T) {
+	subscriptionModel := model.Subscription{
+		ID:               1,
+		TrackableType:    string(sub.Collection),
+		TrackableID:      100,
+		ConfirmPositions: 2,
+	}
+	// A circle reaching 0.01 degrees along the equator
+	shapes := []model.Shape{{
+		ID:    1,
+		Shape: &geometry.Circle{ID: 1, Origo: geometry.Point{X: 0, Y: 0}, Radius: 1112},
+	}}
+
+	// The movements are read straight off the movement store instead of being written to a store
+	geoSubscription := NewGeoSubscriptionFromModel(model.GeoSubscription{Subscription: subscriptionModel}, shapes, nil)
+	geoSubscription.movementStore = movementStore{channel: make(chan model.TrackerMovement, maxMovements)}
+
+	start := time.Now()
+	positionOf := func(id int64, trackerID int64, lon float64, timestamp time.Time) model.Position {
+		return model.Position{ID: id, TrackerID: trackerID, Lon: lon, Timestamp: timestamp.UnixNano()}
+	}
+	setAndDiff := func(geoSubscription *GeoSubscription, position model.Position) []*TrackerMovement {
+		return geoSubscription.SetAndDiffMovement(position, geoSubscription.FindShapesWhichContainsPoint(position))
+	}
+
+	// Tracker 1 enters the circle, while tracker 2 is on its way in
+	setAndDiff(&geoSubscription, positionOf(1, 1, 0.005, start))
+	movements := setAndDiff(&geoSubscription, positionOf(2, 1, 0.005, start.Add(time.Minute)))
+	assert.Equal(t, sub.MovementList{sub.Entered, sub.Inside}, movements[0].lastMovements)
+
+	movements = setAndDiff(&geoSubscription, positionOf(3, 2, 0.005, start.Add(time.Minute)))
+	assert.Equal(t, sub.MovementList{sub.Outside}, movements[0].lastMovements)
+
+	// The movements are stored for the trackers, not for the collection
+	stored := storedMovements(&geoSubscription)
+	assert.Equal(t, 3, len(stored))
+	assert.Equal(t, int64(1), stored[0].TrackerID)
+	assert.Equal(t, int64(1), stored[1].TrackerID)
+	assert.Equal(t, int64(2), stored[2].TrackerID)
+	for _, movement := range stored {
+		assert.Equal(t, subscriptionModel.ID, movement.SubscriptionID)
+	}
+
+	// Restart the subscription from the stored movements
+	restarted := NewGeoSubscriptionFromModel(model.GeoSubscription{
+		Subscription:     subscriptionModel,
+		TrackerMovements: stored,
+	}, shapes, nil)
+	restarted.movementStore = movementStore{channel: make(chan model.TrackerMovement, maxMovements)}
+
+	// Tracker 1 is still inside, so it doesn't enter again
+	movements = setAndDiff(&restarted, positionOf(4, 1, 0.005, start.Add(2*time.Minute)))
+	assert.Equal(t, 1, len(movements))
+	assert.Equal(t, sub.MovementList{sub.Inside}, movements[0].lastMovements)
+
+	// Tracker 2 is outside and enters once confirmed
+	movements = setAndDiff(&restarted, positionOf(5, 2, 0.005, start.Add(2*time.Minute)))
+	assert.Equal(t, sub.MovementList{sub.Outside}, movements[0].lastMovements)
+	movements = setAndDiff(&restarted, positionOf(6, 2, 0.005, start.Add(3*time.Minute)))
+	assert.Equal(t, sub.MovementList{sub.Entered, sub.Inside}, movements[0].lastMovements)
+
+	// Tracker 1 exits without affecting tracker 2
+	setAndDiff(&restarted, positionOf(7, 1, 0.05, start.Add(4*time.Minute)))
+	movements = setAndDiff(&restarted, positionOf(8, 1, 0.05, start.Add(5*time.Minute)))
+	assert.Equal(t, sub.MovementList{sub.Exited, sub.Outside}, movements[0].lastMovements)
+
+	movements = setAndDiff(&restarted, positionOf(9, 2, 0.005, start.Add(5*time.Minute)))
+	assert.Equal(t, sub.MovementList{sub.Inside}, movements[0].lastMovements)
+
+	for _, movement := range storedMovements(&restarted) {
+		assert.NotEqual(t, subscriptionModel.TrackableID, movement.TrackerID)
+	}
+}
+
+func TestSimulate(t *testing.T) {
+	subscriptionModel := model.Subscription{
+		ID:                1,
+		TrackableType:     string(sub.Tracker),
+		TrackableID:       1,
+		ShapeCollectionID: 2,
+		Types:             model.MovementList{string(sub.Entered), string(sub.Exited)},
+		Confidences:       model.ConfidenceList{string(sub.ConfidenceHigh)},
+	}
+	shapes := []model.Shape{{
+		ID:    1,
+		Shape: &geometry.Circle{ID: 1, Origo: geometry.Point{X: 0, Y: 0}, Radius: 1112},
+	}}
+	geoSubscription := NewGeoSubscriptionFromModel(model.GeoSubscription{Subscription: subscriptionModel}, shapes, nil)
+	simulated := NewSimulatedGeoSubscription(subscriptionModel, geoSubscription.Index)
+
+	start := time.Now()
+	positionOf := func(id int64, lon float64, precision float64, timestamp time.Time) model.Position {
+		return model.Position{ID: id, TrackerID: 1, Lon: lon, Precision: precision, Timestamp: timestamp.UnixNano()}
+	}
+
+	// The positions are simulated in order of their timestamps, ignoring those with a precision outside the subscription
+	triggerEvents := simulated.Simulate([]model.Position{
+		positionOf(3, 0.05, 1, start.Add(2*time.Minute)),
+		positionOf(1, 0.05, 1, start),
+		positionOf(2, 0.005, 1, start.Add(time.Minute)),
+		positionOf(4, 0.005, 0.2, start.Add(3*time.Minute)),
+	})
+
+	assert.Equal(t, 2, len(triggerEvents))
+	assert.Equal(t, int64(2), triggerEvents[0].Data.Position.ID)
+	assert.Equal(t, []string{string(sub.Entered), string(sub.Inside)}, triggerEvents[0].Data.Details.Movements)
+	assert.Equal(t, int64(3), triggerEvents[1].Data.Position.ID)
+	assert.Equal(t, []string{string(sub.Exited), string(sub.Outside)}, triggerEvents[1].Data.Details.Movements)
+	for _, triggerEvent := range triggerEvents {
+		assert.Equal(t, subscriptionModel.ID, triggerEvent.Data.SubscriptionID)
+		assert.Equal(t, subscriptionModel.ShapeCollectionID, triggerEvent.Data.Details.ShapecollectionID)
+		assert.Equal(t, int64(1), triggerEvent.Data.Details.ShapeID)
+	}
+
+	// The movements are discarded rather than stored
+	assert.Nil(t, simulated.movementStore.channel)
+}
+
+func TestReplay(t *testing.T) {
+	subscriptionModel := model.Subscription{
+		ID:                1,
+		TrackableType:     string(sub.Tracker),
+		TrackableID:       1,
+		ShapeCollectionID: 2,
+		Types:             model.MovementList{string(sub.Entered)},
+		Confidences:       model.ConfidenceList{string(sub.ConfidenceHigh)},
+	}
+	shapes := []model.Shape{{
+		ID:    1,
+		Shape: &geometry.Circle{ID: 1, Origo: geometry.Point{X: 0, Y: 0}, Radius: 1112},
+	}}
+	geoSubscription := NewGeoSubscriptionFromModel(model.GeoSubscription{Subscription: subscriptionModel}, shapes, nil)
+	replayed := NewSimulatedGeoSubscription(subscriptionModel, geoSubscription.Index)
+
+	start := time.Now()
+	positionOf := func(id int64, lon float64, timestamp time.Time) model.Position {
+		return model.Position{ID: id, TrackerID: 1, Lon: lon, Precision: 1, Timestamp: timestamp.UnixNano()}
+	}
+
+	// The tracker has been inside for a while, so it only enters once
+	movements, triggerEvents := replayed.Replay([]model.Position{
+		positionOf(1, 0.05, start),
+		positionOf(2, 0.005, start.Add(time.Minute)),
+		positionOf(3, 0.005, start.Add(2*time.Minute)),
+	})
+	assert.Equal(t, 1, len(triggerEvents))
+	assert.Equal(t, int64(2), triggerEvents[0].Data.Position.ID)
+
+	assert.Equal(t, 2, len(movements))
+	assert.Equal(t, model.MovementList{string(sub.Entered), string(sub.Inside)}, movements[0].Movements)
+	assert.Equal(t, int64(2), movements[0].PositionID)
+	assert.Equal(t, model.MovementList{string(sub.Inside)}, movements[1].Movements)
+	assert.Equal(t, int64(3), movements[1].PositionID)
+	for _, movement := range movements {
+		assert.Equal(t, subscriptionModel.ID, movement.SubscriptionID)
+		assert.Equal(t, int64(1), movement.TrackerID)
+		assert.Equal(t, int64(1), movement.ShapeID)
+	}
+
+	// The replayed state is kept for the next positions while the movements are no longer collected
+	position := positionOf(4, 0.005, start.Add(3*time.Minute))
+	nextMovements := replayed.SetAndDiffMovement(position, replayed.FindShapesWhichContainsPoint(position))
+	assert.Equal(t, sub.MovementList{sub.Inside}, nextMovements[0].lastMovements)
+	assert.Nil(t, replayed.movementStore.replayed)
+
+	// A replay starting from the movements before it doesn't enter the shape the tracker is already inside
+	seeded := NewSimulatedGeoSubscriptionWithMovements(subscriptionModel, geoSubscription.Index, []model.TrackerMovement{{
+		SubscriptionID: subscriptionModel.ID,
+		TrackerID:      1,
+		ShapeID:        1,
+		PositionID:     1,
+		Movements:      model.MovementList{string(sub.Inside)},
+		Entered:        start.UnixNano(),
+		Timestamp:      start.UnixNano(),
+	}})
+
+	movements, triggerEvents = seeded.Replay([]model.Position{positionOf(2, 0.005, start.Add(time.Minute))})
+	assert.Equal(t, 0, len(triggerEvents))
+	assert.Equal(t, 1, len(movements))
+	assert.Equal(t, model.MovementList{string(sub.Inside)}, movements[0].Movements)
+}
+
+func TestKeepMovements(t *testing.T) {
+	subscriptionModel := model.Subscription{ID: 1, TrackableType: string(sub.Tracker), TrackableID: 1}
+	circleOf := func(id int64) model.Shape {
+		return model.Shape{ID: id, Shape: &geometry.Circle{ID: id, Origo: geometry.Point{X: 0, Y: 0}, Radius: 1112}}
+	}
+
+	geoSubscription := NewGeoSubscriptionFromModel(model.GeoSubscription{Subscription: subscriptionModel}, []model.Shape{circleOf(1), circleOf(2)}, nil)
+	geoSubscription.movementStore = movementStore{}
+
+	start := time.Now()
+	positionOf := func(id int64, timestamp time.Time) model.Position {
+		return model.Position{ID: id, TrackerID: 1, Lon: 0.005, Timestamp: timestamp.UnixNano()}
+	}
+	setAndDiff := func(geoSubscription *GeoSubscription, position model.Position) map[int64]sub.MovementList {
+		movements := make(map[int64]sub.MovementList)
+		for _, movement := range geoSubscription.SetAndDiffMovement(position, geoSubscription.FindShapesWhichContainsPoint(position)) {
+			movements[movement.shapeID] = movement.lastMovements
+		}
+		return movements
+	}
+
+	movements := setAndDiff(&geoSubscription, positionOf(1, start))
+	assert.Equal(t, sub.MovementList{sub.Entered, sub.Inside}, movements[1])
+	assert.Equal(t, sub.MovementList{sub.Entered, sub.Inside}, movements[2])
+
+	// Shape 2 is removed and shape 3 is added
+	reloaded := NewGeoSubscriptionFromModel(model.GeoSubscription{Subscription: subscriptionModel}, []model.Shape{circleOf(1), circleOf(3)}, nil)
+	reloaded.movementStore = movementStore{}
+	reloaded.KeepMovements(&geoSubscription)
+
+	// The tracker is still inside shape 1, enters shape 3 and has no movements for shape 2
+	movements = setAndDiff(&reloaded, positionOf(2, start.Add(time.Minute)))
+	assert.Equal(t, 2, len(movements))
+	assert.Equal(t, sub.MovementList{sub.Inside}, movements[1])
+	assert.Equal(t, sub.MovementList{sub.Entered, sub.Inside}, movements[3])
+
+	// Positions older than the last position before the reload are still ignored
+	movements = setAndDiff(&reloaded, positionOf(3, start.Add(-time.Minute)))
+	assert.Equal(t, 0, len(movements))
+}
@@ -4,27 +4,37 @@ import (
 	"sync"
 
 	"github.com/eesrc/geo/pkg/model"
-	"github.com/eesrc/geo/pkg/sub"
 	"github.com/eesrc/geo/pkg/tria/geometry"
+	"github.com/eesrc/geo/pkg/tria/index"
 )
 
 type movementIndex struct {
 	mutex    *sync.Mutex
 	trackers map[int64][]*TrackerMovement
+	// lastTimestamps holds the timestamp of the last position evaluated for each tracker
+	lastTimestamps map[int64]int64
 }
 
 func newMovementIndex() movementIndex {
 	return movementIndex{
-		mutex:    &sync.Mutex{},
-		trackers: make(map[int64][]*TrackerMovement),
+		mutex:          &sync.Mutex{},
+		trackers:       make(map[int64][]*TrackerMovement),
+		lastTimestamps: make(map[int64]int64),
 	}
 }
 
+// addMovements adds the stored movements of the trackers, ie when restoring the index on startup. The last
+// timestamp of a tracker is seeded from its movements, so positions older than the stored movements are
+// regarded as out of order.
 func (movementIndex *movementIndex) addMovements(movements []*TrackerMovement) {
 	movementIndex.mutex.Lock()
 	defer movementIndex.mutex.Unlock()
 
 	for _, newTrackerMovement := range movements {
+		if newTrackerMovement.lastTimestamp > movementIndex.lastTimestamps[newTrackerMovement.trackerID] {
+			movementIndex.lastTimestamps[newTrackerMovement.trackerID] = newTrackerMovement.lastTimestamp
+		}
+
 		var trackerMovements = movementIndex.trackers[newTrackerMovement.trackerID]
 
 		// First movement registered
@@ -33,23 +43,75 @@ func (movementIndex *movementIndex) addMovements(movements []*TrackerMovement) {
 			continue
 		}
 
-		// Check if the movement is replacing any other movement
+		// Check if the movement is replacing any other movement, the latest movement of a shape
+		// holds the state of the tracker
+		replaced := false
 		for idx, trackerMovement := range trackerMovements {
 			if trackerMovement.shapeID == newTrackerMovement.shapeID {
 				trackerMovements[idx] = newTrackerMovement
-				continue
+				replaced = true
+				break
 			}
 		}
 
+		if replaced {
+			continue
+		}
+
 		// Not found and not first, add to list
 		movementIndex.trackers[newTrackerMovement.trackerID] = append(trackerMovements, newTrackerMovement)
 	}
 }
 
-func (movementIndex *movementIndex) setAndDiffMovement(position model.Position, shapes []geometry.Shape) []*TrackerMovement {
+// keepMovements replaces the movements of the trackers with the movements of the previous index, leaving
+// out movements of shapes which aren't in the shape index anymore. The last timestamps are kept as well.
+func (movementIndex *movementIndex) keepMovements(previous *movementIndex, shapeIndex index.TriaIndex) {
+	if previous.mutex == movementIndex.mutex {
+		return
+	}
+
+	previous.mutex.Lock()
+	defer previous.mutex.Unlock()
+	movementIndex.mutex.Lock()
+	defer movementIndex.mutex.Unlock()
+
+	shapeExists := make(map[int64]bool)
+	for trackerID, trackerMovements := range previous.trackers {
+		keptMovements := make([]*TrackerMovement, 0, len(trackerMovements))
+
+		for _, trackerMovement := range trackerMovements {
+			exists, ok := shapeExists[trackerMovement.shapeID]
+			if !ok {
+				_, err := getShapeByID(shapeIndex, trackerMovement.shapeID)
+				exists = err == nil
+				shapeExists[trackerMovement.shapeID] = exists
+			}
+
+			if exists {
+				keptMovements = append(keptMovements, trackerMovement)
+			}
+		}
+
+		movementIndex.trackers[trackerID] = keptMovements
+	}
+
+	for trackerID, lastTimestamp := range previous.lastTimestamps {
+		movementIndex.lastTimestamps[trackerID] = lastTimestamp
+	}
+}
+
+// setAndDiffMovement updates the movements of the tracker with the shapes containing the position and returns
+// the movements. Positions older than the last position evaluated for the tracker arrived out of order and
+// returns no movements, as they would otherwise flip the state of the tracker back and forth.
+func (movementIndex *movementIndex) setAndDiffMovement(position model.Position, shapes []geometry.Shape, criteria triggerCriteria) []*TrackerMovement {
 	movementIndex.mutex.Lock()
 	defer movementIndex.mutex.Unlock()
 
+	if lastTimestamp, ok := movementIndex.lastTimestamps[position.TrackerID]; ok && position.Timestamp < lastTimestamp {
+		return nil
+	}
+	movementIndex.lastTimestamps[position.TrackerID] = position.Timestamp
+
 	trackerMovementList, ok := movementIndex.trackers[position.TrackerID]
 
 	// No tracker movements registered before. Add new movement list for tracker
@@ -57,12 +119,7 @@ func (movementIndex *movementIndex) setAndDiffMovement(position model.Position,
 		newTrackerMovementList := make([]*TrackerMovement, len(shapes))
 
 		for i, shape := range shapes {
-			newTrackerMovementList[i] = &TrackerMovement{
-				shapeID:        shape.GetID(),
-				trackerID:      position.TrackerID,
-				lastPositionID: position.ID,
-				lastMovements:  sub.NewMovementList(true),
-			}
+			newTrackerMovementList[i] = newTrackerMovement(shape.GetID(), position, criteria)
 		}
 
 		movementIndex.trackers[position.TrackerID] = newTrackerMovementList
@@ -75,7 +132,7 @@ func (movementIndex *movementIndex) setAndDiffMovement(position model.Position,
 	// Tracker is outside of all shapes, iterate and update all movement as outside.
 	if len(shapes) == 0 {
 		for _, trackerMovement := range trackerMovementList {
-			trackerMovement.Update(false, position)
+			trackerMovement.Update(false, position, criteria)
 
 			if len(trackerMovement.lastMovements) > 0 {
 				newTrackerMovementList = append(newTrackerMovementList, trackerMovement)
@@ -94,7 +151,7 @@ func (movementIndex *movementIndex) setAndDiffMovement(position model.Position,
 			if trackerMovement.shapeID == shape.GetID() {
 				found = true
 
-				trackerMovement.Update(true, position)
+				trackerMovement.Update(true, position, criteria)
 
 				if len(trackerMovement.lastMovements) > 0 {
 					newTrackerMovementList = append(newTrackerMovementList, trackerMovement)
@@ -103,7 +160,7 @@ func (movementIndex *movementIndex) setAndDiffMovement(position model.Position,
 		}
 
 		if !found {
-			trackerMovement.Update(false, position)
+			trackerMovement.Update(false, position, criteria)
 
 			if len(trackerMovement.lastMovements) > 0 {
 				newTrackerMovementList = append(newTrackerMovementList, trackerMovement)
@@ -122,12 +179,7 @@ func (movementIndex *movementIndex) setAndDiffMovement(position model.Position,
 		}
 
 		if !found {
-			newTrackerMovementList = append(newTrackerMovementList, &TrackerMovement{
-				shapeID:        shape.GetID(),
-				trackerID:      position.TrackerID,
-				lastMovements:  sub.NewMovementList(true),
-				lastPositionID: position.ID,
-			})
+			newTrackerMovementList = append(newTrackerMovementList, newTrackerMovement(shape.GetID(), position, criteria))
 		}
 	}
 
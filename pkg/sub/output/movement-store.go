@@ -15,12 +15,23 @@ import (
 type movementStore struct {
 	channel chan model.TrackerMovement
 	store   store.Store
+	// replayed collects the movements instead while positions are replayed through the subscription
+	replayed *[]model.TrackerMovement
 }
 
 const maxMovements = 1500
 const debounceTimeMS = 500 * time.Millisecond
 
+// storeMovement queues the movement for storage. A movement store without a channel discards the
+// movements, which is used when simulating a subscription.
 func (buffer *movementStore) storeMovement(newMovement *model.TrackerMovement) {
+	if buffer.replayed != nil {
+		*buffer.replayed = append(*buffer.replayed, *newMovement)
+		return
+	}
+	if buffer.channel == nil {
+		return
+	}
 	buffer.channel <- *newMovement
 }
 
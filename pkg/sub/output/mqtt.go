@@ -0,0 +1,474 @@
+package output
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/eesrc/geo/pkg/model"
+	"github.com/eesrc/geo/pkg/sub/manager/event"
+	"github.com/eesrc/geo/pkg/sub/manager/topic"
+)
+
+const (
+	// defaultMQTTTopic is the default topic template trigger events are published to
+	defaultMQTTTopic = "geo/{subscriptionId}/{shapeId}"
+	// defaultMQTTQoS is the default quality of service of the published trigger events
+	defaultMQTTQoS = 1
+	// defaultMQTTTimeout is the default timeout in milliseconds when connecting and publishing
+	defaultMQTTTimeout = 5000
+	// defaultMQTTBufferSize is the default max number of trigger events buffered while disconnected
+	defaultMQTTBufferSize = 1000
+	// mqttMaxReconnectInterval is the max interval between connection attempts
+	mqttMaxReconnectInterval = time.Minute
+	// mqttDisconnectQuiesce is the time in milliseconds given to the client to finish work when disconnecting
+	mqttDisconnectQuiesce = 250
+)
+
+// mqttTopicPlaceholders are the placeholders available in the topic template
+var mqttTopicPlaceholders = []string{"{subscriptionId}", "{shapeCollectionId}", "{shapeId}", "{trackerId}"}
+
+// MQTTOutput is an output which publishes the subscription triggers as JSON to a MQTT broker.
+// Trigger events are buffered while the output is disconnected from the broker and published
+// once the connection is (re)established.
+//
+// The output is configured through the subscription output config with the following keys:
+//
+//	broker     - The URL of the broker, ie tcp://localhost:1883 (required)
+//	topic      - The topic template. Supports {subscriptionId}, {shapeCollectionId}, {shapeId} and {trackerId}
+//	qos        - The quality of service of the published messages, 0, 1 or 2
+//	retain     - Whether the broker should retain the published messages
+//	clientId   - The client ID used when connecting. Defaults to geo-subscription-{subscriptionId}
+//	username   - The username used when connecting
+//	password   - The password used when connecting
+//	timeout    - The timeout when connecting and publishing in milliseconds
+//	bufferSize - The max number of trigger events buffered while disconnected. The oldest are discarded first
+type MQTTOutput struct {
+	name            string
+	nReceived       int32
+	terminate       chan bool
+	done            chan bool
+	flush           chan bool
+	stopOnce        sync.Once
+	mutex           sync.Mutex
+	started         bool
+	stopTimeout     time.Duration
+	client          mqtt.Client
+	geoSubscription GeoSubscription
+	config          Config
+	eventCallback   func(topic topic.Topic, event event.PublishableEvent)
+	outbox          outbox
+
+	topicTemplate string
+	qos           byte
+	retain        bool
+	timeout       time.Duration
+	bufferSize    int
+	bufferMutex   sync.Mutex
+	buffer        []mqttMessage
+	// bufferClosed is set once the output has stopped publishing the buffer
+	bufferClosed bool
+}
+
+// mqttMessage is a message waiting to be published
+type mqttMessage struct {
+	topic    string
+	payload  []byte
+	delivery *model.Delivery
+}
+
+// errMQTTBufferFull is the reason for discarding a trigger event when the buffer is full
+var errMQTTBufferFull = errors.New("The MQTT buffer is full")
+
+// errMQTTStopped is the reason for discarding a trigger event which wasn't published before the output stopped
+var errMQTTStopped = errors.New("The MQTT output stopped before the trigger event was published")
+
+func (mqttOutput *MQTTOutput) messageReader(receiver <-chan interface{}) {
+	defer close(mqttOutput.done)
+
+	for {
+		select {
+		case <-mqttOutput.terminate:
+			mqttOutput.drain(receiver, mqttOutput.stopTimeout)
+			return
+		case <-mqttOutput.flush:
+			mqttOutput.publishBuffer()
+		case msg, ok := <-receiver:
+			if !ok {
+				mqttOutput.drain(receiver, mqttOutput.timeout)
+				return
+			}
+
+			atomic.AddInt32(&mqttOutput.nReceived, 1)
+			mqttOutput.bufferPayloads(readOutputPayloads(&mqttOutput.geoSubscription, msg, receiver))
+			mqttOutput.publishBuffer()
+		}
+	}
+}
+
+// drain buffers whatever is left in the queue and attempts to publish the buffer until the
+// timeout. The client is disconnected afterwards and the buffer is closed.
+func (mqttOutput *MQTTOutput) drain(receiver <-chan interface{}, timeout time.Duration) {
+	defer mqttOutput.client.Disconnect(mqttDisconnectQuiesce)
+	defer mqttOutput.closeBuffer()
+
+	select {
+	case msg, ok := <-receiver:
+		if ok {
+			mqttOutput.bufferPayloads(readOutputPayloads(&mqttOutput.geoSubscription, msg, receiver))
+		}
+	default:
+	}
+
+	deadline := time.After(timeout)
+
+	for {
+		remaining := mqttOutput.publishBuffer()
+		if remaining == 0 {
+			return
+		}
+
+		select {
+		case <-mqttOutput.flush:
+		case <-deadline:
+			log.Warnf(
+				"Failed to publish %d trigger event(s) for subscription %d to MQTT before stopping. Moving to dead letters",
+				remaining,
+				mqttOutput.geoSubscription.Subscription.ID,
+			)
+			return
+		}
+	}
+}
+
+// bufferPayloads creates trigger events of the payloads and adds them to the buffer. If the buffer
+// is full the oldest messages are discarded. Each trigger event is also published on the subscription
+// trigger topic and added to the outbox.
+func (mqttOutput *MQTTOutput) bufferPayloads(payloads []outputPayload) {
+	for _, payload := range payloads {
+		for _, movement := range payload.movements {
+			if !mqttOutput.geoSubscription.ContainsAnyMovements(movement.lastMovements) {
+				continue
+			}
+
+			subscriptionEvent := event.NewSubscriptionEvent(
+				mqttOutput.geoSubscription.Subscription.ID,
+				payload.position,
+				event.TriggerDetails{
+					Movements:         movement.lastMovements.ToStringSlice(),
+					ShapecollectionID: mqttOutput.geoSubscription.Subscription.ShapeCollectionID,
+					ShapeID:           movement.shapeID,
+					Direction:         movement.direction,
+				},
+			)
+
+			// Publish trigger event
+			mqttOutput.eventCallback(
+				topic.NewEntityTopic(topic.Subscription, mqttOutput.geoSubscription.Subscription.ID, topic.TriggerEvents),
+				subscriptionEvent,
+			)
+
+			delivery := mqttOutput.outbox.add(subscriptionEvent)
+
+			payloadBytes, err := json.Marshal(subscriptionEvent)
+			if err != nil {
+				log.WithError(err).Errorf("Failed to marshal MQTT payload for subscription %d", mqttOutput.geoSubscription.Subscription.ID)
+				mqttOutput.outbox.failed(err, delivery)
+				continue
+			}
+
+			mqttOutput.bufferMessage(mqttMessage{
+				topic:    mqttOutput.topic(movement.shapeID, payload.position.TrackerID),
+				payload:  payloadBytes,
+				delivery: delivery,
+			})
+		}
+	}
+}
+
+// bufferMessage adds the message to the buffer. If the buffer is full the oldest message is discarded.
+func (mqttOutput *MQTTOutput) bufferMessage(message mqttMessage) {
+	mqttOutput.bufferMutex.Lock()
+	defer mqttOutput.bufferMutex.Unlock()
+
+	// Messages buffered after the output stopped, ie redeliveries, would never be published
+	if mqttOutput.bufferClosed {
+		mqttOutput.outbox.failed(errMQTTStopped, message.delivery)
+		return
+	}
+
+	if len(mqttOutput.buffer) >= mqttOutput.bufferSize {
+		log.Warnf("MQTT buffer for subscription %d is full. Moving oldest trigger event to dead letters", mqttOutput.geoSubscription.Subscription.ID)
+		mqttOutput.outbox.failed(errMQTTBufferFull, mqttOutput.buffer[0].delivery)
+		mqttOutput.buffer = mqttOutput.buffer[1:]
+	}
+
+	mqttOutput.buffer = append(mqttOutput.buffer, message)
+}
+
+// publishBuffer publishes the buffered messages in order while the client is connected. Messages
+// which fail to publish are kept in the buffer to be retried on the next (re)connect. Returns the
+// number of messages left in the buffer.
+func (mqttOutput *MQTTOutput) publishBuffer() int {
+	mqttOutput.bufferMutex.Lock()
+	defer mqttOutput.bufferMutex.Unlock()
+
+	for len(mqttOutput.buffer) > 0 && mqttOutput.client.IsConnectionOpen() {
+		message := mqttOutput.buffer[0]
+
+		token := mqttOutput.client.Publish(message.topic, mqttOutput.qos, mqttOutput.retain, message.payload)
+		if !token.WaitTimeout(mqttOutput.timeout) {
+			log.Warnf("Timed out publishing trigger event for subscription %d to MQTT", mqttOutput.geoSubscription.Subscription.ID)
+			break
+		}
+
+		if token.Error() != nil {
+			log.WithError(token.Error()).Warnf("Failed to publish trigger event for subscription %d to MQTT", mqttOutput.geoSubscription.Subscription.ID)
+			break
+		}
+
+		mqttOutput.outbox.delivered(message.delivery)
+		mqttOutput.buffer = mqttOutput.buffer[1:]
+	}
+
+	return len(mqttOutput.buffer)
+}
+
+// closeBuffer empties the buffer and moves the messages to the dead letters. Messages buffered
+// afterwards go straight to the dead letters.
+func (mqttOutput *MQTTOutput) closeBuffer() {
+	mqttOutput.bufferMutex.Lock()
+	defer mqttOutput.bufferMutex.Unlock()
+
+	for _, message := range mqttOutput.buffer {
+		mqttOutput.outbox.failed(errMQTTStopped, message.delivery)
+	}
+
+	mqttOutput.buffer = mqttOutput.buffer[:0]
+	mqttOutput.bufferClosed = true
+}
+
+// topic returns the topic of a trigger event by filling the placeholders of the topic template
+func (mqttOutput *MQTTOutput) topic(shapeID int64, trackerID int64) string {
+	return strings.NewReplacer(
+		"{subscriptionId}", strconv.FormatInt(mqttOutput.geoSubscription.Subscription.ID, 10),
+		"{shapeCollectionId}", strconv.FormatInt(mqttOutput.geoSubscription.Subscription.ShapeCollectionID, 10),
+		"{shapeId}", strconv.FormatInt(shapeID, 10),
+		"{trackerId}", strconv.FormatInt(trackerID, 10),
+	).Replace(mqttOutput.topicTemplate)
+}
+
+// connect attempts to connect to the broker until it succeeds or the output is stopped. Once
+// connected the client reconnects by itself if the connection is lost.
+func (mqttOutput *MQTTOutput) connect() {
+	backoff := time.Second
+
+	for {
+		token := mqttOutput.client.Connect()
+		if token.WaitTimeout(mqttOutput.timeout) && token.Error() == nil {
+			return
+		}
+
+		log.WithError(token.Error()).Warnf(
+			"Failed to connect to MQTT broker for subscription %d, retrying in %s",
+			mqttOutput.geoSubscription.Subscription.ID,
+			backoff,
+		)
+
+		select {
+		case <-mqttOutput.terminate:
+			return
+		case <-mqttOutput.done:
+			return
+		case <-time.After(backoff):
+		}
+
+		if backoff *= 2; backoff > mqttMaxReconnectInterval {
+			backoff = mqttMaxReconnectInterval
+		}
+	}
+}
+
+// onConnect notifies the message reader that the buffer can be published
+func (mqttOutput *MQTTOutput) onConnect(client mqtt.Client) {
+	log.Infof("MQTT output for subscription %d connected", mqttOutput.geoSubscription.Subscription.ID)
+
+	mqttOutput.requestFlush()
+}
+
+// requestFlush notifies the message reader that the buffer should be published
+func (mqttOutput *MQTTOutput) requestFlush() {
+	select {
+	case mqttOutput.flush <- true:
+	default:
+	}
+}
+
+// Start initiate start of the MQTT output
+func (mqttOutput *MQTTOutput) Start(config Config, message <-chan interface{}) {
+	mqttOutput.mutex.Lock()
+	defer mqttOutput.mutex.Unlock()
+
+	mqttOutput.config = config
+	mqttOutput.topicTemplate = config.GetStringWithDefault("topic", defaultMQTTTopic)
+	mqttOutput.qos = byte(config.GetIntWithDefault("qos", defaultMQTTQoS))
+	mqttOutput.retain = config.GetBoolWithDefault("retain", false)
+	mqttOutput.timeout = time.Duration(config.GetIntWithDefault("timeout", defaultMQTTTimeout)) * time.Millisecond
+	mqttOutput.bufferSize = int(config.GetIntWithDefault("bufferSize", defaultMQTTBufferSize))
+	mqttOutput.buffer = make([]mqttMessage, 0)
+
+	if mqttOutput.bufferSize < 1 {
+		mqttOutput.bufferSize = defaultMQTTBufferSize
+	}
+
+	clientOptions := mqtt.NewClientOptions().
+		AddBroker(config.GetStringWithDefault("broker", "")).
+		SetClientID(config.GetStringWithDefault("clientId", fmt.Sprintf("geo-subscription-%d", mqttOutput.geoSubscription.Subscription.ID))).
+		SetUsername(config.GetStringWithDefault("username", "")).
+		SetPassword(config.GetStringWithDefault("password", "")).
+		SetConnectTimeout(mqttOutput.timeout).
+		SetAutoReconnect(true).
+		SetMaxReconnectInterval(mqttMaxReconnectInterval).
+		SetOnConnectHandler(mqttOutput.onConnect).
+		SetConnectionLostHandler(func(client mqtt.Client, err error) {
+			log.WithError(err).Warnf("MQTT output for subscription %d lost connection, reconnecting", mqttOutput.geoSubscription.Subscription.ID)
+		})
+
+	mqttOutput.client = mqtt.NewClient(clientOptions)
+	mqttOutput.started = true
+
+	go mqttOutput.connect()
+	go mqttOutput.messageReader(message)
+}
+
+// Stop initiate a stop of the MQTT output. Buffered trigger events are given until the timeout
+// to be published before they are discarded.
+func (mqttOutput *MQTTOutput) Stop(timeout time.Duration) {
+	mqttOutput.mutex.Lock()
+	defer mqttOutput.mutex.Unlock()
+
+	if !mqttOutput.started {
+		return
+	}
+
+	mqttOutput.stopOnce.Do(func() {
+		mqttOutput.stopTimeout = timeout
+		close(mqttOutput.terminate)
+	})
+
+	<-mqttOutput.done
+}
+
+// Redeliver queues the trigger event of the delivery for publishing. The delivery is updated once
+// the trigger event is published or discarded.
+func (mqttOutput *MQTTOutput) Redeliver(delivery *model.Delivery) error {
+	mqttOutput.mutex.Lock()
+	started := mqttOutput.started
+	mqttOutput.mutex.Unlock()
+
+	if !started {
+		return errors.New("The MQTT output isn't started")
+	}
+
+	subscriptionEvent, err := decodeDelivery(delivery)
+	if err != nil {
+		return err
+	}
+
+	mqttOutput.bufferMessage(mqttMessage{
+		topic:    mqttOutput.topic(subscriptionEvent.Data.Details.ShapeID, subscriptionEvent.Data.Position.TrackerID),
+		payload:  delivery.Payload,
+		delivery: delivery,
+	})
+	mqttOutput.requestFlush()
+
+	return nil
+}
+
+// Validate validates a MQTT configuration
+func (mqttOutput *MQTTOutput) Validate(config Config) error {
+	rawBroker := config.GetStringWithDefault("broker", "")
+	if rawBroker == "" {
+		return errors.New("The MQTT output needs a broker")
+	}
+
+	brokerURL, err := url.Parse(rawBroker)
+	if err != nil {
+		return fmt.Errorf("The MQTT broker '%s' is not a valid url", rawBroker)
+	}
+
+	switch brokerURL.Scheme {
+	case "tcp", "ssl", "tls", "ws", "wss":
+	default:
+		return fmt.Errorf("The MQTT broker '%s' must use tcp, ssl, tls, ws or wss", rawBroker)
+	}
+
+	if brokerURL.Host == "" {
+		return fmt.Errorf("The MQTT broker '%s' is missing a host", rawBroker)
+	}
+
+	topicTemplate := config.GetStringWithDefault("topic", defaultMQTTTopic)
+	if err := validateMQTTTopic(topicTemplate); err != nil {
+		return err
+	}
+
+	qos := config.GetIntWithDefault("qos", defaultMQTTQoS)
+	if qos < 0 || qos > 2 {
+		return errors.New("The MQTT qos must be 0, 1 or 2")
+	}
+
+	if config.GetIntWithDefault("timeout", defaultMQTTTimeout) < 1 {
+		return errors.New("The MQTT timeout must be above 0")
+	}
+
+	if config.GetIntWithDefault("bufferSize", defaultMQTTBufferSize) < 1 {
+		return errors.New("The MQTT bufferSize must be above 0")
+	}
+
+	return nil
+}
+
+// validateMQTTTopic validates that the topic template is a valid topic to publish to once the placeholders
+// are filled
+func validateMQTTTopic(topicTemplate string) error {
+	if topicTemplate == "" {
+		return errors.New("The MQTT topic can't be empty")
+	}
+
+	publishTopic := topicTemplate
+	for _, placeholder := range mqttTopicPlaceholders {
+		publishTopic = strings.ReplaceAll(publishTopic, placeholder, "0")
+	}
+
+	if strings.ContainsAny(publishTopic, "+#{}") {
+		return fmt.Errorf("The MQTT topic '%s' contains wildcards or unknown placeholders", topicTemplate)
+	}
+
+	return nil
+}
+
+// NewMQTTOutput creates a new MQTT output
+func NewMQTTOutput(geoSubscription GeoSubscription, eventCallback func(topic topic.Topic, event event.PublishableEvent)) *MQTTOutput {
+	config := Config(geoSubscription.Subscription.OutputConfig)
+
+	name := config.GetStringWithDefault("name", "MQTT output")
+
+	return &MQTTOutput{
+		name:            name,
+		terminate:       make(chan bool),
+		done:            make(chan bool),
+		flush:           make(chan bool, 1),
+		geoSubscription: geoSubscription,
+		eventCallback:   eventCallback,
+		outbox:          newOutbox(geoSubscription),
+	}
+}
@@ -0,0 +1,206 @@
+package output
+
+import (
+	"encoding/json"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/eclipse/paho.mqtt.golang/packets"
+
+	"github.com/eesrc/geo/pkg/model"
+	"github.com/eesrc/geo/pkg/sub"
+	"github.com/eesrc/geo/pkg/sub/manager/event"
+	"github.com/eesrc/geo/pkg/sub/manager/topic"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMQTTValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  Config
+		wantErr bool
+	}{
+		{name: "valid config", config: Config{"broker": "tcp://localhost:1883"}, wantErr: false},
+		{name: "valid topic template", config: Config{"broker": "ssl://localhost:8883", "topic": "fences/{shapeCollectionId}/{trackerId}"}, wantErr: false},
+		{name: "missing broker", config: Config{}, wantErr: true},
+		{name: "invalid scheme", config: Config{"broker": "http://localhost:1883"}, wantErr: true},
+		{name: "missing host", config: Config{"broker": "tcp://"}, wantErr: true},
+		{name: "wildcard topic", config: Config{"broker": "tcp://localhost:1883", "topic": "geo/#"}, wantErr: true},
+		{name: "unknown placeholder", config: Config{"broker": "tcp://localhost:1883", "topic": "geo/{foo}"}, wantErr: true},
+		{name: "invalid qos", config: Config{"broker": "tcp://localhost:1883", "qos": float64(3)}, wantErr: true},
+		{name: "zero buffer size", config: Config{"broker": "tcp://localhost:1883", "bufferSize": float64(0)}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := (&MQTTOutput{}).Validate(tt.config)
+			assert.Equal(t, tt.wantErr, err != nil, err)
+		})
+	}
+}
+
+// testBroker is a minimal MQTT broker which acknowledges connections and records published messages
+type testBroker struct {
+	listener  net.Listener
+	mutex     sync.Mutex
+	published []*packets.PublishPacket
+}
+
+func newTestBroker(t *testing.T, address string) *testBroker {
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		t.Fatalf("Failed to start test broker: %v", err)
+	}
+
+	broker := &testBroker{listener: listener}
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go broker.handle(conn)
+		}
+	}()
+
+	return broker
+}
+
+func (broker *testBroker) handle(conn net.Conn) {
+	defer conn.Close()
+
+	for {
+		packet, err := packets.ReadPacket(conn)
+		if err != nil {
+			return
+		}
+
+		switch p := packet.(type) {
+		case *packets.ConnectPacket:
+			_ = packets.NewControlPacket(packets.Connack).Write(conn)
+		case *packets.PublishPacket:
+			broker.mutex.Lock()
+			broker.published = append(broker.published, p)
+			broker.mutex.Unlock()
+
+			if p.Qos == 1 {
+				puback := packets.NewControlPacket(packets.Puback).(*packets.PubackPacket)
+				puback.MessageID = p.MessageID
+				_ = puback.Write(conn)
+			}
+		case *packets.PingreqPacket:
+			_ = packets.NewControlPacket(packets.Pingresp).Write(conn)
+		case *packets.DisconnectPacket:
+			return
+		}
+	}
+}
+
+func (broker *testBroker) messages() []*packets.PublishPacket {
+	broker.mutex.Lock()
+	defer broker.mutex.Unlock()
+
+	return append([]*packets.PublishPacket{}, broker.published...)
+}
+
+func (broker *testBroker) close() {
+	broker.listener.Close()
+}
+
+func newTestMQTTOutput(config Config) *MQTTOutput {
+	mqttOutput := NewMQTTOutput(GeoSubscription{
+		Subscription: model.Subscription{
+			ID:                1,
+			ShapeCollectionID: 2,
+			OutputConfig:      model.OutputConfig(config),
+			Types:             model.MovementList{string(sub.Entered)},
+		},
+	}, func(topic topic.Topic, event event.PublishableEvent) {})
+
+	mqttOutput.Start(config, make(chan interface{}))
+
+	return mqttOutput
+}
+
+func TestMQTTPublishesTriggers(t *testing.T) {
+	broker := newTestBroker(t, "127.0.0.1:0")
+	defer broker.close()
+
+	mqttOutput := newTestMQTTOutput(Config{
+		"broker": "tcp://" + broker.listener.Addr().String(),
+		"topic":  "geo/{subscriptionId}/{shapeCollectionId}/{shapeId}/{trackerId}",
+		"retain": true,
+	})
+
+	mqttOutput.bufferPayloads([]outputPayload{enteredPayload(3)})
+	mqttOutput.Stop(5 * time.Second)
+
+	messages := broker.messages()
+	assert.Len(t, messages, 1)
+	if len(messages) != 1 {
+		return
+	}
+
+	assert.Equal(t, "geo/1/2/7/3", messages[0].TopicName)
+	assert.Equal(t, byte(1), messages[0].Qos)
+	assert.True(t, messages[0].Retain)
+
+	var subscriptionEvent event.SubscriptionEvent
+	assert.Nil(t, json.Unmarshal(messages[0].Payload, &subscriptionEvent))
+}
+
+func TestMQTTBuffersUntilConnected(t *testing.T) {
+	// Reserve an address for the broker which isn't up yet
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to reserve address: %v", err)
+	}
+	address := listener.Addr().String()
+	listener.Close()
+
+	mqttOutput := newTestMQTTOutput(Config{"broker": "tcp://" + address, "bufferSize": float64(2)})
+
+	// The oldest trigger event is discarded as the buffer only holds two
+	for i := int64(1); i <= 3; i++ {
+		mqttOutput.bufferPayloads([]outputPayload{enteredPayload(i)})
+	}
+	assert.Equal(t, 2, mqttOutput.publishBuffer())
+
+	broker := newTestBroker(t, address)
+	defer broker.close()
+
+	mqttOutput.Stop(10 * time.Second)
+
+	messages := broker.messages()
+	assert.Len(t, messages, 2)
+	for i, message := range messages {
+		var subscriptionEvent event.SubscriptionEvent
+		assert.Nil(t, json.Unmarshal(message.Payload, &subscriptionEvent))
+		assert.Equal(t, "geo/1/7", message.TopicName)
+		assert.Equal(t, int64(i+2), subscriptionEvent.Data.Position.TrackerID)
+	}
+}
+
+func TestMQTTRedeliverAfterStop(t *testing.T) {
+	// Reserve an address without a broker
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to reserve address: %v", err)
+	}
+	address := listener.Addr().String()
+	listener.Close()
+
+	mqttOutput := newTestMQTTOutput(Config{"broker": "tcp://" + address})
+	mqttOutput.bufferPayloads([]outputPayload{enteredPayload(1)})
+	mqttOutput.Stop(100 * time.Millisecond)
+
+	// A delivery queued after the output stopped would never be published, so it's a dead letter
+	delivery := &model.Delivery{Status: string(sub.Pending), Payload: []byte(`{}`)}
+	assert.Nil(t, mqttOutput.Redeliver(delivery))
+	assert.Equal(t, string(sub.DeadLetter), delivery.Status)
+	assert.Equal(t, errMQTTStopped.Error(), delivery.LastError)
+}
@@ -0,0 +1,119 @@
+package output
+
+import (
+	"encoding/json"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/eesrc/geo/pkg/model"
+	"github.com/eesrc/geo/pkg/store"
+	"github.com/eesrc/geo/pkg/sub"
+	"github.com/eesrc/geo/pkg/sub/manager/event"
+)
+
+// outbox keeps a record of the trigger events of an output along with the status of their
+// delivery. Trigger events are stored before they are delivered so that failed or discarded
+// deliveries end up as dead letters which can be inspected and re-sent.
+type outbox struct {
+	store          store.Store
+	subscriptionID int64
+	output         string
+}
+
+// add stores the trigger event as a pending delivery. The returned delivery is used to report the
+// outcome of the delivery. Failing to store the delivery is logged, but doesn't stop the delivery.
+func (outbox *outbox) add(subscriptionEvent *event.SubscriptionEvent) *model.Delivery {
+	return outbox.addForRecipient(subscriptionEvent, "")
+}
+
+// addForRecipient stores the trigger event as a pending delivery to one of the recipients of the output,
+// so the outcome is tracked per recipient
+func (outbox *outbox) addForRecipient(subscriptionEvent *event.SubscriptionEvent, recipient string) *model.Delivery {
+	now := time.Now()
+
+	delivery := &model.Delivery{
+		SubscriptionID: outbox.subscriptionID,
+		Output:         outbox.output,
+		Status:         string(sub.Pending),
+		Recipient:      recipient,
+		Created:        now,
+		Updated:        now,
+	}
+
+	payload, err := json.Marshal(subscriptionEvent)
+	if err != nil {
+		log.WithError(err).Errorf("Failed to marshal delivery for subscription %d", outbox.subscriptionID)
+		return delivery
+	}
+	delivery.Payload = payload
+
+	if outbox.store == nil {
+		return delivery
+	}
+
+	delivery.ID, err = outbox.store.CreateDelivery(delivery)
+	if err != nil {
+		log.WithError(err).Errorf("Failed to store delivery for subscription %d", outbox.subscriptionID)
+	}
+
+	return delivery
+}
+
+// delivered marks the deliveries as delivered
+func (outbox *outbox) delivered(deliveries ...*model.Delivery) {
+	outbox.update(sub.Delivered, "", deliveries)
+}
+
+// failed marks the deliveries as dead letters with the given reason
+func (outbox *outbox) failed(reason error, deliveries ...*model.Delivery) {
+	outbox.update(sub.DeadLetter, reason.Error(), deliveries)
+}
+
+func (outbox *outbox) update(status sub.DeliveryStatus, lastError string, deliveries []*model.Delivery) {
+	for _, delivery := range deliveries {
+		delivery.Status = string(status)
+		delivery.Attempts++
+		delivery.LastError = lastError
+		delivery.Updated = time.Now()
+
+		// Deliveries which failed to be stored can't be updated
+		if outbox.store == nil || delivery.ID < 1 {
+			continue
+		}
+
+		err := outbox.store.UpdateDelivery(delivery)
+		if err != nil {
+			log.WithError(err).Errorf("Failed to update delivery %d for subscription %d", delivery.ID, outbox.subscriptionID)
+		}
+	}
+}
+
+func newOutbox(geoSubscription GeoSubscription) outbox {
+	return outbox{
+		store:          geoSubscription.store,
+		subscriptionID: geoSubscription.Subscription.ID,
+		output:         geoSubscription.Subscription.Output,
+	}
+}
+
+// DeadLetter moves a stored delivery to the dead letters with the given reason. It's used for
+// deliveries which no output is able to deliver, ie when the subscription isn't active anymore.
+func DeadLetter(store store.Store, delivery *model.Delivery, reason error) {
+	outbox := outbox{
+		store:          store,
+		subscriptionID: delivery.SubscriptionID,
+		output:         delivery.Output,
+	}
+
+	outbox.failed(reason, delivery)
+}
+
+// decodeDelivery decodes the trigger event stored in the delivery
+func decodeDelivery(delivery *model.Delivery) (event.SubscriptionEvent, error) {
+	var subscriptionEvent event.SubscriptionEvent
+
+	err := json.Unmarshal(delivery.Payload, &subscriptionEvent)
+
+	return subscriptionEvent, err
+}
@@ -1,9 +1,40 @@
 package output
 
-import "github.com/eesrc/geo/pkg/model"
+import (
+	log "github.com/sirupsen/logrus"
+
+	"github.com/eesrc/geo/pkg/model"
+)
 
 // outputPayload is a simple internal struct for handling tracker movements for a position
 type outputPayload struct {
 	position  model.Position
 	movements []*TrackerMovement
 }
+
+// readOutputPayloads reads the given message along with any messages already queued on the receiver and
+// returns the payloads which contains movements
+func readOutputPayloads(geoSubscription *GeoSubscription, msg interface{}, receiver <-chan interface{}) []outputPayload {
+	outputPayloads := make([]outputPayload, 0)
+
+	for {
+		outputPayload, err := geoSubscription.GetOutputPayloadFromEvent(msg)
+
+		if err != nil {
+			log.WithError(err).Error("Something went wrong when trying to get outputPayload")
+		} else if len(outputPayload.movements) > 0 {
+			outputPayloads = append(outputPayloads, outputPayload)
+		}
+
+		if len(receiver) == 0 {
+			break
+		}
+
+		var ok bool
+		if msg, ok = <-receiver; !ok {
+			break
+		}
+	}
+
+	return outputPayloads
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/eesrc/geo/pkg/model"
 	"github.com/eesrc/geo/pkg/sub"
 	"github.com/eesrc/geo/pkg/sub/manager/event"
 	"github.com/eesrc/geo/pkg/sub/manager/topic"
@@ -19,9 +20,24 @@ type Output interface {
 	Start(config Config, message <-chan interface{})
 
 	// Stop halts the output. Any buffered messages that can't be sent during
-	// the timeout will be discarded by the output. When the Stop call returns
+	// the timeout will be discarded by the output. Outputs implementing Redeliverer
+	// move discarded messages to their dead letters. When the Stop call returns
 	// the output has stopped.
 	Stop(timeout time.Duration)
+
+	// Validate validates the output configuration. It returns an error describing
+	// the first invalid config parameter.
+	Validate(config Config) error
+}
+
+// Redeliverer is implemented by outputs which keep an outbox of their deliveries and are
+// able to re-send them
+type Redeliverer interface {
+	// Redeliver re-sends a delivery from the outbox of the output. The delivery is marked as
+	// delivered or as a dead letter depending on the outcome. Outputs which deliver asynchronously
+	// return once the delivery is queued. An error is only returned if the delivery can't be
+	// attempted, failed attempts are recorded on the delivery.
+	Redeliver(delivery *model.Delivery) error
 }
 
 // NewOutput initializes a new output
@@ -30,12 +46,30 @@ func NewOutput(geoSubscription GeoSubscription, eventCallback func(topic topic.T
 
 	switch outputType {
 	case sub.SMS:
-		return NewConsoleOutput(geoSubscription, eventCallback), nil
+		return NewSMSOutput(geoSubscription, eventCallback), nil
 	case sub.Webhook:
-		return NewConsoleOutput(geoSubscription, eventCallback), nil
+		return NewWebhookOutput(geoSubscription, eventCallback), nil
 	case sub.WebSocket:
 		return NewWebsocketOutput(geoSubscription, eventCallback), nil
+	case sub.MQTT:
+		return NewMQTTOutput(geoSubscription, eventCallback), nil
 	}
 
 	return nil, fmt.Errorf("Could not find a output with type '%s'", geoSubscription.Subscription.Output)
 }
+
+// ValidateConfig validates the output configuration for the given output type
+func ValidateConfig(outputType sub.OutputType, config Config) error {
+	switch outputType {
+	case sub.SMS:
+		return (&SMSOutput{}).Validate(config)
+	case sub.Webhook:
+		return (&WebhookOutput{}).Validate(config)
+	case sub.WebSocket:
+		return (&WebsocketOutput{}).Validate(config)
+	case sub.MQTT:
+		return (&MQTTOutput{}).Validate(config)
+	}
+
+	return fmt.Errorf("Could not find a output with type '%s'", outputType)
+}
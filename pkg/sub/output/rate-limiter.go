@@ -0,0 +1,49 @@
+package output
+
+import (
+	"sync"
+	"time"
+)
+
+// rateLimiter is a sliding window rate limiter keyed on an arbitrary string, ie a recipient
+type rateLimiter struct {
+	mutex  sync.Mutex
+	limit  int
+	period time.Duration
+	events map[string][]time.Time
+}
+
+// allow reports whether another event for the key is allowed at the given time. Allowed events
+// are counted towards the limit. A limit below 1 disables the rate limiting.
+func (limiter *rateLimiter) allow(key string, now time.Time) bool {
+	if limiter.limit < 1 {
+		return true
+	}
+
+	limiter.mutex.Lock()
+	defer limiter.mutex.Unlock()
+
+	// Drop the events which have fallen out of the window
+	windowStart := now.Add(-limiter.period)
+	events := limiter.events[key]
+	for len(events) > 0 && !events[0].After(windowStart) {
+		events = events[1:]
+	}
+
+	if len(events) >= limiter.limit {
+		limiter.events[key] = events
+		return false
+	}
+
+	limiter.events[key] = append(events, now)
+
+	return true
+}
+
+func newRateLimiter(limit int, period time.Duration) *rateLimiter {
+	return &rateLimiter{
+		limit:  limit,
+		period: period,
+		events: make(map[string][]time.Time),
+	}
+}
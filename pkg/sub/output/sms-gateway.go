@@ -0,0 +1,159 @@
+package output
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultSMSGatewayTimeout is the default timeout in milliseconds for a single gateway request
+	defaultSMSGatewayTimeout = 5000
+	// defaultSMSRecipientField is the default JSON field holding the recipient in a HTTP gateway request
+	defaultSMSRecipientField = "to"
+	// defaultSMSMessageField is the default JSON field holding the message in a HTTP gateway request
+	defaultSMSMessageField = "message"
+)
+
+// SMSGateway is the driver interface used by the SMSOutput to deliver text messages
+type SMSGateway interface {
+	// Send delivers the message to the recipient. The call blocks until the gateway has accepted
+	// the message or the context is done.
+	Send(ctx context.Context, recipient string, message string) error
+}
+
+// newSMSGateway creates the gateway driver configured in the output config
+func newSMSGateway(config Config) (SMSGateway, error) {
+	switch driver := config.GetStringWithDefault("gateway", "http"); driver {
+	case "http":
+		return NewHTTPSMSGateway(config), nil
+	default:
+		return nil, fmt.Errorf("The SMS gateway '%s' is not supported", driver)
+	}
+}
+
+// HTTPSMSGateway is a generic SMS gateway driver which POSTs each message as a JSON object to an URL.
+//
+// The driver is configured through the subscription output config with the following keys:
+//
+//	url            - The URL of the gateway (required)
+//	headers        - A map of custom headers added to each request, ie for authorization
+//	timeout        - The timeout of a single request in milliseconds
+//	recipientField - The name of the JSON field holding the recipient
+//	messageField   - The name of the JSON field holding the message
+type HTTPSMSGateway struct {
+	url            string
+	headers        map[string]string
+	recipientField string
+	messageField   string
+	client         *http.Client
+}
+
+// Send POSTs the message to the gateway URL
+func (gateway *HTTPSMSGateway) Send(ctx context.Context, recipient string, message string) error {
+	body, err := json.Marshal(map[string]string{
+		gateway.recipientField: recipient,
+		gateway.messageField:   message,
+	})
+	if err != nil {
+		return err
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, gateway.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	request.Header.Set("Content-Type", "application/json")
+	for key, value := range gateway.headers {
+		request.Header.Set(key, value)
+	}
+
+	response, err := gateway.client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	// Drain the body so the connection can be reused
+	_, _ = io.Copy(ioutil.Discard, response.Body)
+
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return fmt.Errorf("SMS gateway responded with status %d", response.StatusCode)
+	}
+
+	return nil
+}
+
+// validateHTTPSMSGateway validates a HTTP gateway configuration
+func validateHTTPSMSGateway(config Config) error {
+	err := validateHTTPURL("SMS gateway", config.GetStringWithDefault("url", ""))
+	if err != nil {
+		return err
+	}
+
+	if config.GetIntWithDefault("timeout", defaultSMSGatewayTimeout) < 1 {
+		return fmt.Errorf("The SMS gateway timeout must be above 0")
+	}
+
+	return nil
+}
+
+// NewHTTPSMSGateway creates a new HTTP gateway driver from the output config
+func NewHTTPSMSGateway(config Config) *HTTPSMSGateway {
+	return &HTTPSMSGateway{
+		url:            config.GetStringWithDefault("url", ""),
+		headers:        config.GetStringMapWithDefault("headers", map[string]string{}),
+		recipientField: config.GetStringWithDefault("recipientField", defaultSMSRecipientField),
+		messageField:   config.GetStringWithDefault("messageField", defaultSMSMessageField),
+		client: &http.Client{
+			Timeout: time.Duration(config.GetIntWithDefault("timeout", defaultSMSGatewayTimeout)) * time.Millisecond,
+		},
+	}
+}
+
+// SMSMessage is a text message delivered through the MemorySMSGateway
+type SMSMessage struct {
+	Recipient string
+	Message   string
+}
+
+// MemorySMSGateway is a SMS gateway driver which keeps the messages in memory. It's meant for testing.
+type MemorySMSGateway struct {
+	mutex    sync.Mutex
+	messages []SMSMessage
+}
+
+// Send stores the message in memory
+func (gateway *MemorySMSGateway) Send(ctx context.Context, recipient string, message string) error {
+	gateway.mutex.Lock()
+	defer gateway.mutex.Unlock()
+
+	gateway.messages = append(gateway.messages, SMSMessage{Recipient: recipient, Message: message})
+
+	return nil
+}
+
+// Messages returns a copy of the messages sent through the gateway
+func (gateway *MemorySMSGateway) Messages() []SMSMessage {
+	gateway.mutex.Lock()
+	defer gateway.mutex.Unlock()
+
+	messages := make([]SMSMessage, len(gateway.messages))
+	copy(messages, gateway.messages)
+
+	return messages
+}
+
+// NewMemorySMSGateway creates a new in-memory gateway driver
+func NewMemorySMSGateway() *MemorySMSGateway {
+	return &MemorySMSGateway{
+		messages: make([]SMSMessage, 0),
+	}
+}
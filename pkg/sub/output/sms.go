@@ -0,0 +1,444 @@
+package output
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"text/template"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/eesrc/geo/pkg/model"
+	"github.com/eesrc/geo/pkg/sub"
+	"github.com/eesrc/geo/pkg/sub/manager/event"
+	"github.com/eesrc/geo/pkg/sub/manager/topic"
+)
+
+const (
+	// defaultSMSTemplate is the default template of the text messages
+	defaultSMSTemplate = "{{.TrackerName}} {{.Movement}} {{.ShapeName}}"
+	// defaultSMSRateLimit is the default max number of messages sent to a single recipient within the rate limit period
+	defaultSMSRateLimit = 10
+	// defaultSMSRateLimitPeriod is the default rate limit period in seconds
+	defaultSMSRateLimitPeriod = 3600
+)
+
+// errSMSRateLimited is the reason of dead letters to recipients which have reached the rate limit
+var errSMSRateLimited = errors.New("The SMS rate limit of the recipient is reached")
+
+// SMSOutput is an output which sends a text message to a list of recipients whenever a subscription triggers.
+// The messages are delivered through a SMSGateway driver.
+//
+// The output is configured through the subscription output config with the following keys, along with
+// the keys of the configured gateway driver:
+//
+//	recipients      - The list of phone numbers receiving the messages (required)
+//	template        - A text/template for the message. See smsTemplateData for the available fields
+//	gateway         - The gateway driver delivering the messages. Only "http" is supported
+//	rateLimit       - The max number of messages sent to a single recipient within the period. 0 disables the limit
+//	rateLimitPeriod - The rate limit period in seconds
+type SMSOutput struct {
+	name            string
+	nReceived       int32
+	terminate       chan bool
+	done            chan bool
+	stopOnce        sync.Once
+	mutex           sync.Mutex
+	ctx             context.Context
+	cancel          context.CancelFunc
+	geoSubscription GeoSubscription
+	config          Config
+	eventCallback   func(topic topic.Topic, event event.PublishableEvent)
+	outbox          outbox
+
+	sendMutex    sync.Mutex
+	gateway      SMSGateway
+	recipients   []string
+	template     *template.Template
+	rateLimiter  *rateLimiter
+	trackerNames map[int64]string
+}
+
+// smsTemplateData is the data available in the message template
+type smsTemplateData struct {
+	SubscriptionID   int64
+	SubscriptionName string
+	TrackerID        int64
+	TrackerName      string
+	ShapeID          int64
+	ShapeName        string
+	// Movement is the most significant movement the subscription is triggered by, ie "entered"
+	Movement string
+	// Movements are all the movements the subscription is triggered by
+	Movements []string
+	// Direction is the direction a tripwire was crossed in, ie "left-to-right". Empty for other movements
+	Direction string
+	Lat       float64
+	Lon       float64
+	Timestamp time.Time
+}
+
+func (smsOutput *SMSOutput) messageReader(ctx context.Context, receiver <-chan interface{}) {
+	defer close(smsOutput.done)
+
+	for {
+		select {
+		case <-smsOutput.terminate:
+			// Attempt to send whatever is left in the queue before stopping. Once the stop
+			// timeout cancels the context the remaining trigger events end up as dead letters.
+			for {
+				select {
+				case msg, ok := <-receiver:
+					if !ok {
+						return
+					}
+					smsOutput.sendMessages(ctx, readOutputPayloads(&smsOutput.geoSubscription, msg, receiver))
+				default:
+					return
+				}
+			}
+		case msg, ok := <-receiver:
+			if !ok {
+				return
+			}
+
+			atomic.AddInt32(&smsOutput.nReceived, 1)
+			smsOutput.sendMessages(ctx, readOutputPayloads(&smsOutput.geoSubscription, msg, receiver))
+		}
+	}
+}
+
+// sendMessages sends a text message to each recipient for every movement matching the subscription.
+// Each trigger event is also published on the subscription trigger topic and added to the outbox
+// as a delivery per recipient.
+func (smsOutput *SMSOutput) sendMessages(ctx context.Context, payloads []outputPayload) {
+	for _, payload := range payloads {
+		for _, movement := range payload.movements {
+			if !smsOutput.geoSubscription.ContainsAnyMovements(movement.lastMovements) {
+				continue
+			}
+
+			subscriptionEvent := event.NewSubscriptionEvent(
+				smsOutput.geoSubscription.Subscription.ID,
+				payload.position,
+				event.TriggerDetails{
+					Movements:         movement.lastMovements.ToStringSlice(),
+					ShapecollectionID: smsOutput.geoSubscription.Subscription.ShapeCollectionID,
+					ShapeID:           movement.shapeID,
+					Direction:         movement.direction,
+				},
+			)
+
+			// Publish trigger event
+			smsOutput.eventCallback(
+				topic.NewEntityTopic(topic.Subscription, smsOutput.geoSubscription.Subscription.ID, topic.TriggerEvents),
+				subscriptionEvent,
+			)
+
+			smsOutput.deliver(ctx, subscriptionEvent)
+		}
+	}
+}
+
+// deliver sends the message of the trigger event to each recipient and records the outcome in the
+// outbox. Messages to recipients which have reached the rate limit are moved to the dead letters
+// without being sent.
+func (smsOutput *SMSOutput) deliver(ctx context.Context, subscriptionEvent *event.SubscriptionEvent) {
+	// Messages are sent from both the message reader and redeliveries
+	smsOutput.sendMutex.Lock()
+	defer smsOutput.sendMutex.Unlock()
+
+	message, renderErr := smsOutput.renderEvent(subscriptionEvent)
+
+	for _, recipient := range smsOutput.recipients {
+		delivery := smsOutput.outbox.addForRecipient(subscriptionEvent, recipient)
+
+		err := renderErr
+		if err == nil && !smsOutput.rateLimiter.allow(recipient, time.Now()) {
+			err = errSMSRateLimited
+		}
+		if err == nil {
+			err = smsOutput.gateway.Send(ctx, recipient, message)
+		}
+
+		if err != nil {
+			log.WithError(err).Errorf("Failed to send SMS for subscription %d. Moving to dead letters", smsOutput.geoSubscription.Subscription.ID)
+			smsOutput.outbox.failed(err, delivery)
+			continue
+		}
+
+		smsOutput.outbox.delivered(delivery)
+	}
+}
+
+// send renders the message of the trigger event and sends it to the recipients without applying the
+// rate limit. Returns the last error returned by the gateway, if any.
+func (smsOutput *SMSOutput) send(ctx context.Context, subscriptionEvent *event.SubscriptionEvent, recipients []string) error {
+	smsOutput.sendMutex.Lock()
+	defer smsOutput.sendMutex.Unlock()
+
+	message, err := smsOutput.renderEvent(subscriptionEvent)
+	if err != nil {
+		return err
+	}
+
+	var sendErr error
+
+	for _, recipient := range recipients {
+		err := smsOutput.gateway.Send(ctx, recipient, message)
+		if err != nil {
+			sendErr = err
+		}
+	}
+
+	return sendErr
+}
+
+// renderEvent renders the message of the trigger event
+func (smsOutput *SMSOutput) renderEvent(subscriptionEvent *event.SubscriptionEvent) (string, error) {
+	message, err := smsOutput.renderMessage(
+		subscriptionEvent.Data.Position,
+		subscriptionEvent.Data.Details.ShapeID,
+		sub.NewMovementTypeFromModel(subscriptionEvent.Data.Details.Movements),
+		subscriptionEvent.Data.Details.Direction,
+	)
+	if err != nil {
+		return "", fmt.Errorf("Failed to render SMS template: %v", err)
+	}
+
+	return message, nil
+}
+
+// renderMessage renders the message template for the movements of the tracker relative to the shape
+func (smsOutput *SMSOutput) renderMessage(position model.Position, shapeID int64, lastMovements sub.MovementList, direction string) (string, error) {
+	movements := make([]string, 0)
+	for _, lastMovement := range lastMovements {
+		if smsOutput.geoSubscription.ContainsAnyMovements(sub.MovementList{lastMovement}) {
+			movements = append(movements, string(lastMovement))
+		}
+	}
+
+	mostSignificantMovement := ""
+	if len(movements) > 0 {
+		mostSignificantMovement = movements[0]
+	}
+
+	data := smsTemplateData{
+		SubscriptionID:   smsOutput.geoSubscription.Subscription.ID,
+		SubscriptionName: smsOutput.geoSubscription.Subscription.Name,
+		TrackerID:        position.TrackerID,
+		TrackerName:      smsOutput.trackerName(position.TrackerID),
+		ShapeID:          shapeID,
+		ShapeName:        smsOutput.shapeName(shapeID),
+		Movement:         mostSignificantMovement,
+		Movements:        movements,
+		Direction:        direction,
+		Lat:              position.Lat,
+		Lon:              position.Lon,
+		Timestamp:        time.Unix(0, position.Timestamp),
+	}
+
+	var buf bytes.Buffer
+	err := smsOutput.template.Execute(&buf, data)
+	if err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
+
+// trackerName returns the name of the tracker, falling back to its ID if the name can't be found.
+// Names are cached for the lifetime of the output.
+func (smsOutput *SMSOutput) trackerName(trackerID int64) string {
+	if name, ok := smsOutput.trackerNames[trackerID]; ok {
+		return name
+	}
+
+	name := fmt.Sprintf("Tracker %d", trackerID)
+
+	if smsOutput.geoSubscription.store != nil {
+		tracker, err := smsOutput.geoSubscription.store.GetTracker(trackerID)
+		if err != nil {
+			log.WithError(err).Warnf("Failed to get tracker %d for SMS output", trackerID)
+			return name
+		}
+
+		if tracker.Name != "" {
+			name = tracker.Name
+		}
+	}
+
+	smsOutput.trackerNames[trackerID] = name
+
+	return name
+}
+
+// shapeName returns the name of the shape, falling back to its ID if the shape isn't found in the index
+func (smsOutput *SMSOutput) shapeName(shapeID int64) string {
+	shape, err := getShapeByID(smsOutput.geoSubscription.Index, shapeID)
+	if err == nil && shape.GetName() != "" {
+		return shape.GetName()
+	}
+
+	return fmt.Sprintf("Shape %d", shapeID)
+}
+
+// Start initiate start of the SMS output
+func (smsOutput *SMSOutput) Start(config Config, message <-chan interface{}) {
+	smsOutput.mutex.Lock()
+	defer smsOutput.mutex.Unlock()
+
+	smsOutput.config = config
+	smsOutput.recipients = config.GetStringSliceWithDefault("recipients", []string{})
+	smsOutput.rateLimiter = newRateLimiter(
+		int(config.GetIntWithDefault("rateLimit", defaultSMSRateLimit)),
+		time.Duration(config.GetIntWithDefault("rateLimitPeriod", defaultSMSRateLimitPeriod))*time.Second,
+	)
+
+	messageTemplate, err := template.New("sms").Parse(config.GetStringWithDefault("template", defaultSMSTemplate))
+	if err != nil {
+		log.WithError(err).Errorf("Invalid SMS template for subscription %d. Using default template", smsOutput.geoSubscription.Subscription.ID)
+		messageTemplate = template.Must(template.New("sms").Parse(defaultSMSTemplate))
+	}
+	smsOutput.template = messageTemplate
+
+	// The gateway might already be set, ie when testing
+	if smsOutput.gateway == nil {
+		gateway, err := newSMSGateway(config)
+		if err != nil {
+			// Messages end up in memory and are effectively discarded
+			log.WithError(err).Errorf("Failed to create SMS gateway for subscription %d. Discarding messages", smsOutput.geoSubscription.Subscription.ID)
+			gateway = NewMemorySMSGateway()
+		}
+		smsOutput.gateway = gateway
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	smsOutput.ctx = ctx
+	smsOutput.cancel = cancel
+
+	go smsOutput.messageReader(ctx, message)
+}
+
+// Stop initiate a stop of the SMS output. Pending messages are given until the timeout
+// to be sent before they are cancelled.
+func (smsOutput *SMSOutput) Stop(timeout time.Duration) {
+	smsOutput.mutex.Lock()
+	defer smsOutput.mutex.Unlock()
+
+	if smsOutput.cancel == nil {
+		return
+	}
+
+	smsOutput.stopOnce.Do(func() {
+		close(smsOutput.terminate)
+	})
+
+	select {
+	case <-smsOutput.done:
+	case <-time.After(timeout):
+		smsOutput.cancel()
+		<-smsOutput.done
+	}
+
+	smsOutput.cancel()
+}
+
+// Redeliver sends the trigger event of the delivery to the recipient of the delivery. Deliveries without
+// a recipient were stored for every recipient and are sent to all of them. Manually re-sent messages
+// aren't subject to the rate limit.
+func (smsOutput *SMSOutput) Redeliver(delivery *model.Delivery) error {
+	smsOutput.mutex.Lock()
+	ctx := smsOutput.ctx
+	smsOutput.mutex.Unlock()
+
+	if ctx == nil {
+		return errors.New("The SMS output isn't started")
+	}
+
+	subscriptionEvent, err := decodeDelivery(delivery)
+	if err != nil {
+		return err
+	}
+
+	smsOutput.mutex.Lock()
+	recipients := smsOutput.recipients
+	smsOutput.mutex.Unlock()
+
+	if delivery.Recipient != "" {
+		recipients = []string{delivery.Recipient}
+	}
+
+	err = smsOutput.send(ctx, &subscriptionEvent, recipients)
+	if err != nil {
+		smsOutput.outbox.failed(err, delivery)
+		return nil
+	}
+
+	smsOutput.outbox.delivered(delivery)
+
+	return nil
+}
+
+// Validate validates a SMS configuration
+func (smsOutput *SMSOutput) Validate(config Config) error {
+	recipients := config.GetStringSliceWithDefault("recipients", []string{})
+	if len(recipients) == 0 {
+		return errors.New("The SMS output needs at least one recipient")
+	}
+
+	for _, recipient := range recipients {
+		if strings.TrimSpace(recipient) == "" {
+			return errors.New("The SMS recipients can't be empty")
+		}
+	}
+
+	_, err := template.New("sms").Parse(config.GetStringWithDefault("template", defaultSMSTemplate))
+	if err != nil {
+		return fmt.Errorf("The SMS template is not valid: %v", err)
+	}
+
+	if config.GetIntWithDefault("rateLimit", defaultSMSRateLimit) < 0 {
+		return errors.New("The SMS rateLimit can't be negative")
+	}
+
+	if config.GetIntWithDefault("rateLimitPeriod", defaultSMSRateLimitPeriod) < 1 {
+		return errors.New("The SMS rateLimitPeriod must be above 0")
+	}
+
+	switch driver := config.GetStringWithDefault("gateway", "http"); driver {
+	case "http":
+		return validateHTTPSMSGateway(config)
+	default:
+		return fmt.Errorf("The SMS gateway '%s' is not supported", driver)
+	}
+}
+
+// NewSMSOutput creates a new SMS output using the gateway driver from the output config
+func NewSMSOutput(geoSubscription GeoSubscription, eventCallback func(topic topic.Topic, event event.PublishableEvent)) *SMSOutput {
+	return NewSMSOutputWithGateway(geoSubscription, eventCallback, nil)
+}
+
+// NewSMSOutputWithGateway creates a new SMS output using the given gateway driver. If the gateway
+// is nil the driver is created from the output config when started.
+func NewSMSOutputWithGateway(geoSubscription GeoSubscription, eventCallback func(topic topic.Topic, event event.PublishableEvent), gateway SMSGateway) *SMSOutput {
+	config := Config(geoSubscription.Subscription.OutputConfig)
+
+	name := config.GetStringWithDefault("name", "SMS output")
+
+	return &SMSOutput{
+		name:            name,
+		terminate:       make(chan bool),
+		done:            make(chan bool),
+		geoSubscription: geoSubscription,
+		eventCallback:   eventCallback,
+		outbox:          newOutbox(geoSubscription),
+		gateway:         gateway,
+		trackerNames:    make(map[int64]string),
+	}
+}
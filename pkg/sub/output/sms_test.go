@@ -0,0 +1,205 @@
+package output
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/eesrc/geo/pkg/model"
+	"github.com/eesrc/geo/pkg/store"
+	"github.com/eesrc/geo/pkg/sub"
+	"github.com/eesrc/geo/pkg/sub/manager/event"
+	"github.com/eesrc/geo/pkg/sub/manager/topic"
+	"github.com/eesrc/geo/pkg/tria/geometry"
+	"github.com/eesrc/geo/pkg/tria/index"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSMSValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  Config
+		wantErr bool
+	}{
+		{name: "valid config", config: Config{"url": "https://sms.example.com", "recipients": []interface{}{"+4712345678"}}, wantErr: false},
+		{name: "missing recipients", config: Config{"url": "https://sms.example.com"}, wantErr: true},
+		{name: "empty recipient", config: Config{"url": "https://sms.example.com", "recipients": []interface{}{" "}}, wantErr: true},
+		{name: "missing gateway url", config: Config{"recipients": []interface{}{"+4712345678"}}, wantErr: true},
+		{name: "unknown gateway", config: Config{"gateway": "carrier pigeon", "recipients": []interface{}{"+4712345678"}}, wantErr: true},
+		{name: "invalid template", config: Config{"url": "https://sms.example.com", "recipients": []interface{}{"+4712345678"}, "template": "{{.TrackerName"}, wantErr: true},
+		{name: "negative rate limit", config: Config{"url": "https://sms.example.com", "recipients": []interface{}{"+4712345678"}, "rateLimit": float64(-1)}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := (&SMSOutput{}).Validate(tt.config)
+			assert.Equal(t, tt.wantErr, err != nil, err)
+		})
+	}
+}
+
+// deliveryStore keeps the deliveries of an outbox in memory
+type deliveryStore struct {
+	store.Store
+	deliveries []model.Delivery
+}
+
+func (deliveryStore *deliveryStore) CreateDelivery(delivery *model.Delivery) (int64, error) {
+	deliveryStore.deliveries = append(deliveryStore.deliveries, *delivery)
+	return int64(len(deliveryStore.deliveries)), nil
+}
+
+func (deliveryStore *deliveryStore) UpdateDelivery(delivery *model.Delivery) error {
+	deliveryStore.deliveries[delivery.ID-1] = *delivery
+	return nil
+}
+
+func (deliveryStore *deliveryStore) GetTracker(trackerID int64) (*model.Tracker, error) {
+	return &model.Tracker{ID: trackerID}, nil
+}
+
+func newTestSMSOutput(config Config, gateway SMSGateway) *SMSOutput {
+	return newTestSMSOutputWithStore(config, gateway, nil)
+}
+
+func newTestSMSOutputWithStore(config Config, gateway SMSGateway, store store.Store) *SMSOutput {
+	shapeIndex := index.NewSimpleIndex()
+	shapeIndex.AddShape(&geometry.Circle{ID: 7, Name: "Depot"})
+
+	smsOutput := NewSMSOutputWithGateway(GeoSubscription{
+		Subscription: model.Subscription{
+			ID:           1,
+			OutputConfig: model.OutputConfig(config),
+			Types:        model.MovementList{string(sub.Entered)},
+		},
+		Index: shapeIndex,
+		store: store,
+	}, func(topic topic.Topic, event event.PublishableEvent) {}, gateway)
+
+	smsOutput.Start(config, make(chan interface{}))
+
+	return smsOutput
+}
+
+func enteredPayload(trackerID int64) outputPayload {
+	return outputPayload{
+		position: model.Position{TrackerID: trackerID},
+		movements: []*TrackerMovement{
+			{shapeID: 7, trackerID: trackerID, lastMovements: sub.MovementList{sub.Entered, sub.Inside}},
+			{shapeID: 8, trackerID: trackerID, lastMovements: sub.MovementList{sub.Inside}},
+		},
+	}
+}
+
+func TestSMSTemplate(t *testing.T) {
+	gateway := NewMemorySMSGateway()
+
+	smsOutput := newTestSMSOutput(Config{
+		"recipients": []interface{}{"+4712345678", "+4787654321"},
+		"template":   "{{.TrackerName}} has {{.Movement}} {{.ShapeName}} ({{.ShapeID}})",
+	}, gateway)
+	defer smsOutput.Stop(time.Second)
+
+	smsOutput.sendMessages(context.Background(), []outputPayload{enteredPayload(3)})
+
+	messages := gateway.Messages()
+	assert.Equal(t, []SMSMessage{
+		{Recipient: "+4712345678", Message: "Tracker 3 has entered Depot (7)"},
+		{Recipient: "+4787654321", Message: "Tracker 3 has entered Depot (7)"},
+	}, messages)
+}
+
+func TestSMSRateLimit(t *testing.T) {
+	gateway := NewMemorySMSGateway()
+
+	smsOutput := newTestSMSOutput(Config{
+		"recipients": []interface{}{"+4712345678"},
+		"rateLimit":  float64(2),
+	}, gateway)
+	defer smsOutput.Stop(time.Second)
+
+	// A tracker flapping on the edge of a shape should only reach the recipient twice
+	for i := 0; i < 10; i++ {
+		smsOutput.sendMessages(context.Background(), []outputPayload{enteredPayload(3)})
+	}
+
+	assert.Len(t, gateway.Messages(), 2)
+}
+
+func TestSMSDeliveryPerRecipient(t *testing.T) {
+	gateway := NewMemorySMSGateway()
+	deliveries := &deliveryStore{}
+
+	smsOutput := newTestSMSOutputWithStore(Config{
+		"recipients": []interface{}{"+4712345678", "+4787654321"},
+		"rateLimit":  float64(1),
+	}, gateway, deliveries)
+	defer smsOutput.Stop(time.Second)
+
+	smsOutput.sendMessages(context.Background(), []outputPayload{enteredPayload(3)})
+	smsOutput.sendMessages(context.Background(), []outputPayload{enteredPayload(3)})
+
+	// The outcome is tracked per recipient, messages over the rate limit are dead letters
+	assert.Len(t, deliveries.deliveries, 4)
+	for i, recipient := range []string{"+4712345678", "+4787654321", "+4712345678", "+4787654321"} {
+		assert.Equal(t, recipient, deliveries.deliveries[i].Recipient)
+	}
+	assert.Equal(t, string(sub.Delivered), deliveries.deliveries[0].Status)
+	assert.Equal(t, string(sub.Delivered), deliveries.deliveries[1].Status)
+	assert.Equal(t, string(sub.DeadLetter), deliveries.deliveries[2].Status)
+	assert.Equal(t, errSMSRateLimited.Error(), deliveries.deliveries[2].LastError)
+	assert.Equal(t, string(sub.DeadLetter), deliveries.deliveries[3].Status)
+	assert.Len(t, gateway.Messages(), 2)
+
+	// Redelivering a dead letter only sends the message to its recipient
+	deadLetter := deliveries.deliveries[3]
+	assert.Nil(t, smsOutput.Redeliver(&deadLetter))
+
+	messages := gateway.Messages()
+	assert.Len(t, messages, 3)
+	assert.Equal(t, "+4787654321", messages[2].Recipient)
+	assert.Equal(t, string(sub.Delivered), deliveries.deliveries[3].Status)
+}
+
+func TestRateLimiterWindow(t *testing.T) {
+	limiter := newRateLimiter(2, time.Minute)
+	now := time.Now()
+
+	assert.True(t, limiter.allow("a", now))
+	assert.True(t, limiter.allow("a", now.Add(time.Second)))
+	assert.False(t, limiter.allow("a", now.Add(2*time.Second)))
+
+	// Other keys are limited separately
+	assert.True(t, limiter.allow("b", now))
+
+	// The first event falls out of the window
+	assert.True(t, limiter.allow("a", now.Add(time.Minute+time.Millisecond)))
+	assert.False(t, limiter.allow("a", now.Add(time.Minute+2*time.Millisecond)))
+
+	// A limit of 0 disables the limiter
+	assert.True(t, newRateLimiter(0, time.Minute).allow("a", now))
+}
+
+func TestHTTPSMSGateway(t *testing.T) {
+	var received map[string]string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+		assert.Nil(t, json.NewDecoder(r.Body).Decode(&received))
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	gateway := NewHTTPSMSGateway(Config{
+		"url":            server.URL,
+		"headers":        map[string]interface{}{"Authorization": "Bearer token"},
+		"recipientField": "msisdn",
+	})
+
+	assert.Nil(t, gateway.Send(context.Background(), "+4712345678", "Hello"))
+	assert.Equal(t, map[string]string{"msisdn": "+4712345678", "message": "Hello"}, received)
+}
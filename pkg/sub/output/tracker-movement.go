@@ -1,6 +1,8 @@
 package output
 
 import (
+	"time"
+
 	"github.com/eesrc/geo/pkg/model"
 	"github.com/eesrc/geo/pkg/sub"
 )
@@ -11,16 +13,93 @@ type TrackerMovement struct {
 	trackerID      int64
 	shapeID        int64
 	lastPositionID int64
+	// lastTimestamp is the timestamp of the last position
+	lastTimestamp int64
+	// entered is the timestamp of the position where the tracker entered the shape
+	entered int64
+	// dwelled is set when the dwell movement has been registered for the current visit
+	dwelled bool
+	// pendingPositions is the number of consecutive positions confirming an enter or exit
+	pendingPositions int64
+	// pendingSince is the timestamp of the first position confirming an enter or exit
+	pendingSince int64
+	// direction is the direction a tripwire was crossed in. It's only set for crossed movements
+	direction string
+}
+
+// newTrackerMovement returns a TrackerMovement for a tracker which is seen inside the shape for the
+// first time. The tracker is outside the shape until the criteria confirms that it has entered.
+func newTrackerMovement(shapeID int64, position model.Position, criteria triggerCriteria) *TrackerMovement {
+	trackerMovement := &TrackerMovement{
+		shapeID:        shapeID,
+		trackerID:      position.TrackerID,
+		lastPositionID: position.ID,
+		lastTimestamp:  position.Timestamp,
+		lastMovements:  sub.MovementList{sub.Outside},
+	}
+
+	trackerMovement.Update(true, position, criteria)
+
+	return trackerMovement
 }
 
-// Update updates the shapeMovement based on inside param and position
-func (trackerMovement *TrackerMovement) Update(inside bool, position model.Position) {
+// Update updates the shapeMovement based on inside param and position. Entering or exiting the
+// shape is held back until it's confirmed by the criteria. A dwell movement is added once the
+// tracker has been inside the shape for the dwell time of the criteria.
+func (trackerMovement *TrackerMovement) Update(inside bool, position model.Position, criteria triggerCriteria) {
+	inside = trackerMovement.confirmTransition(inside, position, criteria)
+
 	// Get diff
 	newMovements := trackerMovement.DiffMovement(inside)
 
+	switch {
+	case !inside:
+		trackerMovement.entered = 0
+		trackerMovement.dwelled = false
+	case newMovements.Contains(sub.Entered), trackerMovement.entered == 0:
+		// Movements stored before entry timestamps were kept start counting from now
+		trackerMovement.entered = position.Timestamp
+		trackerMovement.dwelled = false
+	}
+
+	if inside && criteria.dwellTime > 0 && !trackerMovement.dwelled &&
+		time.Duration(position.Timestamp-trackerMovement.entered) >= criteria.dwellTime {
+		newMovements = append(sub.MovementList{sub.Dwell}, newMovements...)
+		trackerMovement.dwelled = true
+	}
+
 	// Update model
 	trackerMovement.lastMovements = newMovements
 	trackerMovement.lastPositionID = position.ID
+	trackerMovement.lastTimestamp = position.Timestamp
+}
+
+// confirmTransition returns whether the tracker should be regarded as inside the shape. A position
+// on the other side of the edge only changes this once enough consecutive positions beyond the
+// boundary buffer have been seen over the confirm time. Positions within the buffer restart the count.
+func (trackerMovement *TrackerMovement) confirmTransition(inside bool, position model.Position, criteria triggerCriteria) bool {
+	wasInside := trackerMovement.lastMovements.Contains(sub.Inside)
+
+	if inside == wasInside || !criteria.confirmsTransition(trackerMovement.shapeID, position) {
+		trackerMovement.pendingPositions = 0
+		trackerMovement.pendingSince = 0
+		return wasInside
+	}
+
+	if trackerMovement.pendingPositions == 0 {
+		trackerMovement.pendingSince = position.Timestamp
+	}
+	trackerMovement.pendingPositions++
+
+	if trackerMovement.pendingPositions < criteria.confirmPositions ||
+		time.Duration(position.Timestamp-trackerMovement.pendingSince) < criteria.confirmTime {
+		return wasInside
+	}
+
+	trackerMovement.pendingPositions = 0
+	trackerMovement.pendingSince = 0
+
+	return inside
 }
 
 // DiffMovement returns a MovementTypes based on param which represents that
@@ -45,9 +124,12 @@ func (trackerMovement *TrackerMovement) DiffMovement(inside bool) sub.MovementLi
 func NewTrackerMovementFromModel(movement *model.TrackerMovement) *TrackerMovement {
 	var trackerMovement = TrackerMovement{
 		lastPositionID: movement.PositionID,
+		lastTimestamp:  movement.Timestamp,
 		lastMovements:  sub.NewMovementTypeFromModel(movement.Movements),
 		shapeID:        movement.ShapeID,
 		trackerID:      movement.TrackerID,
+		entered:        movement.Entered,
+		dwelled:        movement.Dwelled,
 	}
 
 	return &trackerMovement
@@ -0,0 +1,253 @@
+package output
+
+import (
+	"testing"
+	"time"
+
+	"github.com/eesrc/geo/pkg/model"
+	"github.com/eesrc/geo/pkg/sub"
+	"github.com/eesrc/geo/pkg/tria/geometry"
+	"github.com/eesrc/geo/pkg/tria/index"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func positionAt(id int64, timestamp time.Time) model.Position {
+	return model.Position{ID: id, TrackerID: 1, Timestamp: timestamp.UnixNano()}
+}
+
+func TestDwellMovement(t *testing.T) {
+	criteria := triggerCriteria{dwellTime: 15 * time.Minute}
+	start := time.Now()
+	shapes := []geometry.Shape{&geometry.Circle{ID: 1}}
+
+	movementIndex := newMovementIndex()
+
+	movements := movementIndex.setAndDiffMovement(positionAt(1, start), shapes, criteria)
+	assert.Equal(t, sub.MovementList{sub.Entered, sub.Inside}, movements[0].lastMovements)
+
+	movements = movementIndex.setAndDiffMovement(positionAt(2, start.Add(10*time.Minute)), shapes, criteria)
+	assert.Equal(t, sub.MovementList{sub.Inside}, movements[0].lastMovements)
+
+	movements = movementIndex.setAndDiffMovement(positionAt(3, start.Add(15*time.Minute)), shapes, criteria)
+	assert.Equal(t, sub.MovementList{sub.Dwell, sub.Inside}, movements[0].lastMovements)
+
+	// Dwell only fires once per visit
+	movements = movementIndex.setAndDiffMovement(positionAt(4, start.Add(30*time.Minute)), shapes, criteria)
+	assert.Equal(t, sub.MovementList{sub.Inside}, movements[0].lastMovements)
+
+	// Leaving and entering again restarts the dwell time
+	movements = movementIndex.setAndDiffMovement(positionAt(5, start.Add(31*time.Minute)), []geometry.Shape{}, criteria)
+	assert.Equal(t, sub.MovementList{sub.Exited, sub.Outside}, movements[0].lastMovements)
+
+	movementIndex.setAndDiffMovement(positionAt(6, start.Add(32*time.Minute)), shapes, criteria)
+	movements = movementIndex.setAndDiffMovement(positionAt(7, start.Add(46*time.Minute)), shapes, criteria)
+	assert.Equal(t, sub.MovementList{sub.Inside}, movements[0].lastMovements)
+
+	movements = movementIndex.setAndDiffMovement(positionAt(8, start.Add(47*time.Minute)), shapes, criteria)
+	assert.Equal(t, sub.MovementList{sub.Dwell, sub.Inside}, movements[0].lastMovements)
+}
+
+func TestDwellMovementSurvivesRestart(t *testing.T) {
+	criteria := triggerCriteria{dwellTime: 15 * time.Minute}
+	start := time.Now()
+	shapes := []geometry.Shape{&geometry.Circle{ID: 1}}
+
+	movementIndex := newMovementIndex()
+	movements := movementIndex.setAndDiffMovement(positionAt(1, start), shapes, criteria)
+
+	// Restore the movement index from the stored movement as done on startup
+	restoredIndex := newMovementIndex()
+	restoredIndex.addMovements(NewTrackerMovementListFromModel([]model.TrackerMovement{
+		{
+			TrackerID:  1,
+			ShapeID:    1,
+			PositionID: 1,
+			Movements:  movements[0].lastMovements.ToModel(),
+			Entered:    movements[0].entered,
+			Dwelled:    movements[0].dwelled,
+		},
+	}))
+
+	movements = restoredIndex.setAndDiffMovement(positionAt(2, start.Add(16*time.Minute)), shapes, criteria)
+	assert.Equal(t, sub.MovementList{sub.Dwell, sub.Inside}, movements[0].lastMovements)
+	assert.True(t, movements[0].dwelled)
+}
+
+func TestAddMovementsReplacesShapeMovement(t *testing.T) {
+	movementIndex := newMovementIndex()
+
+	movementIndex.addMovements(NewTrackerMovementListFromModel([]model.TrackerMovement{
+		{TrackerID: 1, ShapeID: 1, PositionID: 1, Movements: model.MovementList{"entered", "inside"}},
+		{TrackerID: 1, ShapeID: 1, PositionID: 2, Movements: model.MovementList{"exited", "outside"}},
+	}))
+
+	assert.Len(t, movementIndex.trackers[1], 1)
+	assert.Equal(t, int64(2), movementIndex.trackers[1][0].lastPositionID)
+}
+
+func TestConfirmPositions(t *testing.T) {
+	criteria := triggerCriteria{confirmPositions: 3}
+	start := time.Now()
+	shapes := []geometry.Shape{&geometry.Circle{ID: 1}}
+	outside := []geometry.Shape{}
+
+	movementIndex := newMovementIndex()
+
+	// Jitter along the edge doesn't enter the shape
+	movements := movementIndex.setAndDiffMovement(positionAt(1, start), shapes, criteria)
+	assert.Equal(t, sub.MovementList{sub.Outside}, movements[0].lastMovements)
+	movements = movementIndex.setAndDiffMovement(positionAt(2, start), shapes, criteria)
+	assert.Equal(t, sub.MovementList{sub.Outside}, movements[0].lastMovements)
+	movements = movementIndex.setAndDiffMovement(positionAt(3, start), outside, criteria)
+	assert.Equal(t, sub.MovementList{sub.Outside}, movements[0].lastMovements)
+
+	// Three consecutive positions inside enters the shape
+	for i := int64(4); i < 6; i++ {
+		movements = movementIndex.setAndDiffMovement(positionAt(i, start), shapes, criteria)
+		assert.Equal(t, sub.MovementList{sub.Outside}, movements[0].lastMovements)
+	}
+	movements = movementIndex.setAndDiffMovement(positionAt(6, start), shapes, criteria)
+	assert.Equal(t, sub.MovementList{sub.Entered, sub.Inside}, movements[0].lastMovements)
+
+	// The same goes for exiting
+	movements = movementIndex.setAndDiffMovement(positionAt(7, start), outside, criteria)
+	assert.Equal(t, sub.MovementList{sub.Inside}, movements[0].lastMovements)
+	movements = movementIndex.setAndDiffMovement(positionAt(8, start), outside, criteria)
+	assert.Equal(t, sub.MovementList{sub.Inside}, movements[0].lastMovements)
+	movements = movementIndex.setAndDiffMovement(positionAt(9, start), outside, criteria)
+	assert.Equal(t, sub.MovementList{sub.Exited, sub.Outside}, movements[0].lastMovements)
+}
+
+func TestConfirmTime(t *testing.T) {
+	criteria := triggerCriteria{confirmTime: time.Minute}
+	start := time.Now()
+	shapes := []geometry.Shape{&geometry.Circle{ID: 1}}
+
+	movementIndex := newMovementIndex()
+
+	movements := movementIndex.setAndDiffMovement(positionAt(1, start), shapes, criteria)
+	assert.Equal(t, sub.MovementList{sub.Outside}, movements[0].lastMovements)
+	movements = movementIndex.setAndDiffMovement(positionAt(2, start.Add(30*time.Second)), shapes, criteria)
+	assert.Equal(t, sub.MovementList{sub.Outside}, movements[0].lastMovements)
+	movements = movementIndex.setAndDiffMovement(positionAt(3, start.Add(time.Minute)), shapes, criteria)
+	assert.Equal(t, sub.MovementList{sub.Entered, sub.Inside}, movements[0].lastMovements)
+}
+
+func TestOutOfOrderPosition(t *testing.T) {
+	criteria := triggerCriteria{}
+	start := time.Now()
+	shapes := []geometry.Shape{&geometry.Circle{ID: 1}}
+
+	movementIndex := newMovementIndex()
+
+	movements := movementIndex.setAndDiffMovement(positionAt(1, start), shapes, criteria)
+	assert.Equal(t, sub.MovementList{sub.Entered, sub.Inside}, movements[0].lastMovements)
+
+	// A delayed position from before the tracker entered must not make it exit and enter again
+	movements = movementIndex.setAndDiffMovement(positionAt(2, start.Add(-time.Minute)), []geometry.Shape{}, criteria)
+	assert.Empty(t, movements)
+
+	movements = movementIndex.setAndDiffMovement(positionAt(3, start.Add(time.Minute)), shapes, criteria)
+	assert.Equal(t, sub.MovementList{sub.Inside}, movements[0].lastMovements)
+
+	// Positions are ordered per tracker
+	other := positionAt(4, start.Add(-time.Hour))
+	other.TrackerID = 2
+	movements = movementIndex.setAndDiffMovement(other, shapes, criteria)
+	assert.Equal(t, sub.MovementList{sub.Entered, sub.Inside}, movements[0].lastMovements)
+}
+
+func TestOutOfOrderPositionAfterRestart(t *testing.T) {
+	criteria := triggerCriteria{}
+	start := time.Now()
+	shapes := []geometry.Shape{&geometry.Circle{ID: 1}}
+
+	// Restore the movement index from the stored movement as done on startup
+	movementIndex := newMovementIndex()
+	movementIndex.addMovements(NewTrackerMovementListFromModel([]model.TrackerMovement{
+		{
+			TrackerID:  1,
+			ShapeID:    1,
+			PositionID: 1,
+			Movements:  model.MovementList{"entered", "inside"},
+			Timestamp:  start.UnixNano(),
+		},
+	}))
+
+	// A delayed position from before the stored movement is dropped like before the restart
+	movements := movementIndex.setAndDiffMovement(positionAt(2, start.Add(-time.Minute)), []geometry.Shape{}, criteria)
+	assert.Empty(t, movements)
+
+	movements = movementIndex.setAndDiffMovement(positionAt(3, start.Add(time.Minute)), shapes, criteria)
+	assert.Equal(t, sub.MovementList{sub.Inside}, movements[0].lastMovements)
+}
+
+func TestBoundaryBuffer(t *testing.T) {
+	// A circle reaching 0.01 degrees along the equator
+	circle := &geometry.Circle{ID: 1, Origo: geometry.Point{X: 0, Y: 0}, Radius: 1112}
+	shapeIndex := index.NewSimpleIndex()
+	shapeIndex.AddShape(circle)
+
+	geoSubscription := &GeoSubscription{
+		Subscription: model.Subscription{BoundaryBuffer: 100},
+		Index:        shapeIndex,
+	}
+	criteria := newTriggerCriteria(geoSubscription)
+
+	positionAtLon := func(id int64, lon float64) model.Position {
+		return model.Position{ID: id, TrackerID: 1, Lon: lon, Timestamp: time.Now().UnixNano()}
+	}
+
+	movementIndex := newMovementIndex()
+
+	// About 55 metres inside the edge isn't far enough to enter
+	movements := movementIndex.setAndDiffMovement(positionAtLon(1, 0.0095), geoSubscription.FindShapesWhichContainsPoint(positionAtLon(1, 0.0095)), criteria)
+	assert.Equal(t, sub.MovementList{sub.Outside}, movements[0].lastMovements)
+
+	// About 222 metres inside is
+	movements = movementIndex.setAndDiffMovement(positionAtLon(2, 0.008), geoSubscription.FindShapesWhichContainsPoint(positionAtLon(2, 0.008)), criteria)
+	assert.Equal(t, sub.MovementList{sub.Entered, sub.Inside}, movements[0].lastMovements)
+
+	// About 55 metres outside the edge isn't far enough to exit
+	movements = movementIndex.setAndDiffMovement(positionAtLon(3, 0.0105), geoSubscription.FindShapesWhichContainsPoint(positionAtLon(3, 0.0105)), criteria)
+	assert.Equal(t, sub.MovementList{sub.Inside}, movements[0].lastMovements)
+
+	// About 222 metres outside is
+	movements = movementIndex.setAndDiffMovement(positionAtLon(4, 0.012), geoSubscription.FindShapesWhichContainsPoint(positionAtLon(4, 0.012)), criteria)
+	assert.Equal(t, sub.MovementList{sub.Exited, sub.Outside}, movements[0].lastMovements)
+}
+
+func TestRouteDeviation(t *testing.T) {
+	// A 200 metres wide route along the equator
+	corridor := geometry.NewCorridorFromPoints([]geometry.Point{{X: 0, Y: 0}, {X: 0.1, Y: 0}}, 200)
+	corridor.ID = 1
+	shapeIndex := index.NewRTreeIndex()
+	shapeIndex.AddShape(&corridor)
+
+	geoSubscription := &GeoSubscription{
+		Subscription: model.Subscription{ConfirmPositions: 1},
+		Index:        shapeIndex,
+	}
+	criteria := newTriggerCriteria(geoSubscription)
+
+	positionAtLonLat := func(id int64, lon, lat float64) model.Position {
+		return model.Position{ID: id, TrackerID: 1, Lon: lon, Lat: lat, Timestamp: time.Now().UnixNano()}
+	}
+
+	movementIndex := newMovementIndex()
+
+	// Following the route
+	position := positionAtLonLat(1, 0.01, 0.0005)
+	movements := movementIndex.setAndDiffMovement(position, geoSubscription.FindShapesWhichContainsPoint(position), criteria)
+	assert.Equal(t, sub.MovementList{sub.Entered, sub.Inside}, movements[0].lastMovements)
+
+	position = positionAtLonLat(2, 0.05, -0.0005)
+	movements = movementIndex.setAndDiffMovement(position, geoSubscription.FindShapesWhichContainsPoint(position), criteria)
+	assert.Equal(t, sub.MovementList{sub.Inside}, movements[0].lastMovements)
+
+	// About 220 metres off the route
+	position = positionAtLonLat(3, 0.06, 0.002)
+	movements = movementIndex.setAndDiffMovement(position, geoSubscription.FindShapesWhichContainsPoint(position), criteria)
+	assert.Equal(t, sub.MovementList{sub.Exited, sub.Outside}, movements[0].lastMovements)
+}
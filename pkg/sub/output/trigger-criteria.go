@@ -0,0 +1,52 @@
+package output
+
+import (
+	"time"
+
+	"github.com/eesrc/geo/pkg/model"
+	"github.com/eesrc/geo/pkg/tria/geometry"
+)
+
+// triggerCriteria holds the subscription settings for when movements are registered
+type triggerCriteria struct {
+	// dwellTime is how long a tracker must be inside a shape before a dwell movement. 0 disables it.
+	dwellTime time.Duration
+	// confirmPositions is the number of consecutive positions confirming an enter or exit
+	confirmPositions int64
+	// confirmTime is the minimum time between the first and last confirming position
+	confirmTime time.Duration
+	// boundaryBuffer is the distance in metres a position must be past the edge of a shape to
+	// confirm an enter or exit
+	boundaryBuffer float64
+	// boundaryDistance returns the distance in metres from the position to the edge of the shape
+	boundaryDistance func(shapeID int64, position model.Position) float64
+}
+
+// confirmsTransition checks whether the position is far enough past the edge of the shape to
+// confirm that the tracker has entered or exited it
+func (criteria *triggerCriteria) confirmsTransition(shapeID int64, position model.Position) bool {
+	if criteria.boundaryBuffer <= 0 || criteria.boundaryDistance == nil {
+		return true
+	}
+
+	return criteria.boundaryDistance(shapeID, position) >= criteria.boundaryBuffer
+}
+
+// newTriggerCriteria returns the trigger criteria of the subscription
+func newTriggerCriteria(geoSubscription *GeoSubscription) triggerCriteria {
+	return triggerCriteria{
+		dwellTime:        geoSubscription.Subscription.DwellTime,
+		confirmPositions: geoSubscription.Subscription.ConfirmPositions,
+		confirmTime:      geoSubscription.Subscription.ConfirmTime,
+		boundaryBuffer:   geoSubscription.Subscription.BoundaryBuffer,
+		boundaryDistance: func(shapeID int64, position model.Position) float64 {
+			shape, err := getShapeByID(geoSubscription.Index, shapeID)
+			if err != nil {
+				// The shape is gone, there's no edge to keep a distance to
+				return 0
+			}
+
+			return shape.BoundaryDistance(&geometry.Point{X: position.Lon, Y: position.Lat})
+		},
+	}
+}
@@ -0,0 +1,91 @@
+package output
+
+import (
+	"sync"
+
+	"github.com/eesrc/geo/pkg/model"
+	"github.com/eesrc/geo/pkg/sub"
+	"github.com/eesrc/geo/pkg/tria/geometry"
+)
+
+// trackerPositions holds the previous position of each tracker, which is used to find the tripwires
+// crossed between two positions
+type trackerPositions struct {
+	mutex     *sync.Mutex
+	positions map[int64]model.Position
+}
+
+func newTrackerPositions() trackerPositions {
+	return trackerPositions{
+		mutex:     &sync.Mutex{},
+		positions: make(map[int64]model.Position),
+	}
+}
+
+// keepPositions copies the latest positions of the trackers from the previous tracker positions
+func (trackerPositions *trackerPositions) keepPositions(previous *trackerPositions) {
+	if previous.mutex == trackerPositions.mutex {
+		return
+	}
+
+	previous.mutex.Lock()
+	defer previous.mutex.Unlock()
+	trackerPositions.mutex.Lock()
+	defer trackerPositions.mutex.Unlock()
+
+	for trackerID, position := range previous.positions {
+		trackerPositions.positions[trackerID] = position
+	}
+}
+
+// swap stores the position as the latest position of the tracker and returns the previous position, if
+// any. Positions older than the latest position are ignored and returns no previous position.
+func (trackerPositions *trackerPositions) swap(position model.Position) (model.Position, bool) {
+	trackerPositions.mutex.Lock()
+	defer trackerPositions.mutex.Unlock()
+
+	previous, ok := trackerPositions.positions[position.TrackerID]
+	if ok && position.Timestamp < previous.Timestamp {
+		return model.Position{}, false
+	}
+
+	trackerPositions.positions[position.TrackerID] = position
+
+	return previous, ok
+}
+
+// DiffCrossings stores the position as the latest position of the tracker and returns a crossed movement
+// for each tripwire crossed since the previous position of the tracker
+func (geoSubscription *GeoSubscription) DiffCrossings(position model.Position) []*TrackerMovement {
+	crossings := make([]*TrackerMovement, 0)
+
+	previous, ok := geoSubscription.trackerPositions.swap(position)
+	if !ok {
+		return crossings
+	}
+
+	from := geometry.Point{X: previous.Lon, Y: previous.Lat}
+	to := geometry.Point{X: position.Lon, Y: position.Lat}
+
+	for _, shape := range geoSubscription.Index.FindShapesWhichIntersectsBoundingBox(geometry.CalculateBoundingBox([]geometry.Point{from, to})) {
+		tripwire, ok := shape.(*geometry.Tripwire)
+		if !ok {
+			continue
+		}
+
+		direction := tripwire.Crossing(&from, &to)
+		if direction == geometry.NoCrossing {
+			continue
+		}
+
+		crossings = append(crossings, &TrackerMovement{
+			lastMovements:  sub.MovementList{sub.Crossed},
+			trackerID:      position.TrackerID,
+			shapeID:        tripwire.GetID(),
+			lastPositionID: position.ID,
+			direction:      string(direction),
+		})
+	}
+
+	return crossings
+}
@@ -0,0 +1,55 @@
+package output
+
+import (
+	"testing"
+
+	"github.com/eesrc/geo/pkg/model"
+	"github.com/eesrc/geo/pkg/sub"
+	"github.com/eesrc/geo/pkg/tria/geometry"
+	"github.com/eesrc/geo/pkg/tria/index"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffCrossings(t *testing.T) {
+	// A gate heading north along the prime meridian, and a corridor which is never crossed
+	tripwire := geometry.NewTripwireFromPoints([]geometry.Point{{X: 0, Y: 0}, {X: 0, Y: 0.01}})
+	tripwire.ID = 1
+	corridor := geometry.NewCorridorFromPoints([]geometry.Point{{X: -0.01, Y: 0.005}, {X: 0.01, Y: 0.005}}, 100)
+	corridor.ID = 2
+
+	shapeIndex := index.NewRTreeIndex()
+	shapeIndex.AddShape(&tripwire)
+	shapeIndex.AddShape(&corridor)
+
+	geoSubscription := &GeoSubscription{
+		Index:            shapeIndex,
+		trackerPositions: newTrackerPositions(),
+	}
+
+	position := func(id, trackerID, timestamp int64, lon float64) model.Position {
+		return model.Position{ID: id, TrackerID: trackerID, Lon: lon, Lat: 0.005, Timestamp: timestamp}
+	}
+
+	// The first position of a tracker has nothing to compare with
+	assert.Empty(t, geoSubscription.DiffCrossings(position(1, 1, 1, -0.001)))
+	assert.Empty(t, geoSubscription.DiffCrossings(position(2, 2, 1, 0.001)))
+
+	// Tracker 1 walks from the left to the right side of the gate
+	crossings := geoSubscription.DiffCrossings(position(3, 1, 2, 0.001))
+	assert.Len(t, crossings, 1)
+	assert.Equal(t, sub.MovementList{sub.Crossed}, crossings[0].lastMovements)
+	assert.Equal(t, int64(1), crossings[0].trackerID)
+	assert.Equal(t, int64(1), crossings[0].shapeID)
+	assert.Equal(t, int64(3), crossings[0].lastPositionID)
+	assert.Equal(t, string(geometry.LeftToRight), crossings[0].direction)
+
+	// Tracker 2 keeps its own previous position and walks back to the left side
+	crossings = geoSubscription.DiffCrossings(position(4, 2, 2, -0.001))
+	assert.Len(t, crossings, 1)
+	assert.Equal(t, int64(2), crossings[0].trackerID)
+	assert.Equal(t, string(geometry.RightToLeft), crossings[0].direction)
+
+	// An older position of tracker 1 is ignored, and doesn't replace the previous position
+	assert.Empty(t, geoSubscription.DiffCrossings(position(5, 1, 1, -0.001)))
+	assert.Empty(t, geoSubscription.DiffCrossings(position(6, 1, 3, 0.002)))
+}
@@ -0,0 +1,385 @@
+package output
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/eesrc/geo/pkg/model"
+	"github.com/eesrc/geo/pkg/sub/manager/event"
+	"github.com/eesrc/geo/pkg/sub/manager/topic"
+	"github.com/eesrc/geo/pkg/webhook"
+)
+
+const (
+	// defaultWebhookTimeout is the default timeout in milliseconds for a single webhook request
+	defaultWebhookTimeout = 5000
+	// defaultWebhookRetries is the default number of retries before a webhook request is discarded
+	defaultWebhookRetries = 3
+	// defaultWebhookBackoff is the default initial backoff in milliseconds between retries. The backoff
+	// is doubled for each retry.
+	defaultWebhookBackoff = 500
+	// defaultWebhookBatchSize is the default max number of trigger events sent in a single request
+	defaultWebhookBatchSize = 50
+)
+
+// WebhookOutput is an output which POSTs the subscription triggers as a JSON list to a configured URL.
+//
+// The output is configured through the subscription output config with the following keys:
+//
+//	url       - The URL to POST the triggers to (required)
+//	headers   - A map of custom headers added to each request
+//	timeout   - The timeout of a single request in milliseconds
+//	retries   - The number of retries for a failing request
+//	backoff   - The initial backoff between retries in milliseconds, doubled for each retry
+//	batchSize - The max number of trigger events in a single request
+//	secret    - The secret used to sign each request with HMAC-SHA256. Requests are unsigned if empty
+//
+// Signed requests carry the signature and signing time in the webhook.SignatureHeader and
+// webhook.TimestampHeader headers. Receivers can verify them using the webhook package.
+type WebhookOutput struct {
+	name            string
+	nReceived       int32
+	terminate       chan bool
+	done            chan bool
+	stopOnce        sync.Once
+	mutex           sync.Mutex
+	ctx             context.Context
+	cancel          context.CancelFunc
+	client          *http.Client
+	geoSubscription GeoSubscription
+	config          Config
+	eventCallback   func(topic topic.Topic, event event.PublishableEvent)
+	outbox          outbox
+
+	url       string
+	headers   map[string]string
+	secret    string
+	retries   int64
+	backoff   time.Duration
+	batchSize int64
+}
+
+func (webhookOutput *WebhookOutput) messageReader(ctx context.Context, receiver <-chan interface{}) {
+	defer close(webhookOutput.done)
+
+	for {
+		select {
+		case <-webhookOutput.terminate:
+			// Attempt to deliver whatever is left in the queue before stopping. Once the stop
+			// timeout cancels the context the remaining trigger events end up as dead letters.
+			for {
+				select {
+				case msg, ok := <-receiver:
+					if !ok {
+						return
+					}
+					webhookOutput.deliver(ctx, readOutputPayloads(&webhookOutput.geoSubscription, msg, receiver))
+				default:
+					return
+				}
+			}
+		case msg, ok := <-receiver:
+			if !ok {
+				return
+			}
+
+			atomic.AddInt32(&webhookOutput.nReceived, 1)
+			webhookOutput.deliver(ctx, readOutputPayloads(&webhookOutput.geoSubscription, msg, receiver))
+		}
+	}
+}
+
+// deliver creates trigger events of the payloads and POSTs them in batches to the webhook URL. Each
+// trigger event is added to the outbox and marked according to the outcome of its batch.
+func (webhookOutput *WebhookOutput) deliver(ctx context.Context, payloads []outputPayload) {
+	triggerEvents := webhookOutput.triggerEvents(payloads)
+
+	deliveries := make([]*model.Delivery, len(triggerEvents))
+	for i, triggerEvent := range triggerEvents {
+		deliveries[i] = webhookOutput.outbox.add(triggerEvent)
+	}
+
+	for start := 0; start < len(triggerEvents); start += int(webhookOutput.batchSize) {
+		end := start + int(webhookOutput.batchSize)
+		if end > len(triggerEvents) {
+			end = len(triggerEvents)
+		}
+
+		body, err := json.Marshal(triggerEvents[start:end])
+		if err != nil {
+			log.WithError(err).Errorf("Failed to marshal webhook payload for subscription %d", webhookOutput.geoSubscription.Subscription.ID)
+			webhookOutput.outbox.failed(err, deliveries[start:end]...)
+			continue
+		}
+
+		err = webhookOutput.post(ctx, body)
+		if err != nil {
+			log.WithError(err).Errorf(
+				"Failed to deliver %d trigger event(s) for subscription %d to webhook. Moving to dead letters",
+				end-start,
+				webhookOutput.geoSubscription.Subscription.ID,
+			)
+			webhookOutput.outbox.failed(err, deliveries[start:end]...)
+			continue
+		}
+
+		webhookOutput.outbox.delivered(deliveries[start:end]...)
+	}
+}
+
+// triggerEvents returns the subscription events of the payloads matching the subscription movements.
+// Each trigger event is also published on the subscription trigger topic.
+func (webhookOutput *WebhookOutput) triggerEvents(payloads []outputPayload) []*event.SubscriptionEvent {
+	triggerEvents := make([]*event.SubscriptionEvent, 0)
+
+	for _, payload := range payloads {
+		for _, movement := range payload.movements {
+			if !webhookOutput.geoSubscription.ContainsAnyMovements(movement.lastMovements) {
+				continue
+			}
+
+			subscriptionEvent := event.NewSubscriptionEvent(
+				webhookOutput.geoSubscription.Subscription.ID,
+				payload.position,
+				event.TriggerDetails{
+					Movements:         movement.lastMovements.ToStringSlice(),
+					ShapecollectionID: webhookOutput.geoSubscription.Subscription.ShapeCollectionID,
+					ShapeID:           movement.shapeID,
+					Direction:         movement.direction,
+				},
+			)
+
+			// Publish trigger event
+			webhookOutput.eventCallback(
+				topic.NewEntityTopic(topic.Subscription, webhookOutput.geoSubscription.Subscription.ID, topic.TriggerEvents),
+				subscriptionEvent,
+			)
+
+			triggerEvents = append(triggerEvents, subscriptionEvent)
+		}
+	}
+
+	return triggerEvents
+}
+
+// post sends the body to the webhook URL, retrying with an exponential backoff if the request fails
+func (webhookOutput *WebhookOutput) post(ctx context.Context, body []byte) error {
+	backoff := webhookOutput.backoff
+
+	for attempt := int64(0); ; attempt++ {
+		retry, err := webhookOutput.send(ctx, body)
+		if err == nil {
+			return nil
+		}
+
+		if !retry || attempt >= webhookOutput.retries {
+			return err
+		}
+
+		log.WithError(err).Warnf("Webhook request for subscription %d failed, retrying in %s", webhookOutput.geoSubscription.Subscription.ID, backoff)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+
+		backoff *= 2
+	}
+}
+
+// send performs a single POST request of body to the webhook URL. Returns whether the request
+// should be retried along with an error if the request failed.
+func (webhookOutput *WebhookOutput) send(ctx context.Context, body []byte) (bool, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, webhookOutput.url, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+
+	request.Header.Set("Content-Type", "application/json")
+	for key, value := range webhookOutput.headers {
+		request.Header.Set(key, value)
+	}
+
+	// Sign each attempt separately so retries don't end up outside the receivers replay window
+	if webhookOutput.secret != "" {
+		now := time.Now()
+		request.Header.Set(webhook.TimestampHeader, strconv.FormatInt(now.Unix(), 10))
+		request.Header.Set(webhook.SignatureHeader, webhook.Sign(webhookOutput.secret, now, body))
+	}
+
+	response, err := webhookOutput.client.Do(request)
+	if err != nil {
+		return ctx.Err() == nil, err
+	}
+	defer response.Body.Close()
+
+	// Drain the body so the connection can be reused
+	_, _ = io.Copy(ioutil.Discard, response.Body)
+
+	if response.StatusCode >= 200 && response.StatusCode < 300 {
+		return false, nil
+	}
+
+	retry := response.StatusCode >= 500 || response.StatusCode == http.StatusTooManyRequests
+	return retry, fmt.Errorf("Webhook responded with status %d", response.StatusCode)
+}
+
+// Start initiate start of the webhook output
+func (webhookOutput *WebhookOutput) Start(config Config, message <-chan interface{}) {
+	webhookOutput.mutex.Lock()
+	defer webhookOutput.mutex.Unlock()
+
+	webhookOutput.config = config
+	webhookOutput.url = config.GetStringWithDefault("url", "")
+	webhookOutput.headers = config.GetStringMapWithDefault("headers", map[string]string{})
+	webhookOutput.secret = config.GetStringWithDefault("secret", "")
+	webhookOutput.retries = config.GetIntWithDefault("retries", defaultWebhookRetries)
+	webhookOutput.backoff = time.Duration(config.GetIntWithDefault("backoff", defaultWebhookBackoff)) * time.Millisecond
+	webhookOutput.batchSize = config.GetIntWithDefault("batchSize", defaultWebhookBatchSize)
+	webhookOutput.client = &http.Client{
+		Timeout: time.Duration(config.GetIntWithDefault("timeout", defaultWebhookTimeout)) * time.Millisecond,
+	}
+
+	if webhookOutput.batchSize < 1 {
+		webhookOutput.batchSize = defaultWebhookBatchSize
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	webhookOutput.ctx = ctx
+	webhookOutput.cancel = cancel
+
+	go webhookOutput.messageReader(ctx, message)
+}
+
+// Stop initiate a stop of the webhook output. Pending requests are given until the timeout
+// to complete before they are cancelled.
+func (webhookOutput *WebhookOutput) Stop(timeout time.Duration) {
+	webhookOutput.mutex.Lock()
+	defer webhookOutput.mutex.Unlock()
+
+	if webhookOutput.cancel == nil {
+		return
+	}
+
+	webhookOutput.stopOnce.Do(func() {
+		close(webhookOutput.terminate)
+	})
+
+	select {
+	case <-webhookOutput.done:
+	case <-time.After(timeout):
+		webhookOutput.cancel()
+		<-webhookOutput.done
+	}
+
+	webhookOutput.cancel()
+}
+
+// Redeliver POSTs the trigger event of the delivery to the webhook URL
+func (webhookOutput *WebhookOutput) Redeliver(delivery *model.Delivery) error {
+	webhookOutput.mutex.Lock()
+	ctx := webhookOutput.ctx
+	webhookOutput.mutex.Unlock()
+
+	if ctx == nil {
+		return errors.New("The webhook output isn't started")
+	}
+
+	// The webhook receives a list of trigger events
+	body := append(append([]byte("["), delivery.Payload...), ']')
+
+	err := webhookOutput.post(ctx, body)
+	if err != nil {
+		webhookOutput.outbox.failed(err, delivery)
+		return nil
+	}
+
+	webhookOutput.outbox.delivered(delivery)
+
+	return nil
+}
+
+// Validate validates a webhook configuration
+func (webhookOutput *WebhookOutput) Validate(config Config) error {
+	err := validateHTTPURL("webhook", config.GetStringWithDefault("url", ""))
+	if err != nil {
+		return err
+	}
+
+	if secret, ok := config["secret"]; ok && secret != nil {
+		if _, ok := secret.(string); !ok {
+			return errors.New("The webhook secret must be a string")
+		}
+	}
+
+	if config.GetIntWithDefault("timeout", defaultWebhookTimeout) < 1 {
+		return errors.New("The webhook timeout must be above 0")
+	}
+
+	if config.GetIntWithDefault("retries", defaultWebhookRetries) < 0 {
+		return errors.New("The webhook retries can't be negative")
+	}
+
+	if config.GetIntWithDefault("backoff", defaultWebhookBackoff) < 0 {
+		return errors.New("The webhook backoff can't be negative")
+	}
+
+	if config.GetIntWithDefault("batchSize", defaultWebhookBatchSize) < 1 {
+		return errors.New("The webhook batchSize must be above 0")
+	}
+
+	return nil
+}
+
+// validateHTTPURL validates that rawURL is an absolute http or https URL. The name is used
+// to describe the owner of the URL in the returned error.
+func validateHTTPURL(name string, rawURL string) error {
+	if rawURL == "" {
+		return fmt.Errorf("The %s output needs an url", name)
+	}
+
+	parsedURL, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("The %s url '%s' is not a valid url", name, rawURL)
+	}
+
+	if parsedURL.Scheme != "http" && parsedURL.Scheme != "https" {
+		return fmt.Errorf("The %s url '%s' must use http or https", name, rawURL)
+	}
+
+	if parsedURL.Host == "" {
+		return fmt.Errorf("The %s url '%s' is missing a host", name, rawURL)
+	}
+
+	return nil
+}
+
+// NewWebhookOutput creates a new webhook output
+func NewWebhookOutput(geoSubscription GeoSubscription, eventCallback func(topic topic.Topic, event event.PublishableEvent)) *WebhookOutput {
+	config := Config(geoSubscription.Subscription.OutputConfig)
+
+	name := config.GetStringWithDefault("name", "Webhook output")
+
+	return &WebhookOutput{
+		name:            name,
+		terminate:       make(chan bool),
+		done:            make(chan bool),
+		geoSubscription: geoSubscription,
+		eventCallback:   eventCallback,
+		outbox:          newOutbox(geoSubscription),
+	}
+}
@@ -0,0 +1,185 @@
+package output
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/eesrc/geo/pkg/model"
+	"github.com/eesrc/geo/pkg/sub"
+	"github.com/eesrc/geo/pkg/sub/manager/event"
+	"github.com/eesrc/geo/pkg/sub/manager/topic"
+	"github.com/eesrc/geo/pkg/webhook"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebhookValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  Config
+		wantErr bool
+	}{
+		{name: "valid url", config: Config{"url": "https://example.com/hook"}, wantErr: false},
+		{name: "missing url", config: Config{}, wantErr: true},
+		{name: "non http scheme", config: Config{"url": "ftp://example.com/hook"}, wantErr: true},
+		{name: "missing host", config: Config{"url": "http:///hook"}, wantErr: true},
+		{name: "malformed url", config: Config{"url": "http://[::1"}, wantErr: true},
+		{name: "negative retries", config: Config{"url": "http://example.com", "retries": float64(-1)}, wantErr: true},
+		{name: "non string secret", config: Config{"url": "http://example.com", "secret": float64(1)}, wantErr: true},
+		{name: "zero batch size", config: Config{"url": "http://example.com", "batchSize": float64(0)}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := (&WebhookOutput{}).Validate(tt.config)
+			assert.Equal(t, tt.wantErr, err != nil, err)
+		})
+	}
+}
+
+func newTestWebhookOutput(config Config) *WebhookOutput {
+	webhookOutput := NewWebhookOutput(GeoSubscription{
+		Subscription: model.Subscription{
+			ID:           1,
+			OutputConfig: model.OutputConfig(config),
+			Types:        model.MovementList{string(sub.Entered)},
+		},
+	}, func(topic topic.Topic, event event.PublishableEvent) {})
+
+	webhookOutput.Start(config, make(chan interface{}))
+
+	return webhookOutput
+}
+
+func TestWebhookRetriesAndBatching(t *testing.T) {
+	var requests int32
+	var received int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "bar", r.Header.Get("X-Foo"))
+
+		// Fail the first request to trigger a retry
+		if atomic.AddInt32(&requests, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		var triggerEvents []event.SubscriptionEvent
+		assert.Nil(t, json.NewDecoder(r.Body).Decode(&triggerEvents))
+		atomic.AddInt32(&received, int32(len(triggerEvents)))
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	webhookOutput := newTestWebhookOutput(Config{
+		"url":       server.URL,
+		"headers":   map[string]interface{}{"X-Foo": "bar"},
+		"backoff":   float64(1),
+		"batchSize": float64(2),
+	})
+	defer webhookOutput.Stop(time.Second)
+
+	payloads := make([]outputPayload, 0)
+	for i := int64(0); i < 3; i++ {
+		payloads = append(payloads, outputPayload{
+			position: model.Position{ID: i, TrackerID: 1},
+			movements: []*TrackerMovement{
+				{shapeID: 1, trackerID: 1, lastMovements: sub.MovementList{sub.Entered, sub.Inside}},
+				{shapeID: 2, trackerID: 1, lastMovements: sub.MovementList{sub.Inside}},
+			},
+		})
+	}
+
+	webhookOutput.deliver(context.Background(), payloads)
+
+	// 3 matching trigger events in batches of 2 with one failed request
+	assert.Equal(t, int32(3), atomic.LoadInt32(&requests))
+	assert.Equal(t, int32(3), atomic.LoadInt32(&received))
+}
+
+func TestWebhookDoesNotRetryClientErrors(t *testing.T) {
+	var requests int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	webhookOutput := newTestWebhookOutput(Config{"url": server.URL, "backoff": float64(1)})
+	defer webhookOutput.Stop(time.Second)
+
+	err := webhookOutput.post(context.Background(), []byte("[]"))
+	assert.NotNil(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&requests))
+}
+
+func TestWebhookSignsRequests(t *testing.T) {
+	var verified int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, err := webhook.VerifyRequest(r, "secret", webhook.DefaultTolerance)
+		assert.Nil(t, err)
+		if err == nil {
+			atomic.AddInt32(&verified, 1)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	webhookOutput := newTestWebhookOutput(Config{"url": server.URL, "secret": "secret"})
+	defer webhookOutput.Stop(time.Second)
+
+	assert.Nil(t, webhookOutput.post(context.Background(), []byte("[]")))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&verified))
+}
+
+func TestWebhookRedeliver(t *testing.T) {
+	var fail int32 = 1
+	var received []event.SubscriptionEvent
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.LoadInt32(&fail) == 1 {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		assert.Nil(t, json.NewDecoder(r.Body).Decode(&received))
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	webhookOutput := newTestWebhookOutput(Config{"url": server.URL, "backoff": float64(1)})
+	defer webhookOutput.Stop(time.Second)
+
+	subscriptionEvent := event.NewSubscriptionEvent(1, model.Position{TrackerID: 3}, event.TriggerDetails{ShapeID: 7})
+	delivery := webhookOutput.outbox.add(subscriptionEvent)
+	assert.Equal(t, string(sub.Pending), delivery.Status)
+
+	// Failed attempts are recorded on the delivery
+	assert.Nil(t, webhookOutput.Redeliver(delivery))
+	assert.Equal(t, string(sub.DeadLetter), delivery.Status)
+	assert.Equal(t, int64(1), delivery.Attempts)
+	assert.NotEmpty(t, delivery.LastError)
+
+	atomic.StoreInt32(&fail, 0)
+
+	assert.Nil(t, webhookOutput.Redeliver(delivery))
+	assert.Equal(t, string(sub.Delivered), delivery.Status)
+	assert.Equal(t, int64(2), delivery.Attempts)
+	assert.Empty(t, delivery.LastError)
+
+	assert.Len(t, received, 1)
+	if len(received) == 1 {
+		assert.Equal(t, int64(3), received[0].Data.Position.TrackerID)
+		assert.Equal(t, int64(7), received[0].Data.Details.ShapeID)
+	}
+
+	// Outputs which aren't started can't re-send deliveries
+	assert.NotNil(t, NewWebhookOutput(GeoSubscription{}, nil).Redeliver(delivery))
+}
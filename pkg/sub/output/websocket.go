@@ -93,6 +93,7 @@ func (websocketOutput *WebsocketOutput) publishToWebsocket(payloads []outputPayl
 							Movements:         movement.lastMovements.ToStringSlice(),
 							ShapecollectionID: websocketOutput.geoSubscription.Subscription.ShapeCollectionID,
 							ShapeID:           movement.shapeID,
+							Direction:         movement.direction,
 						},
 					),
 				)
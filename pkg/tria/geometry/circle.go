@@ -1,6 +1,11 @@
 package geometry
 
-// Circle contains information about circle
+import (
+	"math"
+)
+
+// Circle contains information about circle. The origo is given in WGS84 degrees and the
+// radius in metres.
 type Circle struct {
 	ID         int64
 	Name       string
@@ -41,22 +46,39 @@ func (circle *Circle) SetProperties(shapeProperties ShapeProperties) {
 
 // BoundingBox returns the circles BoundingBox
 func (circle *Circle) GetBoundingBox() BoundingBox {
-	return BoundingBox{
-		MinX: circle.Origo.X - circle.Radius,
-		MinY: circle.Origo.Y - circle.Radius,
-		MaxX: circle.Origo.X + circle.Radius,
-		MaxY: circle.Origo.Y + circle.Radius,
+	deltaLat := toDegrees(circle.Radius / EarthRadius)
+
+	boundingBox := BoundingBox{
+		MinX: -180,
+		MinY: math.Max(circle.Origo.Y-deltaLat, -90),
+		MaxX: 180,
+		MaxY: math.Min(circle.Origo.Y+deltaLat, 90),
+	}
+
+	// A degree of longitude shrinks towards the poles. If the circle covers a pole it spans
+	// every longitude.
+	if boundingBox.MinY > -90 && boundingBox.MaxY < 90 {
+		deltaLon := toDegrees(math.Asin(math.Sin(circle.Radius/EarthRadius) / math.Cos(toRadians(circle.Origo.Y))))
+		boundingBox.MinX = circle.Origo.X - deltaLon
+		boundingBox.MaxX = circle.Origo.X + deltaLon
 	}
+
+	return boundingBox
 }
 
 // PointInside checks if a point is inside circle
 func (circle *Circle) PointInside(point *Point) bool {
-	return circle.Origo.DistanceTo(point) <= circle.Radius
+	return HaversineDistance(&circle.Origo, point) <= circle.Radius
 }
 
 // PointInsideBoundingBox checks if a point is inside the circle
 func (circle *Circle) PointInsideBoundingBox(point *Point) bool {
-	return circle.Origo.DistanceTo(point) <= circle.Radius
+	return HaversineDistance(&circle.Origo, point) <= circle.Radius
+}
+
+// BoundaryDistance returns the distance in metres from the point to the edge of the circle
+func (circle *Circle) BoundaryDistance(point *Point) float64 {
+	return math.Abs(HaversineDistance(&circle.Origo, point) - circle.Radius)
 }
 
 // ShapeInside checks if a shape is inside the circle
@@ -66,6 +88,8 @@ func (circle *Circle) ShapeInside(shape Shape) bool {
 		return circle.PolygonInside(shape)
 	case *Circle:
 		return circle.CircleInside(shape)
+	case *MultiPolygon:
+		return circle.MultiPolygonInside(shape)
 	default:
 		return false
 	}
@@ -86,10 +110,19 @@ func (circle *Circle) PolygonInside(polygon *Polygon) bool {
 	return false
 }
 
+// MultiPolygonInside checks whether all the polygons of a multi polygon are inside the circle
+func (circle *Circle) MultiPolygonInside(multiPolygon *MultiPolygon) bool {
+	for i := range multiPolygon.Polygons {
+		if !circle.PolygonInside(&multiPolygon.Polygons[i]) {
+			return false
+		}
+	}
+	return len(multiPolygon.Polygons) > 0
+}
+
 // CircleInside checks whether a circle is inside the circle
-// Expects the Radius to be normalized according to X,Y-grid
 func (circle *Circle) CircleInside(circleCandidate *Circle) bool {
-	distOrigo := circle.Origo.DistanceTo(&circleCandidate.Origo)
+	distOrigo := HaversineDistance(&circle.Origo, &circleCandidate.Origo)
 
 	return (distOrigo + circleCandidate.Radius) <= circle.Radius
 }
@@ -1,12 +1,13 @@
 package geometry
 
 import (
+	"math"
 	"testing"
 )
 
 func TestCircle_PolygonInside(t *testing.T) {
 	simpleCircle := Circle{
-		Radius: 10,
+		Radius: 200000,
 		Origo:  Point{X: 0, Y: 0},
 	}
 
@@ -72,16 +73,16 @@ func TestCircle_PolygonInside(t *testing.T) {
 
 func TestCircle_CircleInside(t *testing.T) {
 	tinyCircle := Circle{
-		Radius: 1,
+		Radius: 20000,
 		Origo:  Point{X: 0, Y: 0},
 	}
 	simpleCircle := Circle{
-		Radius: 10,
+		Radius: 200000,
 		Origo:  Point{X: 0, Y: 0},
 	}
 	overlappingCircle := Circle{
-		Radius: 2,
-		Origo:  Point{X: 10, Y: 0},
+		Radius: 50000,
+		Origo:  Point{X: 1.5, Y: 0},
 	}
 
 	type args struct {
@@ -139,3 +140,62 @@ func TestCircle_CircleInside(t *testing.T) {
 		})
 	}
 }
+
+func TestCircle_PointInside(t *testing.T) {
+	// A circle with a radius of 600 metres in Trondheim
+	circle := Circle{
+		Radius: 600,
+		Origo:  Point{X: 10.4, Y: 63.4},
+	}
+
+	metresPerDegreeLat := EarthRadius * math.Pi / 180
+	metresPerDegreeLon := metresPerDegreeLat * math.Cos(circle.Origo.Y*math.Pi/180)
+
+	tests := []struct {
+		name  string
+		point Point
+		want  bool
+	}{
+		{name: "should return true for origo", point: circle.Origo, want: true},
+		{name: "should return true 500 metres north", point: Point{X: 10.4, Y: 63.4 + 500/metresPerDegreeLat}, want: true},
+		{name: "should return true 500 metres east", point: Point{X: 10.4 + 500/metresPerDegreeLon, Y: 63.4}, want: true},
+		{name: "should return false 700 metres north", point: Point{X: 10.4, Y: 63.4 + 700/metresPerDegreeLat}, want: false},
+		{name: "should return false 700 metres east", point: Point{X: 10.4 + 700/metresPerDegreeLon, Y: 63.4}, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := circle.PointInside(&tt.point); got != tt.want {
+				t.Errorf("Circle.PointInside() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCircle_GetBoundingBox(t *testing.T) {
+	circle := Circle{
+		Radius: 600,
+		Origo:  Point{X: 10.4, Y: 63.4},
+	}
+
+	boundingBox := circle.GetBoundingBox()
+
+	// The box should touch the circle north, south, east and west of origo
+	for _, edge := range []Point{
+		{X: circle.Origo.X, Y: boundingBox.MinY},
+		{X: circle.Origo.X, Y: boundingBox.MaxY},
+		{X: boundingBox.MinX, Y: circle.Origo.Y},
+		{X: boundingBox.MaxX, Y: circle.Origo.Y},
+	} {
+		if distance := HaversineDistance(&circle.Origo, &edge); math.Abs(distance-circle.Radius) > 0.01 {
+			t.Errorf("Circle.GetBoundingBox() edge %v is %v metres from origo, want %v", edge, distance, circle.Radius)
+		}
+	}
+
+	polarCircle := Circle{
+		Radius: 200000,
+		Origo:  Point{X: 10.4, Y: 89},
+	}
+	if polarBoundingBox := polarCircle.GetBoundingBox(); polarBoundingBox.MinX != -180 || polarBoundingBox.MaxX != 180 || polarBoundingBox.MaxY != 90 {
+		t.Errorf("Circle.GetBoundingBox() = %v, want every longitude for a circle covering the pole", polarBoundingBox)
+	}
+}
@@ -0,0 +1,157 @@
+package geometry
+
+import (
+	"math"
+)
+
+// Corridor is a buffered line, such as a road, a pipeline or a ferry route. The line is given in WGS84
+// degrees and the width in metres. A point is inside the corridor when it's within half the width of the line.
+type Corridor struct {
+	ID          int64
+	Name        string
+	Description string
+	Line        []Point
+	Width       float64
+	BoundingBox BoundingBox
+	Properties  ShapeProperties
+}
+
+// NewCorridorFromPoints Returns a new Corridor with a BoundingBox covering the line buffered by the width
+func NewCorridorFromPoints(points []Point, width float64) Corridor {
+	lineBoundingBox := CalculateBoundingBox(points)
+
+	// Widen the box by half the width, using the latitude furthest from equator for the longitude
+	deltaLat := toDegrees(width / 2 / EarthRadius)
+	maxLat := math.Min(math.Max(math.Abs(lineBoundingBox.MinY), math.Abs(lineBoundingBox.MaxY))+deltaLat, 90)
+
+	deltaLon := 180.0
+	if maxLat < 90 {
+		deltaLon = math.Min(deltaLat/math.Cos(toRadians(maxLat)), 180)
+	}
+
+	return Corridor{
+		Line:  points,
+		Width: width,
+		BoundingBox: BoundingBox{
+			MinX: lineBoundingBox.MinX - deltaLon,
+			MinY: math.Max(lineBoundingBox.MinY-deltaLat, -90),
+			MaxX: lineBoundingBox.MaxX + deltaLon,
+			MaxY: math.Min(lineBoundingBox.MaxY+deltaLat, 90),
+		},
+	}
+}
+
+// Type returns the shape type
+func (corridor *Corridor) Type() string {
+	return "corridor"
+}
+
+// GetName returns the name of the corridor
+func (corridor *Corridor) GetName() string {
+	return corridor.Name
+}
+
+// GetID Returns the ID of the corridor
+func (corridor *Corridor) GetID() int64 {
+	return corridor.ID
+}
+
+// SetID Sets the ID of the corridor
+func (corridor *Corridor) SetID(id int64) {
+	corridor.ID = id
+}
+
+// GetProperties fetches the properties of the corridor shape
+func (corridor *Corridor) GetProperties() ShapeProperties {
+	return corridor.Properties
+}
+
+// SetProperties sets the properties of the corridor shape
+func (corridor *Corridor) SetProperties(shapeProperties ShapeProperties) {
+	corridor.Properties = shapeProperties
+}
+
+// GetBoundingBox returns the corridor BoundingBox
+func (corridor *Corridor) GetBoundingBox() BoundingBox {
+	return corridor.BoundingBox
+}
+
+// PointInside checks if a point is within half the width of the line
+func (corridor *Corridor) PointInside(point *Point) bool {
+	return corridor.DistanceToLine(point) <= corridor.Width/2
+}
+
+// PointInsideBoundingBox checks if a point is inside the corridor BoundingBox
+func (corridor *Corridor) PointInsideBoundingBox(point *Point) bool {
+	return corridor.BoundingBox.ContainsPoint(point)
+}
+
+// BoundaryDistance returns the distance in metres from the point to the edge of the corridor
+func (corridor *Corridor) BoundaryDistance(point *Point) float64 {
+	return math.Abs(corridor.DistanceToLine(point) - corridor.Width/2)
+}
+
+// DistanceToLine returns the distance in metres from the point to the closest segment of the line
+func (corridor *Corridor) DistanceToLine(point *Point) float64 {
+	if len(corridor.Line) == 1 {
+		return HaversineDistance(point, &corridor.Line[0])
+	}
+
+	distance := math.Inf(1)
+	origin := Point{}
+
+	for i := 0; i < len(corridor.Line)-1; i++ {
+		a := toLocalMetres(&corridor.Line[i], point)
+		b := toLocalMetres(&corridor.Line[i+1], point)
+
+		distance = math.Min(distance, distanceToSegment(&origin, &a, &b))
+	}
+
+	return distance
+}
+
+// ShapeInside checks if another shape is inside the corridor. Polygons and corridors are checked by their vertices.
+func (corridor *Corridor) ShapeInside(shape Shape) bool {
+	switch shape := shape.(type) {
+	case *Polygon:
+		return corridor.PolygonInside(shape)
+	case *Circle:
+		return corridor.CircleInside(shape)
+	case *MultiPolygon:
+		for i := range shape.Polygons {
+			if !corridor.PolygonInside(&shape.Polygons[i]) {
+				return false
+			}
+		}
+		return len(shape.Polygons) > 0
+	case *Corridor:
+		return corridor.CorridorInside(shape)
+	default:
+		return false
+	}
+}
+
+// PolygonInside checks whether all the vertices of a polygon are inside the corridor
+func (corridor *Corridor) PolygonInside(polygon *Polygon) bool {
+	for i := range polygon.AlphaShape {
+		if !corridor.PointInside(&polygon.AlphaShape[i]) {
+			return false
+		}
+	}
+	return len(polygon.AlphaShape) > 0
+}
+
+// CircleInside checks whether a circle is inside the corridor
+func (corridor *Corridor) CircleInside(circle *Circle) bool {
+	return corridor.DistanceToLine(&circle.Origo)+circle.Radius <= corridor.Width/2
+}
+
+// CorridorInside checks whether the line of another corridor, buffered by its width, is inside the corridor
+func (corridor *Corridor) CorridorInside(corridorCandidate *Corridor) bool {
+	for i := range corridorCandidate.Line {
+		if corridor.DistanceToLine(&corridorCandidate.Line[i])+corridorCandidate.Width/2 > corridor.Width/2 {
+			return false
+		}
+	}
+	return len(corridorCandidate.Line) > 0
+}
@@ -0,0 +1,69 @@
+package geometry
+
+import (
+	"math"
+	"testing"
+)
+
+func TestCorridor_PointInside(t *testing.T) {
+	// A 100 metres wide road going east and then north in Trondheim
+	corridor := NewCorridorFromPoints([]Point{{X: 10.40, Y: 63.40}, {X: 10.42, Y: 63.40}, {X: 10.42, Y: 63.41}}, 100)
+
+	metresPerDegreeLat := EarthRadius * math.Pi / 180
+	metresPerDegreeLon := metresPerDegreeLat * math.Cos(63.4*math.Pi/180)
+
+	tests := []struct {
+		name  string
+		point Point
+		want  bool
+	}{
+		{name: "should return true on the line", point: Point{X: 10.41, Y: 63.40}, want: true},
+		{name: "should return true 40 metres north of the first segment", point: Point{X: 10.41, Y: 63.40 + 40/metresPerDegreeLat}, want: true},
+		{name: "should return false 60 metres south of the first segment", point: Point{X: 10.41, Y: 63.40 - 60/metresPerDegreeLat}, want: false},
+		{name: "should return true 40 metres east of the second segment", point: Point{X: 10.42 + 40/metresPerDegreeLon, Y: 63.405}, want: true},
+		{name: "should return false 60 metres east of the second segment", point: Point{X: 10.42 + 60/metresPerDegreeLon, Y: 63.405}, want: false},
+		{name: "should return false beyond the end of the line", point: Point{X: 10.42, Y: 63.41 + 60/metresPerDegreeLat}, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := corridor.PointInside(&tt.point); got != tt.want {
+				t.Errorf("Corridor.PointInside() = %v, want %v", got, tt.want)
+			}
+			if tt.want && !corridor.PointInsideBoundingBox(&tt.point) {
+				t.Errorf("Corridor.PointInsideBoundingBox() = false, want true")
+			}
+		})
+	}
+}
+
+func TestCorridor_BoundaryDistance(t *testing.T) {
+	corridor := NewCorridorFromPoints([]Point{{X: 0, Y: 0}, {X: 0.01, Y: 0}}, 200)
+
+	tests := []struct {
+		name  string
+		point Point
+		want  float64
+	}{
+		{name: "Should measure from the line", point: Point{X: 0.005, Y: 0}, want: 100},
+		{name: "Should measure from inside the corridor", point: Point{X: 0.005, Y: 0.0005}, want: 44.4},
+		{name: "Should measure from outside the corridor", point: Point{X: 0.005, Y: -0.002}, want: 122.4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := corridor.BoundaryDistance(&tt.point); math.Abs(got-tt.want) > 1 {
+				t.Errorf("Corridor.BoundaryDistance() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCorridor_ShapeInside(t *testing.T) {
+	corridor := NewCorridorFromPoints([]Point{{X: 0, Y: 0}, {X: 0.01, Y: 0}}, 200)
+
+	if !corridor.ShapeInside(&Circle{Origo: Point{X: 0.005, Y: 0}, Radius: 50}) {
+		t.Errorf("Corridor.ShapeInside() = false for a circle inside the corridor, want true")
+	}
+	if corridor.ShapeInside(&Circle{Origo: Point{X: 0.005, Y: 0}, Radius: 150}) {
+		t.Errorf("Corridor.ShapeInside() = true for a circle wider than the corridor, want false")
+	}
+}
@@ -0,0 +1,58 @@
+package geometry
+
+import (
+	"math"
+)
+
+// EarthRadius is the mean radius of the earth in metres
+const EarthRadius = 6371008.8
+
+// HaversineDistance returns the great-circle distance in metres between two points where X is
+// the longitude and Y is the latitude in degrees
+func HaversineDistance(p1, p2 *Point) float64 {
+	lat1 := toRadians(p1.Y)
+	lat2 := toRadians(p2.Y)
+	deltaLat := lat2 - lat1
+	deltaLon := toRadians(p2.X - p1.X)
+
+	a := math.Sin(deltaLat/2)*math.Sin(deltaLat/2) +
+		math.Cos(lat1)*math.Cos(lat2)*math.Sin(deltaLon/2)*math.Sin(deltaLon/2)
+
+	return 2 * EarthRadius * math.Atan2(math.Sqrt(a), math.Sqrt(1-a))
+}
+
+// toLocalMetres projects the point onto a plane in metres centered on the origin using an
+// equirectangular approximation. It's accurate for the short distances near a shape edge.
+func toLocalMetres(point, origin *Point) Point {
+	return Point{
+		X: toRadians(point.X-origin.X) * math.Cos(toRadians(origin.Y)) * EarthRadius,
+		Y: toRadians(point.Y-origin.Y) * EarthRadius,
+	}
+}
+
+// distanceToSegment returns the distance from the point to the closest point on the segment a-b
+func distanceToSegment(p, a, b *Point) float64 {
+	AB := b.MinusPoint(a)
+	AP := p.MinusPoint(a)
+
+	lengthSquared := AB.Dot(&AB)
+	if lengthSquared == 0 {
+		return p.DistanceTo(a)
+	}
+
+	// Clamp the projection of AP onto AB to the segment
+	t := math.Max(0, math.Min(1, AP.Dot(&AB)/lengthSquared))
+
+	ABScaled := AB.Times(t)
+	closest := a.PlusPoint(&ABScaled)
+
+	return p.DistanceTo(&closest)
+}
+
+func toRadians(degrees float64) float64 {
+	return degrees * math.Pi / 180
+}
+
+func toDegrees(radians float64) float64 {
+	return radians * 180 / math.Pi
+}
@@ -0,0 +1,62 @@
+package geometry
+
+import (
+	"math"
+	"testing"
+)
+
+func TestHaversineDistance(t *testing.T) {
+	tests := []struct {
+		name      string
+		p1        Point
+		p2        Point
+		want      float64
+		tolerance float64
+	}{
+		{name: "Should be 0 for the same point", p1: Point{X: 10.4, Y: 63.4}, p2: Point{X: 10.4, Y: 63.4}, want: 0, tolerance: 0},
+		{name: "Should be about 111 km for one degree along the equator", p1: Point{X: 0, Y: 0}, p2: Point{X: 1, Y: 0}, want: 111195, tolerance: 1},
+		{name: "Should be about 392 km between Oslo and Trondheim", p1: Point{X: 10.7522, Y: 59.9139}, p2: Point{X: 10.3951, Y: 63.4305}, want: 392000, tolerance: 1000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := HaversineDistance(&tt.p1, &tt.p2); math.Abs(got-tt.want) > tt.tolerance {
+				t.Errorf("HaversineDistance() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBoundaryDistance(t *testing.T) {
+	// About 1112 x 1112 metres at the equator
+	square := NewPolygonFromPoints([]Point{
+		{X: 0, Y: 0},
+		{X: 0.01, Y: 0},
+		{X: 0.01, Y: 0.01},
+		{X: 0, Y: 0.01},
+	})
+	circle := Circle{Origo: Point{X: 0, Y: 0}, Radius: 1112}
+
+	tests := []struct {
+		name  string
+		shape Shape
+		point Point
+		want  float64
+	}{
+		{name: "Should measure from the center of a polygon", shape: &square, point: Point{X: 0.005, Y: 0.005}, want: 556},
+		{name: "Should measure to the closest polygon edge", shape: &square, point: Point{X: 0.009, Y: 0.005}, want: 111},
+		{name: "Should measure from outside a polygon", shape: &square, point: Point{X: 0.011, Y: 0.005}, want: 111},
+		{name: "Should measure to the closing polygon edge", shape: &square, point: Point{X: -0.001, Y: 0.005}, want: 111},
+		{name: "Should measure from origo of a circle", shape: &circle, point: Point{X: 0, Y: 0}, want: 1112},
+		{name: "Should measure from inside a circle", shape: &circle, point: Point{X: 0.009, Y: 0}, want: 111},
+		{name: "Should measure from outside a circle", shape: &circle, point: Point{X: 0, Y: -0.011}, want: 111},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.shape.BoundaryDistance(&tt.point); math.Abs(got-tt.want) > 1 {
+				t.Errorf("BoundaryDistance() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
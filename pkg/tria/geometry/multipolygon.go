@@ -0,0 +1,109 @@
+package geometry
+
+import (
+	"math"
+)
+
+// MultiPolygon contains a list of polygons which together make up a single shape, such as a
+// municipality with islands
+type MultiPolygon struct {
+	ID          int64
+	Name        string
+	Description string
+	Polygons    []Polygon
+	BoundingBox BoundingBox
+	Properties  ShapeProperties
+}
+
+// NewMultiPolygonFromPolygons Returns a new MultiPolygon with a BoundingBox covering all the polygons
+func NewMultiPolygonFromPolygons(polygons []Polygon) MultiPolygon {
+	var corners []Point
+	for _, polygon := range polygons {
+		corners = append(corners,
+			Point{X: polygon.BoundingBox.MinX, Y: polygon.BoundingBox.MinY},
+			Point{X: polygon.BoundingBox.MaxX, Y: polygon.BoundingBox.MaxY},
+		)
+	}
+
+	return MultiPolygon{
+		Polygons:    polygons,
+		BoundingBox: CalculateBoundingBox(corners),
+	}
+}
+
+// Type returns the shape type
+func (multiPolygon *MultiPolygon) Type() string {
+	return "multipolygon"
+}
+
+// GetName returns the name of the multi polygon
+func (multiPolygon *MultiPolygon) GetName() string {
+	return multiPolygon.Name
+}
+
+// GetID Returns the ID of the multi polygon
+func (multiPolygon *MultiPolygon) GetID() int64 {
+	return multiPolygon.ID
+}
+
+// SetID Sets the ID of the multi polygon
+func (multiPolygon *MultiPolygon) SetID(id int64) {
+	multiPolygon.ID = id
+}
+
+// GetProperties fetches the properties of the multi polygon shape
+func (multiPolygon *MultiPolygon) GetProperties() ShapeProperties {
+	return multiPolygon.Properties
+}
+
+// SetProperties sets the properties of the multi polygon shape
+func (multiPolygon *MultiPolygon) SetProperties(shapeProperties ShapeProperties) {
+	multiPolygon.Properties = shapeProperties
+}
+
+// GetBoundingBox returns the BoundingBox covering all the polygons
+func (multiPolygon *MultiPolygon) GetBoundingBox() BoundingBox {
+	return multiPolygon.BoundingBox
+}
+
+// PointInside checks if a point is inside any of the polygons
+func (multiPolygon *MultiPolygon) PointInside(point *Point) bool {
+	for i := range multiPolygon.Polygons {
+		polygon := &multiPolygon.Polygons[i]
+		if polygon.PointInsideBoundingBox(point) && polygon.PointInside(point) {
+			return true
+		}
+	}
+	return false
+}
+
+// PointInsideBoundingBox checks if a point is inside the BoundingBox of any of the polygons
+func (multiPolygon *MultiPolygon) PointInsideBoundingBox(point *Point) bool {
+	for i := range multiPolygon.Polygons {
+		if multiPolygon.Polygons[i].PointInsideBoundingBox(point) {
+			return true
+		}
+	}
+	return false
+}
+
+// BoundaryDistance returns the distance in metres from the point to the closest edge of any of the polygons
+func (multiPolygon *MultiPolygon) BoundaryDistance(point *Point) float64 {
+	distance := math.Inf(1)
+
+	for i := range multiPolygon.Polygons {
+		distance = math.Min(distance, multiPolygon.Polygons[i].BoundaryDistance(point))
+	}
+
+	return distance
+}
+
+// ShapeInside checks if another shape is inside one of the polygons
+func (multiPolygon *MultiPolygon) ShapeInside(shape Shape) bool {
+	for i := range multiPolygon.Polygons {
+		if multiPolygon.Polygons[i].ShapeInside(shape) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,104 @@
+package geometry
+
+import (
+	"testing"
+)
+
+func newTestMultiPolygon() MultiPolygon {
+	square := NewPolygonFromPoints([]Point{{X: 0, Y: 0}, {X: 1, Y: 0}, {X: 1, Y: 1}, {X: 0, Y: 1}})
+	square.Triangles = [][]Point{
+		{{X: 0, Y: 0}, {X: 1, Y: 0}, {X: 1, Y: 1}},
+		{{X: 0, Y: 0}, {X: 1, Y: 1}, {X: 0, Y: 1}},
+	}
+
+	island := NewPolygonFromPoints([]Point{{X: 5, Y: 5}, {X: 6, Y: 5}, {X: 6, Y: 6}, {X: 5, Y: 6}})
+	island.Triangles = [][]Point{
+		{{X: 5, Y: 5}, {X: 6, Y: 5}, {X: 6, Y: 6}},
+		{{X: 5, Y: 5}, {X: 6, Y: 6}, {X: 5, Y: 6}},
+	}
+
+	return NewMultiPolygonFromPolygons([]Polygon{square, island})
+}
+
+func TestMultiPolygon_GetBoundingBox(t *testing.T) {
+	multiPolygon := newTestMultiPolygon()
+
+	want := BoundingBox{MinX: 0, MinY: 0, MaxX: 6, MaxY: 6}
+	if got := multiPolygon.GetBoundingBox(); got != want {
+		t.Errorf("MultiPolygon.GetBoundingBox() = %v, want %v", got, want)
+	}
+}
+
+func TestMultiPolygon_PointInside(t *testing.T) {
+	multiPolygon := newTestMultiPolygon()
+
+	tests := []struct {
+		name  string
+		point Point
+		want  bool
+	}{
+		{name: "should return true inside the first polygon", point: Point{X: 0.5, Y: 0.5}, want: true},
+		{name: "should return true inside the island", point: Point{X: 5.5, Y: 5.5}, want: true},
+		{name: "should return false between the polygons", point: Point{X: 3, Y: 3}, want: false},
+		{name: "should return false outside all polygons", point: Point{X: 7, Y: 7}, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := multiPolygon.PointInside(&tt.point); got != tt.want {
+				t.Errorf("MultiPolygon.PointInside() = %v, want %v", got, tt.want)
+			}
+			if got := multiPolygon.PointInsideBoundingBox(&tt.point); got != tt.want {
+				t.Errorf("MultiPolygon.PointInsideBoundingBox() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMultiPolygon_ShapeInside(t *testing.T) {
+	multiPolygon := newTestMultiPolygon()
+
+	encompassingPolygon := NewPolygonFromPoints([]Point{{X: -1, Y: -1}, {X: 7, Y: -1}, {X: 7, Y: 7}, {X: -1, Y: 7}})
+	encompassingPolygon.Triangles = [][]Point{
+		{{X: -1, Y: -1}, {X: 7, Y: -1}, {X: 7, Y: 7}},
+		{{X: -1, Y: -1}, {X: 7, Y: 7}, {X: -1, Y: 7}},
+	}
+
+	tests := []struct {
+		name      string
+		container Shape
+		shape     Shape
+		want      bool
+	}{
+		{
+			name:      "should return true for a circle inside the island",
+			container: &multiPolygon,
+			shape:     &Circle{Origo: Point{X: 5.5, Y: 5.5}, Radius: 1000},
+			want:      true,
+		},
+		{
+			name:      "should return false for a circle between the polygons",
+			container: &multiPolygon,
+			shape:     &Circle{Origo: Point{X: 3, Y: 3}, Radius: 1000},
+			want:      false,
+		},
+		{
+			name:      "should return true for a multi polygon inside a polygon",
+			container: &encompassingPolygon,
+			shape:     &multiPolygon,
+			want:      true,
+		},
+		{
+			name:      "should return false for a multi polygon partly inside a polygon",
+			container: &multiPolygon.Polygons[1],
+			shape:     &multiPolygon,
+			want:      false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.container.ShapeInside(tt.shape); got != tt.want {
+				t.Errorf("ShapeInside() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,11 +1,17 @@
 package geometry
 
-// Polygon contains static and processed information about a polygon
+import (
+	"math"
+)
+
+// Polygon contains static and processed information about a polygon. The holes are interior
+// rings cut out of the polygon and are never covered by the triangles.
 type Polygon struct {
 	ID          int64
 	Name        string
 	Description string
 	AlphaShape  []Point
+	Holes       [][]Point
 	Triangles   [][]Point
 	BoundingBox BoundingBox
 	Properties  ShapeProperties
@@ -54,6 +60,11 @@ func NewPolygonFromPoints(points []Point) Polygon {
 	}
 }
 
+// Rings returns the outer ring of the polygon followed by the holes
+func (polygon *Polygon) Rings() [][]Point {
+	return append([][]Point{polygon.AlphaShape}, polygon.Holes...)
+}
+
 // PointInside checks if a point is inside the given polygon
 func (polygon *Polygon) PointInside(point *Point) bool {
 	return PointInsidePolygon(point, polygon)
@@ -64,6 +75,23 @@ func (polygon *Polygon) PointInsideBoundingBox(point *Point) bool {
 	return polygon.BoundingBox.ContainsPoint(point)
 }
 
+// BoundaryDistance returns the distance in metres from the point to the closest edge of the polygon
+func (polygon *Polygon) BoundaryDistance(point *Point) float64 {
+	distance := math.Inf(1)
+	origin := Point{}
+
+	for _, ring := range polygon.Rings() {
+		for i := range ring {
+			a := toLocalMetres(&ring[i], point)
+			b := toLocalMetres(&ring[(i+1)%len(ring)], point)
+
+			distance = math.Min(distance, distanceToSegment(&origin, &a, &b))
+		}
+	}
+
+	return distance
+}
+
 // ShapeInside checks if another shape is inside the given polygon
 func (polygon *Polygon) ShapeInside(shape Shape) bool {
 	switch shape := shape.(type) {
@@ -71,51 +99,72 @@ func (polygon *Polygon) ShapeInside(shape Shape) bool {
 		return polygon.PolygonInside(shape)
 	case *Circle:
 		return polygon.CircleInside(shape)
+	case *MultiPolygon:
+		return polygon.MultiPolygonInside(shape)
 	default:
 		return false
 	}
 }
 
-// PolygonInside checks whether a polygon is inside the polygon
+// PolygonInside checks whether a polygon is inside the polygon, ie that every vertex of the candidate is
+// inside the polygon
 func (polygon *Polygon) PolygonInside(polygonCandidate *Polygon) bool {
-	for _, point := range polygon.AlphaShape {
-		if !polygonCandidate.PointInsideBoundingBox(&point) || !polygonCandidate.PointInside(&point) {
+	for _, point := range polygonCandidate.AlphaShape {
+		if !polygon.PointInsideBoundingBox(&point) || !polygon.PointInside(&point) {
 			return false
 		}
 	}
 	return true
 }
 
+// MultiPolygonInside checks whether all the polygons of a multi polygon are inside the polygon
+func (polygon *Polygon) MultiPolygonInside(multiPolygon *MultiPolygon) bool {
+	for i := range multiPolygon.Polygons {
+		if !polygon.PolygonInside(&multiPolygon.Polygons[i]) {
+			return false
+		}
+	}
+	return len(multiPolygon.Polygons) > 0
+}
+
 // CircleInside checks whether a circle is inside the polygon
 func (polygon *Polygon) CircleInside(circle *Circle) bool {
 	// First check if origo is within BoundingBox. If no, the circle can't be inside polygon
 	if polygon.PointInsideBoundingBox(&circle.Origo) {
 		// Then we check the outer bounds of and see if the radius is less than the distance between min and max points
 		// If yes, we need to check all the points in the polygon alpha shape
-		if circle.Origo.DistanceTo(&Point{X: polygon.BoundingBox.MinX, Y: polygon.BoundingBox.MinY}) > circle.Radius &&
-			circle.Origo.DistanceTo(&Point{X: polygon.BoundingBox.MaxX, Y: polygon.BoundingBox.MaxY}) > circle.Radius {
+		if HaversineDistance(&circle.Origo, &Point{X: polygon.BoundingBox.MinX, Y: polygon.BoundingBox.MinY}) > circle.Radius &&
+			HaversineDistance(&circle.Origo, &Point{X: polygon.BoundingBox.MaxX, Y: polygon.BoundingBox.MaxY}) > circle.Radius {
+			// Edge distances are measured in metres on a plane centered on origo
+			origo := Point{X: 0, Y: 0}
+
 			// For each vertice, check if intersecting (ray crossing) and check that points is outside circle radius
 			numOfIntersections := 0
 
 			// Creating ray, adding +1 to Y value to ensure intersection of edges
 			circleRayCrossing := []Point{circle.Origo, Point{X: polygon.BoundingBox.MaxX + 1, Y: circle.Origo.Y}}
 
-			for i := 0; i < len(polygon.AlphaShape); i++ {
-				p1 := polygon.AlphaShape[i]
-				p2 := polygon.AlphaShape[(i+1)%len(polygon.AlphaShape)]
+			// The edges of the holes count as well, as origo can't be inside a hole
+			for _, ring := range polygon.Rings() {
+				for i := 0; i < len(ring); i++ {
+					p1 := ring[i]
+					p2 := ring[(i+1)%len(ring)]
 
-				if circle.Origo.DistanceToLine(&p1, &p2) < circle.Radius {
-					return false
-				}
+					a := toLocalMetres(&p1, &circle.Origo)
+					b := toLocalMetres(&p2, &circle.Origo)
+					if distanceToSegment(&origo, &a, &b) < circle.Radius {
+						return false
+					}
 
-				intersects, err := LineIntersects(circleRayCrossing, []Point{p1, p2})
+					intersects, err := LineIntersects(circleRayCrossing, []Point{p1, p2})
 
-				if err != nil {
-					return false
-				}
+					if err != nil {
+						return false
+					}
 
-				if intersects {
-					numOfIntersections++
+					if intersects {
+						numOfIntersections++
+					}
 				}
 			}
 
@@ -35,28 +35,47 @@ func TestPolygon_ShapeInside(t *testing.T) {
 	})
 	polygonTriangle.Triangles = [][]Point{polygonTriangle.AlphaShape}
 
-	circle1RadiusBottomLeft := Circle{
-		Radius: 1,
+	polygonSquare2by2 := NewPolygonFromPoints([]Point{
+		Point{2, 2},
+		Point{4, 2},
+		Point{4, 4},
+		Point{2, 4},
+	})
+	polygonSquare2by2.Triangles = [][]Point{
+		[]Point{
+			Point{2, 2},
+			Point{4, 2},
+			Point{2, 4},
+		},
+		[]Point{
+			Point{4, 2},
+			Point{2, 4},
+			Point{4, 4},
+		},
+	}
+
+	circle100kmRadiusBottomLeft := Circle{
+		Radius: 100000,
 		Origo:  Point{X: 1, Y: 1},
 	}
-	circle1RadiusInCenter := Circle{
-		Radius: 1,
+	circle100kmRadiusInCenter := Circle{
+		Radius: 100000,
 		Origo:  Point{X: 5, Y: 5},
 	}
-	circle1RadiusToCloseToEdge := Circle{
-		Radius: 1,
-		Origo:  Point{X: 5, Y: 9.1},
+	circle100kmRadiusToCloseToEdge := Circle{
+		Radius: 100000,
+		Origo:  Point{X: 5, Y: 9.5},
 	}
-	circle1RadiusOutsideSquare := Circle{
-		Radius: 1,
+	circle100kmRadiusOutsideSquare := Circle{
+		Radius: 100000,
 		Origo:  Point{X: 5, Y: 15},
 	}
-	circle1RadiusTouchingOuterEdge := Circle{
-		Radius: 1,
-		Origo:  Point{X: 5, Y: 11},
+	circle100kmRadiusTouchingOuterEdge := Circle{
+		Radius: 100000,
+		Origo:  Point{X: 5, Y: 10.9},
 	}
-	circle100RadiusEncompassing := Circle{
-		Radius: 100,
+	circle10000kmRadiusEncompassing := Circle{
+		Radius: 10000000,
 		Origo:  Point{X: 0, Y: 0},
 	}
 
@@ -70,7 +89,7 @@ func TestPolygon_ShapeInside(t *testing.T) {
 			name:   "should return true when circle is within triangle",
 			fields: polygonTriangle,
 			args: args{
-				shape: &circle1RadiusBottomLeft,
+				shape: &circle100kmRadiusBottomLeft,
 			},
 			want: true,
 		},
@@ -78,7 +97,7 @@ func TestPolygon_ShapeInside(t *testing.T) {
 			name:   "should return false when circle is on triangle vertice",
 			fields: polygonTriangle,
 			args: args{
-				shape: &circle1RadiusInCenter,
+				shape: &circle100kmRadiusInCenter,
 			},
 			want: false,
 		},
@@ -86,7 +105,7 @@ func TestPolygon_ShapeInside(t *testing.T) {
 			name:   "should return true when circle is within polygon",
 			fields: polygonSquare10by10,
 			args: args{
-				shape: &circle1RadiusInCenter,
+				shape: &circle100kmRadiusInCenter,
 			},
 			want: true,
 		},
@@ -94,7 +113,7 @@ func TestPolygon_ShapeInside(t *testing.T) {
 			name:   "should return false when circle is too close to edge of square",
 			fields: polygonSquare10by10,
 			args: args{
-				shape: &circle1RadiusToCloseToEdge,
+				shape: &circle100kmRadiusToCloseToEdge,
 			},
 			want: false,
 		},
@@ -102,7 +121,7 @@ func TestPolygon_ShapeInside(t *testing.T) {
 			name:   "should return false when circle is outside square",
 			fields: polygonSquare10by10,
 			args: args{
-				shape: &circle1RadiusOutsideSquare,
+				shape: &circle100kmRadiusOutsideSquare,
 			},
 			want: false,
 		},
@@ -110,7 +129,7 @@ func TestPolygon_ShapeInside(t *testing.T) {
 			name:   "should return false when circle is outside square, yet touching outer edge",
 			fields: polygonSquare10by10,
 			args: args{
-				shape: &circle1RadiusTouchingOuterEdge,
+				shape: &circle100kmRadiusTouchingOuterEdge,
 			},
 			want: false,
 		},
@@ -118,7 +137,31 @@ func TestPolygon_ShapeInside(t *testing.T) {
 			name:   "should return false when circle is encompassing square",
 			fields: polygonSquare10by10,
 			args: args{
-				shape: &circle100RadiusEncompassing,
+				shape: &circle10000kmRadiusEncompassing,
+			},
+			want: false,
+		},
+		{
+			name:   "should return true when polygon is within square",
+			fields: polygonSquare10by10,
+			args: args{
+				shape: &polygonSquare2by2,
+			},
+			want: true,
+		},
+		{
+			name:   "should return false when polygon is encompassing square",
+			fields: polygonSquare2by2,
+			args: args{
+				shape: &polygonSquare10by10,
+			},
+			want: false,
+		},
+		{
+			name:   "should return false when polygon is partly outside triangle",
+			fields: polygonTriangle,
+			args: args{
+				shape: &polygonSquare10by10,
 			},
 			want: false,
 		},
@@ -9,8 +9,11 @@ import (
 
 // ShapeStore is a local struct to easier serialize/deserialize a list of shapes
 type ShapeStore struct {
-	Circles  []Circle
-	Polygons []Polygon
+	Circles       []Circle
+	Polygons      []Polygon
+	MultiPolygons []MultiPolygon
+	Corridors     []Corridor
+	Tripwires     []Tripwire
 }
 
 // Value implements SQL value driver
@@ -46,6 +49,15 @@ func ShapesToShapeStore(shapes []Shape) ShapeStore {
 			case *Circle:
 				circle := shape
 				shapeStore.Circles = append(shapeStore.Circles, *circle)
+			case *MultiPolygon:
+				multiPolygon := shape
+				shapeStore.MultiPolygons = append(shapeStore.MultiPolygons, *multiPolygon)
+			case *Corridor:
+				corridor := shape
+				shapeStore.Corridors = append(shapeStore.Corridors, *corridor)
+			case *Tripwire:
+				tripwire := shape
+				shapeStore.Tripwires = append(shapeStore.Tripwires, *tripwire)
 			default:
 				log.Warn("I have no idea who I am", shape)
 			}
@@ -69,5 +81,20 @@ func ShapeStoreToShapes(store *ShapeStore) []Shape {
 		shapes = append(shapes, &circleCopy)
 	}
 
+	for _, multiPolygon := range store.MultiPolygons {
+		multiPolygonCopy := multiPolygon
+		shapes = append(shapes, &multiPolygonCopy)
+	}
+
+	for _, corridor := range store.Corridors {
+		corridorCopy := corridor
+		shapes = append(shapes, &corridorCopy)
+	}
+
+	for _, tripwire := range store.Tripwires {
+		tripwireCopy := tripwire
+		shapes = append(shapes, &tripwireCopy)
+	}
+
 	return shapes
 }
@@ -35,5 +35,9 @@ type Shape interface {
 	PointInside(point *Point) bool
 	PointInsideBoundingBox(point *Point) bool
 
+	// BoundaryDistance returns the distance in metres from the point to the closest edge of
+	// the shape, regardless of the point being inside or outside the shape
+	BoundaryDistance(point *Point) float64
+
 	ShapeInside(shape Shape) bool
 }
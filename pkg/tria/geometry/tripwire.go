@@ -0,0 +1,130 @@
+package geometry
+
+import (
+	"math"
+)
+
+// CrossingDirection is the direction a tripwire is crossed in, seen when walking along the tripwire
+// from its first to its last point
+type CrossingDirection string
+
+const (
+	// NoCrossing is used when the tripwire isn't crossed
+	NoCrossing CrossingDirection = ""
+	// LeftToRight is a crossing from the left side to the right side of the tripwire
+	LeftToRight CrossingDirection = "left-to-right"
+	// RightToLeft is a crossing from the right side to the left side of the tripwire
+	RightToLeft CrossingDirection = "right-to-left"
+)
+
+// Tripwire is a line, such as a gate, which is crossed rather than entered. No point is ever
+// inside a tripwire.
+type Tripwire struct {
+	ID          int64
+	Name        string
+	Description string
+	Line        []Point
+	BoundingBox BoundingBox
+	Properties  ShapeProperties
+}
+
+// NewTripwireFromPoints Returns a new Tripwire with a BoundingBox covering the line
+func NewTripwireFromPoints(points []Point) Tripwire {
+	return Tripwire{
+		Line:        points,
+		BoundingBox: CalculateBoundingBox(points),
+	}
+}
+
+// Type returns the shape type
+func (tripwire *Tripwire) Type() string {
+	return "tripwire"
+}
+
+// GetName returns the name of the tripwire
+func (tripwire *Tripwire) GetName() string {
+	return tripwire.Name
+}
+
+// GetID Returns the ID of the tripwire
+func (tripwire *Tripwire) GetID() int64 {
+	return tripwire.ID
+}
+
+// SetID Sets the ID of the tripwire
+func (tripwire *Tripwire) SetID(id int64) {
+	tripwire.ID = id
+}
+
+// GetProperties fetches the properties of the tripwire shape
+func (tripwire *Tripwire) GetProperties() ShapeProperties {
+	return tripwire.Properties
+}
+
+// SetProperties sets the properties of the tripwire shape
+func (tripwire *Tripwire) SetProperties(shapeProperties ShapeProperties) {
+	tripwire.Properties = shapeProperties
+}
+
+// GetBoundingBox returns the tripwire BoundingBox
+func (tripwire *Tripwire) GetBoundingBox() BoundingBox {
+	return tripwire.BoundingBox
+}
+
+// PointInside always returns false as a line has no inside
+func (tripwire *Tripwire) PointInside(point *Point) bool {
+	return false
+}
+
+// PointInsideBoundingBox checks if a point is inside the tripwire BoundingBox
+func (tripwire *Tripwire) PointInsideBoundingBox(point *Point) bool {
+	return tripwire.BoundingBox.ContainsPoint(point)
+}
+
+// BoundaryDistance returns the distance in metres from the point to the closest segment of the tripwire
+func (tripwire *Tripwire) BoundaryDistance(point *Point) float64 {
+	distance := math.Inf(1)
+	origin := Point{}
+
+	for i := 0; i < len(tripwire.Line)-1; i++ {
+		a := toLocalMetres(&tripwire.Line[i], point)
+		b := toLocalMetres(&tripwire.Line[i+1], point)
+
+		distance = math.Min(distance, distanceToSegment(&origin, &a, &b))
+	}
+
+	return distance
+}
+
+// ShapeInside always returns false as a line has no inside
+func (tripwire *Tripwire) ShapeInside(shape Shape) bool {
+	return false
+}
+
+// Crossing returns the direction the tripwire is crossed in when moving from one point to another.
+// If several segments of the tripwire are crossed, the direction of the first segment crossed is
+// returned. Moving onto or along the tripwire isn't a crossing.
+func (tripwire *Tripwire) Crossing(from, to *Point) CrossingDirection {
+	for i := 0; i < len(tripwire.Line)-1; i++ {
+		a, b := tripwire.Line[i], tripwire.Line[i+1]
+
+		fromSide := orientation(a, b, *from)
+		toSide := orientation(a, b, *to)
+
+		if fromSide == 0 || toSide == 0 || fromSide == toSide {
+			continue
+		}
+
+		if intersects, err := LineIntersects([]Point{*from, *to}, []Point{a, b}); err != nil || !intersects {
+			continue
+		}
+
+		// A counter clockwise orientation is on the left side of the segment
+		if fromSide == 1 {
+			return LeftToRight
+		}
+		return RightToLeft
+	}
+
+	return NoCrossing
+}
@@ -0,0 +1,45 @@
+package geometry
+
+import (
+	"testing"
+)
+
+func TestTripwire_Crossing(t *testing.T) {
+	// A gate heading north and then east. Walking along the gate, west of the first segment and
+	// north of the second segment is to the left.
+	tripwire := NewTripwireFromPoints([]Point{{X: 0, Y: 0}, {X: 0, Y: 1}, {X: 1, Y: 1}})
+
+	tests := []struct {
+		name string
+		from Point
+		to   Point
+		want CrossingDirection
+	}{
+		{name: "Should cross the first segment from left to right", from: Point{X: -1, Y: 0.5}, to: Point{X: 1, Y: 0.5}, want: LeftToRight},
+		{name: "Should cross the first segment from right to left", from: Point{X: 1, Y: 0.5}, to: Point{X: -1, Y: 0.5}, want: RightToLeft},
+		{name: "Should cross the second segment from left to right", from: Point{X: 0.5, Y: 2}, to: Point{X: 0.5, Y: 0.5}, want: LeftToRight},
+		{name: "Should cross the second segment from right to left", from: Point{X: 0.5, Y: 0.5}, to: Point{X: 0.5, Y: 2}, want: RightToLeft},
+		{name: "Should not cross when moving onto the tripwire", from: Point{X: -1, Y: 0.5}, to: Point{X: 0, Y: 0.5}, want: NoCrossing},
+		{name: "Should not cross when moving on one side", from: Point{X: -1, Y: 0.5}, to: Point{X: -0.5, Y: 2}, want: NoCrossing},
+		{name: "Should not cross when passing the end of the tripwire", from: Point{X: 2, Y: 0.5}, to: Point{X: 2, Y: 2}, want: NoCrossing},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tripwire.Crossing(&tt.from, &tt.to); got != tt.want {
+				t.Errorf("Tripwire.Crossing() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTripwire_PointInside(t *testing.T) {
+	tripwire := NewTripwireFromPoints([]Point{{X: 0, Y: 0}, {X: 0, Y: 1}})
+
+	point := Point{X: 0, Y: 0.5}
+	if tripwire.PointInside(&point) {
+		t.Errorf("Tripwire.PointInside() = true, want false")
+	}
+	if !tripwire.PointInsideBoundingBox(&point) {
+		t.Errorf("Tripwire.PointInsideBoundingBox() = false, want true")
+	}
+}
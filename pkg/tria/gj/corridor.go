@@ -0,0 +1,27 @@
+package gj
+
+import (
+	"github.com/eesrc/geo/pkg/tria/geometry"
+	geojson "github.com/paulmach/go.geojson"
+)
+
+// NewCorridorFeatureFromCorridor Returns a GeoJSON LineStringFeature with property "width" set to the corridor width
+// and "name" as the corridor name
+func NewCorridorFeatureFromCorridor(corridor geometry.Corridor) *geojson.Feature {
+	line := make([][]float64, len(corridor.Line))
+	for i, point := range corridor.Line {
+		line[i] = []float64{point.X, point.Y}
+	}
+
+	lineFeature := geojson.NewLineStringFeature(line)
+
+	for k, v := range corridor.GetProperties() {
+		lineFeature.SetProperty(k, v)
+	}
+
+	lineFeature.SetProperty("width", corridor.Width)
+	lineFeature.SetProperty("name", corridor.Name)
+	lineFeature.SetProperty("id", corridor.GetID())
+
+	return lineFeature
+}
@@ -8,52 +8,52 @@ import (
 	geojson "github.com/paulmach/go.geojson"
 )
 
-// TransformFromGeoJSONCoordinatesToPoints Transforms a geoJSON geometry to points
-func TransformFromGeoJSONCoordinatesToPoints(geoJSONGeometry *geojson.Geometry, projection MapProjection) [][]geometry.Point {
-	var pointList [][]geometry.Point
+// TransformFromGeoJSONCoordinatesToPoints Transforms a geoJSON geometry to a list of polygons where each polygon
+// is a list of rings. The first ring is the outer ring and the rest are holes. A point is returned as a single
+// ring with one point and a line string as a single ring with the points of the line.
+func TransformFromGeoJSONCoordinatesToPoints(geoJSONGeometry *geojson.Geometry, projection MapProjection) [][][]geometry.Point {
+	var polygonList [][][]geometry.Point
 
 	if geoJSONGeometry == nil {
-		return pointList
+		return polygonList
 	}
 
 	if geoJSONGeometry.IsPoint() {
-		pointList = append(pointList, []geometry.Point{getPointFromProjection(geoJSONGeometry.Point, projection)})
-		return pointList
+		polygonList = append(polygonList, [][]geometry.Point{{getPointFromProjection(geoJSONGeometry.Point, projection)}})
+		return polygonList
 	}
 
-	if geoJSONGeometry.IsPolygon() {
-		for i := 0; i < len(geoJSONGeometry.Polygon); i++ {
-			var polygonPoints []geometry.Point
-			polygon := geoJSONGeometry.Polygon[i]
+	if geoJSONGeometry.IsLineString() {
+		polygonList = append(polygonList, getRingsFromProjection([][][]float64{geoJSONGeometry.LineString}, projection))
+		return polygonList
+	}
 
-			for j := 0; j < len(polygon); j++ {
-				polygonPoints = append(polygonPoints, getPointFromProjection(polygon[j], projection))
-			}
+	if geoJSONGeometry.IsPolygon() {
+		polygonList = append(polygonList, getRingsFromProjection(geoJSONGeometry.Polygon, projection))
+		return polygonList
+	}
 
-			pointList = append(pointList, polygonPoints)
+	if geoJSONGeometry.IsMultiPolygon() {
+		for _, polygon := range geoJSONGeometry.MultiPolygon {
+			polygonList = append(polygonList, getRingsFromProjection(polygon, projection))
 		}
 
-		return pointList
+		return polygonList
 	}
 
-	if geoJSONGeometry.IsMultiPolygon() {
-		for _, multipolygon := range geoJSONGeometry.MultiPolygon {
-			for i := 0; i < len(multipolygon); i++ {
-				var polygonPoints []geometry.Point
-				polygon := multipolygon[i]
+	return polygonList
+}
 
-				for j := 0; j < len(polygon); j++ {
-					polygonPoints = append(polygonPoints, getPointFromProjection(polygon[j], projection))
-				}
+func getRingsFromProjection(polygon [][][]float64, projection MapProjection) [][]geometry.Point {
+	rings := make([][]geometry.Point, len(polygon))
 
-				pointList = append(pointList, polygonPoints)
-			}
+	for i, ring := range polygon {
+		for j := 0; j < len(ring); j++ {
+			rings[i] = append(rings[i], getPointFromProjection(ring[j], projection))
 		}
-
-		return pointList
 	}
 
-	return pointList
+	return rings
 }
 
 func getPointFromProjection(point []float64, projection MapProjection) geometry.Point {
@@ -104,6 +104,22 @@ func NewGeoJSONFeatureCollectionFromShape(shape geometry.Shape, includeTriangles
 		circle := shape
 		featureCollection.AddFeature(NewCircleFeatureFromCircle(*circle))
 
+	case *geometry.MultiPolygon:
+		multiPolygon := shape
+
+		geometries := NewMultiPolygonFeaturesFromMultiPolygon(multiPolygon, includeTriangles)
+		for _, geometry := range geometries {
+			featureCollection.AddFeature(geometry)
+		}
+
+	case *geometry.Corridor:
+		corridor := shape
+		featureCollection.AddFeature(NewCorridorFeatureFromCorridor(*corridor))
+
+	case *geometry.Tripwire:
+		tripwire := shape
+		featureCollection.AddFeature(NewTripwireFeatureFromTripwire(*tripwire))
+
 	default:
 		return *featureCollection
 	}
@@ -137,6 +153,15 @@ func GetFeaturesFromShape(shape geometry.Shape, includeTriangles bool) []*geojso
 	case *geometry.Circle:
 		circle := shape
 		features = append(features, NewCircleFeatureFromCircle(*circle))
+	case *geometry.MultiPolygon:
+		multiPolygon := shape
+		features = append(features, NewMultiPolygonFeaturesFromMultiPolygon(multiPolygon, includeTriangles)...)
+	case *geometry.Corridor:
+		corridor := shape
+		features = append(features, NewCorridorFeatureFromCorridor(*corridor))
+	case *geometry.Tripwire:
+		tripwire := shape
+		features = append(features, NewTripwireFeatureFromTripwire(*tripwire))
 	default:
 		return features
 	}
@@ -146,6 +171,29 @@ func GetFeaturesFromShape(shape geometry.Shape, includeTriangles bool) []*geojso
 
 // GetFeatureFromPoints creates a GeoJSON feature from a list of points and a map of properties.
 func GetFeatureFromPoints(points []geometry.Point, properties map[string]interface{}) *geojson.Feature {
+	return GetFeatureFromRings([][]geometry.Point{points}, properties)
+}
+
+// GetFeatureFromRings creates a GeoJSON polygon feature from a list of rings and a map of properties.
+// The first ring is the outer ring and the rest are holes.
+func GetFeatureFromRings(rings [][]geometry.Point, properties map[string]interface{}) *geojson.Feature {
+	// Initiate three dimensional Feature point array for the GeoJSON Feature
+	polyPoints := make([][][]float64, len(rings))
+
+	for i, ring := range rings {
+		polyPoints[i] = getGeoJSONRing(ring)
+	}
+
+	feature := geojson.NewPolygonFeature(polyPoints)
+
+	for key, value := range properties {
+		feature.SetProperty(key, value)
+	}
+
+	return feature
+}
+
+func getGeoJSONRing(points []geometry.Point) [][]float64 {
 	numberOfPoints := len(points)
 
 	// GeoJSON needs the start and end point to be the same to be valid. Check if it already satisfies this criteria.
@@ -156,32 +204,23 @@ func GetFeatureFromPoints(points []geometry.Point, properties map[string]interfa
 		numberOfGeoJSONPoints += 1
 	}
 
-	// Initiate three dimensional Feature point array for the GeoJSON Feature
-	polyPoints := make([][][]float64, 1)
-
-	polyPoints[0] = make([][]float64, numberOfGeoJSONPoints)
+	ring := make([][]float64, numberOfGeoJSONPoints)
 	for i := 0; i < numberOfGeoJSONPoints; i++ {
-		polyPoints[0][i] = make([]float64, 2)
+		ring[i] = make([]float64, 2)
 	}
 
 	for i, point := range points {
-		polyPoints[0][i][0] = point.X
-		polyPoints[0][i][1] = point.Y
+		ring[i][0] = point.X
+		ring[i][1] = point.Y
 	}
 
 	// If no matching ends, populate the last point with the first
 	if !matchingEnds {
-		polyPoints[0][len(points)][0] = points[0].X
-		polyPoints[0][len(points)][1] = points[0].Y
+		ring[len(points)][0] = points[0].X
+		ring[len(points)][1] = points[0].Y
 	}
 
-	feature := geojson.NewPolygonFeature(polyPoints)
-
-	for key, value := range properties {
-		feature.SetProperty(key, value)
-	}
-
-	return feature
+	return ring
 }
 
 // GetFeatureFromPoint creates a GeoJSON feature from a single point and a map of properties.
@@ -64,3 +64,40 @@ func TestGetFeatureFromPoints(t *testing.T) {
 		})
 	}
 }
+
+func TestNewPolygonFeaturesFromPolygonWithHoles(t *testing.T) {
+	rings := [][][]float64{
+		[][]float64{
+			[]float64{0, 0},
+			[]float64{10, 0},
+			[]float64{10, 10},
+			[]float64{0, 10},
+			[]float64{0, 0},
+		},
+		[][]float64{
+			[]float64{4, 4},
+			[]float64{4, 6},
+			[]float64{6, 6},
+			[]float64{6, 4},
+			[]float64{4, 4},
+		},
+	}
+
+	polygonList := TransformFromGeoJSONCoordinatesToPoints(geojson.NewPolygonGeometry(rings), WGS84Projection)
+	if len(polygonList) != 1 || len(polygonList[0]) != 2 {
+		t.Fatalf("TransformFromGeoJSONCoordinatesToPoints() = %v, want one polygon with two rings", polygonList)
+	}
+
+	polygon := geometry.NewPolygonFromPoints(polygonList[0][0])
+	polygon.Name = "Square with hole"
+	polygon.Holes = polygonList[0][1:]
+
+	features := NewPolygonFeaturesFromPolygon(&polygon, false)
+	if len(features) != 1 {
+		t.Fatalf("NewPolygonFeaturesFromPolygon() returned %d features, want 1", len(features))
+	}
+
+	if !reflect.DeepEqual(features[0].Geometry.Polygon, rings) {
+		t.Errorf("NewPolygonFeaturesFromPolygon() = %v, want %v", features[0].Geometry.Polygon, rings)
+	}
+}
@@ -0,0 +1,37 @@
+package gj
+
+import (
+	"github.com/eesrc/geo/pkg/tria/geometry"
+	geojson "github.com/paulmach/go.geojson"
+)
+
+// NewMultiPolygonFeaturesFromMultiPolygon returns a list of GeoJSON Features from a multi polygon. If
+// includeTriangles is true, the triangulated shapes will be added as a separate feature for each triangle.
+func NewMultiPolygonFeaturesFromMultiPolygon(multiPolygon *geometry.MultiPolygon, includeTriangles bool) []*geojson.Feature {
+	var multiPolygonFeatures []*geojson.Feature
+
+	multiPolygonPoints := make([][][][]float64, len(multiPolygon.Polygons))
+	for i, polygon := range multiPolygon.Polygons {
+		// Reuse the rings of the polygon feature to get closed GeoJSON rings
+		multiPolygonPoints[i] = GetFeatureFromRings(polygon.Rings(), nil).Geometry.Polygon
+	}
+
+	multiPolygonFeature := geojson.NewMultiPolygonFeature(multiPolygonPoints...)
+	multiPolygonFeature.SetProperty("name", multiPolygon.GetName())
+
+	for k, v := range multiPolygon.GetProperties() {
+		multiPolygonFeature.SetProperty(k, v)
+	}
+
+	multiPolygonFeatures = append(multiPolygonFeatures, multiPolygonFeature)
+
+	if includeTriangles {
+		for i := range multiPolygon.Polygons {
+			// The polygon feature itself is already part of the multi polygon feature
+			polygonFeatures := NewPolygonFeaturesFromPolygon(&multiPolygon.Polygons[i], true)
+			multiPolygonFeatures = append(multiPolygonFeatures, polygonFeatures[1:]...)
+		}
+	}
+
+	return multiPolygonFeatures
+}
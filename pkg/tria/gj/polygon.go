@@ -12,7 +12,7 @@ import (
 func NewPolygonFeaturesFromPolygon(polygon *geometry.Polygon, includeTriangles bool) []*geojson.Feature {
 	var polygonFeatures []*geojson.Feature
 
-	polygonFeature := GetFeatureFromPoints(polygon.AlphaShape, map[string]interface{}{
+	polygonFeature := GetFeatureFromRings(polygon.Rings(), map[string]interface{}{
 		"name": polygon.GetName(),
 	})
 
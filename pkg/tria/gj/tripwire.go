@@ -0,0 +1,27 @@
+package gj
+
+import (
+	"github.com/eesrc/geo/pkg/tria/geometry"
+	geojson "github.com/paulmach/go.geojson"
+)
+
+// NewTripwireFeatureFromTripwire Returns a GeoJSON LineStringFeature with property "tripwire" set to true
+// and "name" as the tripwire name
+func NewTripwireFeatureFromTripwire(tripwire geometry.Tripwire) *geojson.Feature {
+	line := make([][]float64, len(tripwire.Line))
+	for i, point := range tripwire.Line {
+		line[i] = []float64{point.X, point.Y}
+	}
+
+	lineFeature := geojson.NewLineStringFeature(line)
+
+	for k, v := range tripwire.GetProperties() {
+		lineFeature.SetProperty(k, v)
+	}
+
+	lineFeature.SetProperty("tripwire", true)
+	lineFeature.SetProperty("name", tripwire.Name)
+	lineFeature.SetProperty("id", tripwire.GetID())
+
+	return lineFeature
+}
@@ -0,0 +1,135 @@
+package index
+
+import "sync"
+
+// Cache shares the indexes of shape collections between the subscriptions using them, so every
+// shape collection is indexed once no matter how many subscriptions there are. The indexes are
+// reference counted and evicted when the last reference is released.
+//
+// Invalidating a shape collection bumps its version. Indexes of older versions are kept for the
+// subscriptions still using them, but are never handed out again.
+type Cache struct {
+	mutex       *sync.Mutex
+	collections map[int64]*cacheCollection
+}
+
+// cacheCollection holds the indexes of the versions of a shape collection which are still in use,
+// along with the version of every built index for releasing them. It's removed once none of them are.
+type cacheCollection struct {
+	version  int64
+	entries  map[int64]*cacheEntry
+	versions map[TriaIndex]int64
+}
+
+// cacheEntry is an index and its references. The index is built without holding the lock of the
+// cache, so ready is closed once the build is done and either index or err is set.
+type cacheEntry struct {
+	ready      chan struct{}
+	index      TriaIndex
+	err        error
+	references int
+}
+
+// NewCache returns an empty index cache
+func NewCache() *Cache {
+	return &Cache{
+		mutex:       &sync.Mutex{},
+		collections: make(map[int64]*cacheCollection),
+	}
+}
+
+// Acquire returns the index of the current version of the shape collection and adds a reference to
+// it. If the index isn't cached it's built with the given function. Concurrent acquires of the same
+// index wait for a single build, while other shape collections can be acquired and released meanwhile.
+// Every acquired index must be released when it's no longer used.
+func (cache *Cache) Acquire(shapeCollectionID int64, build func() (TriaIndex, error)) (TriaIndex, error) {
+	cache.mutex.Lock()
+
+	collection, exists := cache.collections[shapeCollectionID]
+	if !exists {
+		collection = &cacheCollection{
+			entries:  make(map[int64]*cacheEntry),
+			versions: make(map[TriaIndex]int64),
+		}
+		cache.collections[shapeCollectionID] = collection
+	}
+
+	version := collection.version
+	entry, exists := collection.entries[version]
+	if exists {
+		entry.references++
+		cache.mutex.Unlock()
+
+		<-entry.ready
+		return entry.index, entry.err
+	}
+
+	entry = &cacheEntry{ready: make(chan struct{}), references: 1}
+	collection.entries[version] = entry
+	cache.mutex.Unlock()
+
+	index, err := build()
+
+	cache.mutex.Lock()
+	entry.index, entry.err = index, err
+	if err == nil {
+		collection.versions[index] = version
+	} else {
+		// The acquires waiting for the build fail as well, so none of them hold a reference
+		cache.remove(shapeCollectionID, version)
+	}
+	cache.mutex.Unlock()
+
+	close(entry.ready)
+
+	return index, err
+}
+
+// Release removes a reference to the index of the shape collection. The index is evicted once the
+// last reference is released. Indexes which aren't cached are ignored.
+func (cache *Cache) Release(shapeCollectionID int64, index TriaIndex) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	collection, exists := cache.collections[shapeCollectionID]
+	if !exists {
+		return
+	}
+
+	version, exists := collection.versions[index]
+	if !exists {
+		return
+	}
+
+	entry := collection.entries[version]
+	entry.references--
+	if entry.references <= 0 {
+		cache.remove(shapeCollectionID, version)
+	}
+}
+
+// Invalidate makes the next acquire of the shape collection build a new index, typically when its
+// shapes have changed
+func (cache *Cache) Invalidate(shapeCollectionID int64) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	// Shape collections without any cached index are built on the next acquire anyway
+	if collection, exists := cache.collections[shapeCollectionID]; exists {
+		collection.version++
+	}
+}
+
+// remove evicts the index of the version of the shape collection, along with the shape collection if
+// none of its versions are in use. The cache must be locked.
+func (cache *Cache) remove(shapeCollectionID int64, version int64) {
+	collection := cache.collections[shapeCollectionID]
+
+	if entry := collection.entries[version]; entry.index != nil {
+		delete(collection.versions, entry.index)
+	}
+	delete(collection.entries, version)
+	if len(collection.entries) == 0 {
+		delete(cache.collections, shapeCollectionID)
+	}
+}
@@ -0,0 +1,108 @@
+package index
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCache(t *testing.T) {
+	cache := NewCache()
+
+	builds := 0
+	build := func() (TriaIndex, error) {
+		builds++
+		return NewRTreeIndex(), nil
+	}
+
+	first, err := cache.Acquire(1, build)
+	assert.Nil(t, err)
+	second, err := cache.Acquire(1, build)
+	assert.Nil(t, err)
+	assert.True(t, first == second, "Should share the index")
+	assert.Equal(t, 1, builds)
+
+	other, err := cache.Acquire(2, build)
+	assert.Nil(t, err)
+	assert.False(t, other == first, "Should index every shape collection separately")
+	assert.Equal(t, 2, builds)
+
+	// Subscriptions still using an invalidated index keep it, new ones get a fresh index
+	cache.Invalidate(1)
+	updated, err := cache.Acquire(1, build)
+	assert.Nil(t, err)
+	assert.False(t, updated == first, "Should build a new index after invalidation")
+	assert.Equal(t, 3, builds)
+
+	cache.Release(1, first)
+	cache.Release(1, second)
+	assert.Equal(t, 1, len(cache.collections[1].entries), "Should evict the outdated index")
+
+	cache.Release(1, updated)
+	_, exists := cache.collections[1]
+	assert.False(t, exists, "Should remove the shape collection after the last release")
+
+	again, err := cache.Acquire(1, build)
+	assert.Nil(t, err)
+	assert.False(t, again == updated, "Should rebuild the index after the last release")
+	assert.Equal(t, 4, builds)
+
+	// Indexes which aren't cached are ignored
+	cache.Release(2, NewRTreeIndex())
+	assert.Equal(t, 1, len(cache.collections[2].entries), "Should ignore indexes which aren't cached")
+
+	// Shape collections without any cached index aren't tracked
+	cache.Invalidate(3)
+	assert.Equal(t, 2, len(cache.collections))
+}
+
+func TestCacheBuildsOnce(t *testing.T) {
+	cache := NewCache()
+
+	started := make(chan struct{})
+	finish := make(chan struct{})
+	builds := 0
+	build := func() (TriaIndex, error) {
+		builds++
+		close(started)
+		<-finish
+		return NewRTreeIndex(), nil
+	}
+
+	indexes := make(chan TriaIndex)
+	go func() {
+		index, err := cache.Acquire(1, build)
+		assert.Nil(t, err)
+		indexes <- index
+	}()
+	<-started
+
+	// Other shape collections don't wait for the build
+	other, err := cache.Acquire(2, func() (TriaIndex, error) { return NewRTreeIndex(), nil })
+	assert.Nil(t, err)
+	cache.Release(2, other)
+
+	go func() {
+		index, err := cache.Acquire(1, build)
+		assert.Nil(t, err)
+		indexes <- index
+	}()
+
+	close(finish)
+	first, second := <-indexes, <-indexes
+	assert.True(t, first == second, "Should share the index")
+	assert.Equal(t, 1, builds)
+}
+
+func TestCacheBuildError(t *testing.T) {
+	cache := NewCache()
+
+	_, err := cache.Acquire(1, func() (TriaIndex, error) { return nil, errors.New("failed") })
+	assert.NotNil(t, err)
+	assert.Equal(t, 0, len(cache.collections), "Should not cache failed builds")
+
+	index, err := cache.Acquire(1, func() (TriaIndex, error) { return NewRTreeIndex(), nil })
+	assert.Nil(t, err)
+	assert.NotNil(t, index)
+}
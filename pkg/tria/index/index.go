@@ -17,4 +17,6 @@ type TriaIndex interface {
 	FindShapesWhichContainsPoint(geometry.Point) []geometry.Shape
 	// FindShapesWhichContainsShape checks the store shapes if it contains with given shape
 	FindShapesWhichContainsShape(geometry.Shape) []geometry.Shape
+	// FindShapesWhichIntersectsBoundingBox returns the store shapes with a bounding box intersecting the given bounding box
+	FindShapesWhichIntersectsBoundingBox(geometry.BoundingBox) []geometry.Shape
 }
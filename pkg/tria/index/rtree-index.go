@@ -52,15 +52,17 @@ func NewRTreeIndexFromModel(shapeModels []model.Shape) *RTreeIndex {
 }
 
 func (store *RTreeIndex) AddShape(shape geometry.Shape) {
-	rTreeObject, err := newRTreeObjectFromShape(shape)
+	rTreeObjects, err := newRTreeObjectsFromShape(shape)
 
 	if err != nil {
 		log.WithError(err).Warnf("Could not add shape %#v, boundingbox %#v", shape, shape.GetBoundingBox())
 		return
 	}
 
-	store.treeObjects = append(store.treeObjects, rTreeObject)
-	store.tree.Insert(rTreeObject)
+	for _, rTreeObject := range rTreeObjects {
+		store.treeObjects = append(store.treeObjects, rTreeObject)
+		store.tree.Insert(rTreeObject)
+	}
 }
 
 // AddShapes ...
@@ -81,17 +83,35 @@ func (store *RTreeIndex) GetShapeByName(shapeName string) (geometry.Shape, error
 	return nil, errors.New("No shape found with name " + shapeName)
 }
 
+// GetShapeByID ...
+func (store *RTreeIndex) GetShapeByID(shapeID int64) (geometry.Shape, error) {
+	for _, treeObject := range store.treeObjects {
+		if treeObject.shape.GetID() == shapeID {
+			return treeObject.shape, nil
+		}
+	}
+
+	return nil, fmt.Errorf("No shape found with ID %d", shapeID)
+}
+
 // RemoveShapeByName ...
 func (store *RTreeIndex) RemoveShapeByName(shapeName string) (geometry.Shape, error) {
 	for _, treeObject := range store.treeObjects {
 		if treeObject.shape.GetName() == shapeName {
-			removed := store.tree.Delete(treeObject)
+			shape := treeObject.shape
+
+			// A shape can be indexed by several tree objects, remove all of them
+			for _, shapeTreeObject := range store.treeObjects {
+				if shapeTreeObject.shape != shape {
+					continue
+				}
 
-			if !removed {
-				return treeObject.shape, fmt.Errorf("Could not find shape to remove")
+				if removed := store.tree.Delete(shapeTreeObject); !removed {
+					return shape, fmt.Errorf("Could not find shape to remove")
+				}
 			}
 
-			return treeObject.shape, nil
+			return shape, nil
 		}
 	}
 
@@ -114,8 +134,8 @@ func (store *RTreeIndex) FindShapesWhichContainsPoint(point geometry.Point) []ge
 		searchRect,
 	)
 
-	for _, result := range results {
-		if treeObject, ok := result.(*rTreeObject); ok && treeObject.shape.PointInside(&point) {
+	for _, treeObject := range uniqueShapeTreeObjects(results) {
+		if treeObject.shape.PointInside(&point) {
 			matchingShapes = append(matchingShapes, treeObject.shape)
 		}
 	}
@@ -123,6 +143,23 @@ func (store *RTreeIndex) FindShapesWhichContainsPoint(point geometry.Point) []ge
 	return matchingShapes
 }
 
+// FindShapesWhichIntersectsBoundingBox ...
+func (store *RTreeIndex) FindShapesWhichIntersectsBoundingBox(boundingBox geometry.BoundingBox) []geometry.Shape {
+	var matchingShapes []geometry.Shape = make([]geometry.Shape, 0)
+
+	searchRect, err := newRectFromBoundingBox(boundingBox)
+	if err != nil {
+		log.WithError(err).Warnf("Failed to create new rect")
+		return matchingShapes
+	}
+
+	for _, treeObject := range uniqueShapeTreeObjects(store.tree.SearchIntersect(searchRect)) {
+		matchingShapes = append(matchingShapes, treeObject.shape)
+	}
+
+	return matchingShapes
+}
+
 // FindShapesWhichContainsShape ...
 func (store *RTreeIndex) FindShapesWhichContainsShape(shape geometry.Shape) []geometry.Shape {
 	var matchingShapes []geometry.Shape = make([]geometry.Shape, 0)
@@ -138,8 +175,8 @@ func (store *RTreeIndex) FindShapesWhichContainsShape(shape geometry.Shape) []ge
 
 	results := store.tree.SearchIntersect(searchRect)
 
-	for _, result := range results {
-		if treeObject, ok := result.(*rTreeObject); ok && treeObject.shape.ShapeInside(shape) {
+	for _, treeObject := range uniqueShapeTreeObjects(results) {
+		if treeObject.shape.ShapeInside(shape) {
 			matchingShapes = append(matchingShapes, treeObject.shape)
 		}
 	}
@@ -148,6 +185,50 @@ func (store *RTreeIndex) FindShapesWhichContainsShape(shape geometry.Shape) []ge
 
 }
 
+// newRTreeObjectsFromShape returns the tree objects indexing the shape. Each polygon of a multi
+// polygon gets its own tree object, so polygons far apart don't share one large bounding box.
+func newRTreeObjectsFromShape(shape geometry.Shape) ([]*rTreeObject, error) {
+	multiPolygon, ok := shape.(*geometry.MultiPolygon)
+	if !ok {
+		treeObject, err := newRTreeObjectFromShape(shape)
+		if err != nil {
+			return nil, err
+		}
+
+		return []*rTreeObject{treeObject}, nil
+	}
+
+	var rTreeObjects []*rTreeObject
+	for _, polygon := range multiPolygon.Polygons {
+		rect, err := newRectFromBoundingBox(polygon.BoundingBox)
+		if err != nil {
+			return nil, err
+		}
+
+		rTreeObjects = append(rTreeObjects, &rTreeObject{
+			shape: shape,
+			rect:  rect,
+		})
+	}
+
+	return rTreeObjects, nil
+}
+
+// uniqueShapeTreeObjects returns the tree objects of the search results with only one tree object per shape
+func uniqueShapeTreeObjects(results []rtreego.Spatial) []*rTreeObject {
+	var treeObjects []*rTreeObject
+	seenShapes := make(map[geometry.Shape]bool)
+
+	for _, result := range results {
+		if treeObject, ok := result.(*rTreeObject); ok && !seenShapes[treeObject.shape] {
+			seenShapes[treeObject.shape] = true
+			treeObjects = append(treeObjects, treeObject)
+		}
+	}
+
+	return treeObjects
+}
+
 func newRTreeObjectFromShape(shape geometry.Shape) (*rTreeObject, error) {
 	rect, err := newRectFromBoundingBox(shape.GetBoundingBox())
 
@@ -161,9 +242,15 @@ func newRTreeObjectFromShape(shape geometry.Shape) (*rTreeObject, error) {
 	}, nil
 }
 
+// newRectFromBoundingBox returns a rect covering the bounding box. Rects must have a size, so bounding
+// boxes of horizontal or vertical lines get the same minimal size as a point lookup.
 func newRectFromBoundingBox(boundingBox geometry.BoundingBox) (*rtreego.Rect, error) {
-	return rtreego.NewRect(
-		rtreego.Point{boundingBox.MinX, boundingBox.MinY},
-		[]float64{boundingBox.MaxX - boundingBox.MinX, boundingBox.MaxY - boundingBox.MinY},
-	)
+	lengths := []float64{boundingBox.MaxX - boundingBox.MinX, boundingBox.MaxY - boundingBox.MinY}
+	for i := range lengths {
+		if lengths[i] == 0 {
+			lengths[i] = 0.00001
+		}
+	}
+
+	return rtreego.NewRect(rtreego.Point{boundingBox.MinX, boundingBox.MinY}, lengths)
 }
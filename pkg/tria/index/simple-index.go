@@ -2,6 +2,7 @@ package index
 
 import (
 	"errors"
+	"fmt"
 	"sync"
 
 	"github.com/eesrc/geo/pkg/model"
@@ -63,6 +64,17 @@ func (store *SimpleIndex) GetShapeByName(shapeName string) (geometry.Shape, erro
 	return &geometry.Circle{}, errors.New("No shape found with name " + shapeName)
 }
 
+// GetShapeByID ...
+func (store *SimpleIndex) GetShapeByID(shapeID int64) (geometry.Shape, error) {
+	for _, shape := range store.Shapes {
+		if shape.GetID() == shapeID {
+			return shape, nil
+		}
+	}
+
+	return &geometry.Circle{}, fmt.Errorf("No shape found with ID %d", shapeID)
+}
+
 // RemoveShapeByName ...
 func (store *SimpleIndex) RemoveShapeByName(shapeName string) (geometry.Shape, error) {
 	for i, shape := range store.Shapes {
@@ -94,6 +106,20 @@ func (store *SimpleIndex) FindShapesWhichContainsPoint(point geometry.Point) []g
 	return matchingShapes
 }
 
+// FindShapesWhichIntersectsBoundingBox ...
+func (store *SimpleIndex) FindShapesWhichIntersectsBoundingBox(boundingBox geometry.BoundingBox) []geometry.Shape {
+	var matchingShapes []geometry.Shape
+
+	for _, shape := range store.Shapes {
+		shapeBoundingBox := shape.GetBoundingBox()
+		if shapeBoundingBox.BoundingBoxIntersects(&boundingBox) {
+			matchingShapes = append(matchingShapes, shape)
+		}
+	}
+
+	return matchingShapes
+}
+
 // FindShapesWhichContainsShape ...
 func (store *SimpleIndex) FindShapesWhichContainsShape(shape geometry.Shape) []geometry.Shape {
 	var matchingShapes []geometry.Shape
@@ -2,6 +2,8 @@ package triangulation
 
 import (
 	"errors"
+	"math"
+	"sort"
 
 	"github.com/eesrc/geo/pkg/tria/geometry"
 )
@@ -43,8 +45,9 @@ func isEar(earCandidate *geometry.PointElement) bool {
 
 	// Search through all points to see if they exist within triangle A, B, C. If yes, it's not an ear.
 	// Limit iteration on previous element as we've looped through all elements if satisfied.
+	// Points duplicated by a hole bridge are skipped when they are on a corner of the triangle.
 	for verticePoint != earCandidate.Prev {
-		if geometry.PointInsideTriangle(&verticePoint.Point, &a, &b, &c) {
+		if !isCorner(&verticePoint.Point, &a, &b, &c) && geometry.PointInsideTriangle(&verticePoint.Point, &a, &b, &c) {
 			return false
 		}
 		verticePoint = verticePoint.Next
@@ -53,6 +56,11 @@ func isEar(earCandidate *geometry.PointElement) bool {
 	return true
 }
 
+// isCorner checks if the point is one of the corners of the triangle
+func isCorner(p, a, b, c *geometry.Point) bool {
+	return p.Equal(a) || p.Equal(b) || p.Equal(c)
+}
+
 // GetEars Returns polygon ears by using the ear cutting algorithm (n^2)
 func GetEars(polygon []geometry.Point) ([][]geometry.Point, error) {
 	polygonSize := len(polygon)
@@ -133,3 +141,133 @@ func TriangulateByEarCut(polygon []geometry.Point) ([][]geometry.Point, error) {
 
 	return GetEars(normalizedPoints)
 }
+
+// TriangulateByEarCutWithHoles Triangulates a polygon with holes returning a list of triangles of Point2d.
+// The holes are bridged into the polygon before it is triangulated so no triangle covers a hole.
+func TriangulateByEarCutWithHoles(polygon []geometry.Point, holes [][]geometry.Point) ([][]geometry.Point, error) {
+	if len(holes) == 0 {
+		return TriangulateByEarCut(polygon)
+	}
+
+	bridgedPolygon := geometry.NormalizePoints(polygon)
+
+	// The outer polygon is counter clockwise, so the holes must be clockwise
+	normalizedHoles := make([][]geometry.Point, 0, len(holes))
+	for _, hole := range holes {
+		normalizedHole := geometry.NormalizePoints(hole)
+		if len(normalizedHole) < 3 {
+			continue
+		}
+
+		for i, j := 0, len(normalizedHole)-1; i < j; i, j = i+1, j-1 {
+			normalizedHole[i], normalizedHole[j] = normalizedHole[j], normalizedHole[i]
+		}
+
+		normalizedHoles = append(normalizedHoles, normalizedHole)
+	}
+
+	// Bridge the holes from right to left so a hole can be bridged through the holes already bridged
+	sort.Slice(normalizedHoles, func(i, j int) bool {
+		return normalizedHoles[i][rightmostPoint(normalizedHoles[i])].X > normalizedHoles[j][rightmostPoint(normalizedHoles[j])].X
+	})
+
+	for _, hole := range normalizedHoles {
+		var err error
+		bridgedPolygon, err = bridgeHole(bridgedPolygon, hole)
+		if err != nil {
+			return [][]geometry.Point{}, err
+		}
+	}
+
+	return GetEars(bridgedPolygon)
+}
+
+// bridgeHole joins a clockwise hole with a counter clockwise polygon by adding a bridge from the
+// rightmost point of the hole to a point on the polygon visible from it. The points at each end of
+// the bridge are duplicated in the returned polygon.
+func bridgeHole(polygon, hole []geometry.Point) ([]geometry.Point, error) {
+	holeIndex := rightmostPoint(hole)
+	m := hole[holeIndex]
+
+	// Cast a ray from m towards positive X and find the closest edge it hits
+	edgeIndex := -1
+	intersectionX := math.Inf(1)
+	for i := range polygon {
+		a, b := polygon[i], polygon[(i+1)%len(polygon)]
+		if a.Y == b.Y || math.Min(a.Y, b.Y) > m.Y || math.Max(a.Y, b.Y) < m.Y {
+			continue
+		}
+
+		x := a.X + (m.Y-a.Y)*(b.X-a.X)/(b.Y-a.Y)
+		if x >= m.X && x < intersectionX {
+			intersectionX = x
+			edgeIndex = i
+		}
+	}
+
+	if edgeIndex < 0 {
+		return polygon, errors.New("Hole is not inside polygon")
+	}
+
+	// The end of the edge furthest to the right is a bridge candidate
+	polygonIndex := edgeIndex
+	if polygon[(edgeIndex+1)%len(polygon)].X > polygon[edgeIndex].X {
+		polygonIndex = (edgeIndex + 1) % len(polygon)
+	}
+
+	intersection := geometry.Point{X: intersectionX, Y: m.Y}
+	if !intersection.Equal(&polygon[edgeIndex]) && !intersection.Equal(&polygon[(edgeIndex+1)%len(polygon)]) {
+		// Reflex points inside the triangle of m, the intersection and the candidate can block the
+		// view to the candidate. Pick the one with the smallest angle to the ray instead.
+		candidate := polygon[polygonIndex]
+		bestTangent := math.Inf(1)
+
+		for i := range polygon {
+			p := polygon[i]
+			if i == polygonIndex || p.X < m.X || !pointInsideTriangle(&p, &m, &intersection, &candidate) {
+				continue
+			}
+
+			if geometry.IsConvex(polygon[(i+len(polygon)-1)%len(polygon)], p, polygon[(i+1)%len(polygon)]) {
+				continue
+			}
+
+			tangent := math.Abs(p.Y-m.Y) / (p.X - m.X)
+			if tangent < bestTangent || tangent == bestTangent && p.X < polygon[polygonIndex].X {
+				bestTangent = tangent
+				polygonIndex = i
+			}
+		}
+	} else if intersection.Equal(&polygon[(edgeIndex+1)%len(polygon)]) {
+		polygonIndex = (edgeIndex + 1) % len(polygon)
+	} else {
+		polygonIndex = edgeIndex
+	}
+
+	bridgedPolygon := make([]geometry.Point, 0, len(polygon)+len(hole)+2)
+	bridgedPolygon = append(bridgedPolygon, polygon[:polygonIndex+1]...)
+	bridgedPolygon = append(bridgedPolygon, hole[holeIndex:]...)
+	bridgedPolygon = append(bridgedPolygon, hole[:holeIndex+1]...)
+	bridgedPolygon = append(bridgedPolygon, polygon[polygonIndex:]...)
+
+	return bridgedPolygon, nil
+}
+
+// rightmostPoint returns the index of the point with the largest X
+func rightmostPoint(points []geometry.Point) int {
+	rightmost := 0
+	for i := range points {
+		if points[i].X > points[rightmost].X {
+			rightmost = i
+		}
+	}
+	return rightmost
+}
+
+// pointInsideTriangle checks if the point is inside the triangle regardless of the triangle orientation
+func pointInsideTriangle(p, a, b, c *geometry.Point) bool {
+	if geometry.IsClockwise([]geometry.Point{*a, *b, *c}) {
+		return geometry.PointInsideTriangle(p, a, c, b)
+	}
+	return geometry.PointInsideTriangle(p, a, b, c)
+}
@@ -1,6 +1,7 @@
 package triangulation
 
 import (
+	"math"
 	"reflect"
 	"testing"
 
@@ -93,6 +94,80 @@ func TestTriangulateByEarCut(t *testing.T) {
 	}
 }
 
+func TestTriangulateByEarCutWithHoles(t *testing.T) {
+	square := []geometry.Point{
+		geometry.Point{X: 0, Y: 0},
+		geometry.Point{X: 10, Y: 0},
+		geometry.Point{X: 10, Y: 10},
+		geometry.Point{X: 0, Y: 10},
+	}
+	centerHole := []geometry.Point{
+		geometry.Point{X: 4, Y: 4},
+		geometry.Point{X: 6, Y: 4},
+		geometry.Point{X: 6, Y: 6},
+		geometry.Point{X: 4, Y: 6},
+		geometry.Point{X: 4, Y: 4},
+	}
+	// Clockwise and in line with the center hole to force a bridge through it
+	leftHole := []geometry.Point{
+		geometry.Point{X: 1, Y: 4},
+		geometry.Point{X: 1, Y: 6},
+		geometry.Point{X: 3, Y: 6},
+		geometry.Point{X: 3, Y: 4},
+	}
+
+	tests := []struct {
+		name          string
+		holes         [][]geometry.Point
+		wantArea      float64
+		insidePoints  []geometry.Point
+		outsidePoints []geometry.Point
+	}{
+		{
+			name:          "Should cut a single hole out of a square",
+			holes:         [][]geometry.Point{centerHole},
+			wantArea:      96,
+			insidePoints:  []geometry.Point{{X: 1, Y: 1}, {X: 8, Y: 5}, {X: 5, Y: 8}},
+			outsidePoints: []geometry.Point{{X: 5, Y: 5}, {X: 4.5, Y: 5.5}},
+		},
+		{
+			name:          "Should cut several holes out of a square",
+			holes:         [][]geometry.Point{leftHole, centerHole},
+			wantArea:      92,
+			insidePoints:  []geometry.Point{{X: 0.5, Y: 5}, {X: 3.5, Y: 5}, {X: 8, Y: 5}},
+			outsidePoints: []geometry.Point{{X: 5, Y: 5}, {X: 2, Y: 5}, {X: 1.5, Y: 4.5}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			triangles, err := TriangulateByEarCutWithHoles(square, tt.holes)
+			if err != nil {
+				t.Fatalf("TriangulateByEarCutWithHoles() error = %v", err)
+			}
+
+			area := 0.0
+			for _, triangle := range triangles {
+				area += geometry.PolygonArea(triangle)
+			}
+			if math.Abs(area-tt.wantArea) > 1e-9 {
+				t.Errorf("TriangulateByEarCutWithHoles() area = %v, want %v", area, tt.wantArea)
+			}
+
+			polygon := geometry.Polygon{Triangles: triangles}
+			for _, point := range tt.insidePoints {
+				if !polygon.PointInside(&point) {
+					t.Errorf("PointInside(%v) = false, want true", point)
+				}
+			}
+			for _, point := range tt.outsidePoints {
+				if polygon.PointInside(&point) {
+					t.Errorf("PointInside(%v) = true, want false", point)
+				}
+			}
+		})
+	}
+}
+
 func BenchmarkTriangulateByEarCut(b *testing.B) {
 	poly := []geometry.Point{
 		geometry.Point{X: 0, Y: 0},
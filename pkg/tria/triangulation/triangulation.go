@@ -13,18 +13,35 @@ func TriangulatePoints(points []geometry.Point) ([][]geometry.Point, error) {
 	return TriangulateByEarCut(points)
 }
 
+// TriangulatePointsWithHoles initiates a triangulation based on a polygon with holes
+// Returns a double dimensioned array consisting of triangles which doesn't cover the holes
+func TriangulatePointsWithHoles(points []geometry.Point, holes [][]geometry.Point) ([][]geometry.Point, error) {
+	return TriangulateByEarCutWithHoles(points, holes)
+}
+
 // NewTriangulatedPolygonFromPoints creates a fully triangulated Polygon from a list of points
 func NewTriangulatedPolygonFromPoints(points []geometry.Point) (geometry.Polygon, error) {
-	triangles, err := TriangulatePoints(points)
+	return NewTriangulatedPolygonWithHolesFromPoints(points, nil)
+}
+
+// NewTriangulatedPolygonWithHolesFromPoints creates a fully triangulated Polygon from a list of points
+// and a list of holes cut out of the polygon
+func NewTriangulatedPolygonWithHolesFromPoints(points []geometry.Point, holes [][]geometry.Point) (geometry.Polygon, error) {
+	triangles, err := TriangulatePointsWithHoles(points, holes)
 
 	if err != nil {
 		return geometry.Polygon{}, err
 	}
 
+	if len(holes) == 0 {
+		holes = nil
+	}
+
 	return geometry.Polygon{
 		Name:        "Unnamed",
 		Description: "No description",
 		AlphaShape:  points,
+		Holes:       holes,
 		Triangles:   triangles,
 		BoundingBox: geometry.CalculateBoundingBox(points),
 	}, nil
@@ -67,21 +84,74 @@ func TriangulateGeoJSONFeatureToShape(feature *geojson.Feature, mapProjection gj
 	var shape geometry.Shape
 
 	name := gj.GetFeatureName(feature)
-	pointList := gj.TransformFromGeoJSONCoordinatesToPoints(feature.Geometry, mapProjection)
+	polygonList := gj.TransformFromGeoJSONCoordinatesToPoints(feature.Geometry, mapProjection)
+
+	// A line string is buffered to a corridor. We set the width to a default 30m, with the option of
+	// overloading with a width property in metres. A line string with the tripwire property set is
+	// kept as a line to be crossed instead.
+	if feature.Geometry != nil && feature.Geometry.IsLineString() {
+		if len(polygonList) == 0 || len(polygonList[0][0]) == 0 {
+			return shape, nil
+		}
+
+		if feature.PropertyMustBool("tripwire", false) {
+			tripwire := geometry.NewTripwireFromPoints(polygonList[0][0])
+			tripwire.Name = name
+			tripwire.SetProperties(feature.Properties)
+
+			return &tripwire, nil
+		}
+
+		corridor := geometry.NewCorridorFromPoints(polygonList[0][0], feature.PropertyMustFloat64("width", 30.0))
+		corridor.Name = name
+		corridor.SetProperties(feature.Properties)
+
+		return &corridor, nil
+	}
+
+	// Every polygon of a multi polygon is triangulated and kept together as a single shape
+	if feature.Geometry != nil && feature.Geometry.IsMultiPolygon() {
+		var polygons []geometry.Polygon
+
+		for _, rings := range polygonList {
+			if len(rings) == 0 {
+				continue
+			}
+
+			polygon, err := NewTriangulatedPolygonWithHolesFromPoints(rings[0], rings[1:])
+			if err != nil {
+				return shape, err
+			}
+			polygon.Name = name
+
+			polygons = append(polygons, polygon)
+		}
+
+		multiPolygon := geometry.NewMultiPolygonFromPolygons(polygons)
+		multiPolygon.Name = name
+		multiPolygon.SetProperties(feature.Properties)
+
+		return &multiPolygon, nil
+	}
+
+	for _, rings := range polygonList {
+		if len(rings) == 0 {
+			continue
+		}
+		points := rings[0]
 
-	for _, points := range pointList {
 		// If the length is 1, it's a single point. Single points are not really supported, but we make a circle.
 		if len(points) == 1 {
-			// We set the radius to a default 30m, with the option of overloading with a property in the geometry property
+			// We set the radius to a default 30m, with the option of overloading with a radius property in metres
 			circle := geometry.Circle{
 				Origo:  points[0],
-				Radius: feature.PropertyMustFloat64("radius", 30.0/111111.0),
+				Radius: feature.PropertyMustFloat64("radius", 30.0),
 				Name:   name,
 			}
 
 			shape = &circle
 		} else {
-			polygon, err := NewTriangulatedPolygonFromPoints(points)
+			polygon, err := NewTriangulatedPolygonWithHolesFromPoints(points, rings[1:])
 			polygon.Name = name
 
 			if err != nil {
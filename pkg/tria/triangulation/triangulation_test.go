@@ -1,9 +1,12 @@
 package triangulation
 
 import (
+	"reflect"
 	"testing"
 
 	"github.com/eesrc/geo/pkg/tria/geometry"
+	"github.com/eesrc/geo/pkg/tria/gj"
+	geojson "github.com/paulmach/go.geojson"
 )
 
 func TestGeoPolygon_PointInside(t *testing.T) {
@@ -73,6 +76,139 @@ func TestGeoPolygon_PointInside(t *testing.T) {
 	}
 }
 
+func TestTriangulateGeoJSONFeatureToShapeWithHole(t *testing.T) {
+	feature := geojson.NewPolygonFeature([][][]float64{
+		{{0, 0}, {10, 0}, {10, 10}, {0, 10}, {0, 0}},
+		{{4, 4}, {4, 6}, {6, 6}, {6, 4}, {4, 4}},
+	})
+
+	shape, err := TriangulateGeoJSONFeatureToShape(feature, gj.WGS84Projection)
+	if err != nil {
+		t.Fatalf("TriangulateGeoJSONFeatureToShape() error = %v", err)
+	}
+
+	polygon, ok := shape.(*geometry.Polygon)
+	if !ok {
+		t.Fatalf("TriangulateGeoJSONFeatureToShape() = %T, want *geometry.Polygon", shape)
+	}
+
+	if len(polygon.AlphaShape) != 5 || len(polygon.Holes) != 1 {
+		t.Errorf("TriangulateGeoJSONFeatureToShape() has %d outer points and %d holes, want 5 and 1", len(polygon.AlphaShape), len(polygon.Holes))
+	}
+
+	if !polygon.PointInside(&geometry.Point{X: 2, Y: 5}) {
+		t.Errorf("geometry.Polygon.PointInside() = false outside the hole, want true")
+	}
+
+	if polygon.PointInside(&geometry.Point{X: 5, Y: 5}) {
+		t.Errorf("geometry.Polygon.PointInside() = true inside the hole, want false")
+	}
+}
+
+func TestTriangulateGeoJSONFeatureToShapeWithMultiPolygon(t *testing.T) {
+	coordinates := [][][][]float64{
+		{{{0, 0}, {10, 0}, {10, 10}, {0, 10}, {0, 0}}},
+		{{{20, 0}, {30, 0}, {30, 10}, {20, 10}, {20, 0}}, {{24, 4}, {24, 6}, {26, 6}, {26, 4}, {24, 4}}},
+	}
+	feature := geojson.NewMultiPolygonFeature(coordinates...)
+	feature.SetProperty("name", "Municipality")
+
+	shape, err := TriangulateGeoJSONFeatureToShape(feature, gj.WGS84Projection)
+	if err != nil {
+		t.Fatalf("TriangulateGeoJSONFeatureToShape() error = %v", err)
+	}
+
+	multiPolygon, ok := shape.(*geometry.MultiPolygon)
+	if !ok {
+		t.Fatalf("TriangulateGeoJSONFeatureToShape() = %T, want *geometry.MultiPolygon", shape)
+	}
+
+	if multiPolygon.GetName() != "Municipality" || len(multiPolygon.Polygons) != 2 {
+		t.Errorf("TriangulateGeoJSONFeatureToShape() = %q with %d polygons, want %q with 2", multiPolygon.GetName(), len(multiPolygon.Polygons), "Municipality")
+	}
+
+	for _, tt := range []struct {
+		point geometry.Point
+		want  bool
+	}{
+		{point: geometry.Point{X: 5, Y: 5}, want: true},
+		{point: geometry.Point{X: 22, Y: 5}, want: true},
+		{point: geometry.Point{X: 15, Y: 5}, want: false},
+		{point: geometry.Point{X: 25, Y: 5}, want: false},
+	} {
+		if got := multiPolygon.PointInside(&tt.point); got != tt.want {
+			t.Errorf("geometry.MultiPolygon.PointInside(%v) = %v, want %v", tt.point, got, tt.want)
+		}
+	}
+
+	features := gj.GetFeaturesFromShape(multiPolygon, false)
+	if len(features) != 1 || !reflect.DeepEqual(features[0].Geometry.MultiPolygon, coordinates) {
+		t.Errorf("gj.GetFeaturesFromShape() = %v, want a single feature with %v", features, coordinates)
+	}
+}
+
+func TestTriangulateGeoJSONFeatureToShapeWithLineString(t *testing.T) {
+	line := [][]float64{{10.40, 63.40}, {10.42, 63.40}, {10.42, 63.41}}
+	feature := geojson.NewLineStringFeature(line)
+	feature.SetProperty("name", "Ferry route")
+	feature.SetProperty("width", 100.0)
+
+	shape, err := TriangulateGeoJSONFeatureToShape(feature, gj.WGS84Projection)
+	if err != nil {
+		t.Fatalf("TriangulateGeoJSONFeatureToShape() error = %v", err)
+	}
+
+	corridor, ok := shape.(*geometry.Corridor)
+	if !ok {
+		t.Fatalf("TriangulateGeoJSONFeatureToShape() = %T, want *geometry.Corridor", shape)
+	}
+
+	if corridor.GetName() != "Ferry route" || corridor.Width != 100 || len(corridor.Line) != 3 {
+		t.Errorf("TriangulateGeoJSONFeatureToShape() = %q with width %v and %d points, want %q with width 100 and 3 points", corridor.GetName(), corridor.Width, len(corridor.Line), "Ferry route")
+	}
+
+	features := gj.GetFeaturesFromShape(corridor, false)
+	if len(features) != 1 || !reflect.DeepEqual(features[0].Geometry.LineString, line) || features[0].Properties["width"] != 100.0 {
+		t.Errorf("gj.GetFeaturesFromShape() = %v, want a single line string feature with width 100", features)
+	}
+
+	defaultFeature := geojson.NewLineStringFeature(line)
+	defaultShape, err := TriangulateGeoJSONFeatureToShape(defaultFeature, gj.WGS84Projection)
+	if err != nil {
+		t.Fatalf("TriangulateGeoJSONFeatureToShape() error = %v", err)
+	}
+
+	if width := defaultShape.(*geometry.Corridor).Width; width != 30 {
+		t.Errorf("TriangulateGeoJSONFeatureToShape() width = %v without a width property, want 30", width)
+	}
+}
+
+func TestTriangulateGeoJSONFeatureToShapeWithTripwire(t *testing.T) {
+	line := [][]float64{{10.40, 63.40}, {10.40, 63.41}}
+	feature := geojson.NewLineStringFeature(line)
+	feature.SetProperty("name", "Gate")
+	feature.SetProperty("tripwire", true)
+
+	shape, err := TriangulateGeoJSONFeatureToShape(feature, gj.WGS84Projection)
+	if err != nil {
+		t.Fatalf("TriangulateGeoJSONFeatureToShape() error = %v", err)
+	}
+
+	tripwire, ok := shape.(*geometry.Tripwire)
+	if !ok {
+		t.Fatalf("TriangulateGeoJSONFeatureToShape() = %T, want *geometry.Tripwire", shape)
+	}
+
+	if tripwire.GetName() != "Gate" || len(tripwire.Line) != 2 {
+		t.Errorf("TriangulateGeoJSONFeatureToShape() = %q with %d points, want %q with 2 points", tripwire.GetName(), len(tripwire.Line), "Gate")
+	}
+
+	features := gj.GetFeaturesFromShape(tripwire, false)
+	if len(features) != 1 || !reflect.DeepEqual(features[0].Geometry.LineString, line) || features[0].Properties["tripwire"] != true {
+		t.Errorf("gj.GetFeaturesFromShape() = %v, want a single line string feature with tripwire set", features)
+	}
+}
+
 func BenchmarkPointInside(b *testing.B) {
 	geoPolygon, _ := NewTriangulatedPolygonFromPoints([]geometry.Point{
 		geometry.Point{X: 0, Y: 0},
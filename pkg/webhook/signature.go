@@ -0,0 +1,120 @@
+// Package webhook contains helpers for receivers of geo webhook deliveries. Each delivery is signed with
+// the subscription secret using HMAC-SHA256 over the timestamp and the request body. Receivers should
+// verify the signature and reject requests with a timestamp outside their tolerance to prevent replays.
+package webhook
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// SignatureHeader is the header containing the signature of the request
+	SignatureHeader = "X-Geo-Signature"
+	// TimestampHeader is the header containing the unix timestamp in seconds of when the request was signed
+	TimestampHeader = "X-Geo-Timestamp"
+	// DefaultTolerance is the recommended max age of a signed request
+	DefaultTolerance = 5 * time.Minute
+
+	signaturePrefix = "sha256="
+)
+
+var (
+	// ErrMissingSignature is returned when the request lacks a signature or timestamp
+	ErrMissingSignature = errors.New("Missing webhook signature or timestamp")
+	// ErrInvalidSignature is returned when the signature doesn't match the body
+	ErrInvalidSignature = errors.New("Invalid webhook signature")
+	// ErrExpiredTimestamp is returned when the timestamp is outside the tolerance
+	ErrExpiredTimestamp = errors.New("Webhook timestamp is outside the tolerance")
+)
+
+// NewSecret returns a new random hex encoded secret
+func NewSecret() (string, error) {
+	buf := make([]byte, 32)
+	n, err := rand.Read(buf)
+	if err == nil && n != len(buf) {
+		return "", fmt.Errorf("unable to generate secret %d bytes long. Only got %d bytes", len(buf), n)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(buf), nil
+}
+
+// Sign returns the signature header value of the body signed at the given timestamp
+func Sign(secret string, timestamp time.Time, body []byte) string {
+	return signaturePrefix + hex.EncodeToString(computeMAC(secret, strconv.FormatInt(timestamp.Unix(), 10), body))
+}
+
+// Verify verifies the signature and timestamp header values against the body. The timestamp must be
+// within the tolerance of the current time. A tolerance of 0 disables the timestamp check.
+func Verify(secret string, signature string, timestamp string, body []byte, tolerance time.Duration) error {
+	if signature == "" || timestamp == "" {
+		return ErrMissingSignature
+	}
+
+	unixTimestamp, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ErrMissingSignature
+	}
+
+	if tolerance > 0 {
+		age := time.Since(time.Unix(unixTimestamp, 0))
+		if age > tolerance || age < -tolerance {
+			return ErrExpiredTimestamp
+		}
+	}
+
+	if !strings.HasPrefix(signature, signaturePrefix) {
+		return ErrInvalidSignature
+	}
+
+	mac, err := hex.DecodeString(strings.TrimPrefix(signature, signaturePrefix))
+	if err != nil {
+		return ErrInvalidSignature
+	}
+
+	if !hmac.Equal(mac, computeMAC(secret, timestamp, body)) {
+		return ErrInvalidSignature
+	}
+
+	return nil
+}
+
+// VerifyRequest reads the body of the request and verifies it using the signature headers. The body
+// is returned and the request body is replaced so it can be read again.
+func VerifyRequest(request *http.Request, secret string, tolerance time.Duration) ([]byte, error) {
+	body, err := ioutil.ReadAll(request.Body)
+	if err != nil {
+		return nil, err
+	}
+	request.Body.Close()
+	request.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+	err = Verify(secret, request.Header.Get(SignatureHeader), request.Header.Get(TimestampHeader), body, tolerance)
+	if err != nil {
+		return nil, err
+	}
+
+	return body, nil
+}
+
+func computeMAC(secret string, timestamp string, body []byte) []byte {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+
+	return mac.Sum(nil)
+}
@@ -0,0 +1,69 @@
+package webhook
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSignAndVerify(t *testing.T) {
+	body := []byte(`[{"subscriptionId":1}]`)
+	now := time.Now()
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+
+	signature := Sign("secret", now, body)
+
+	assert.Nil(t, Verify("secret", signature, timestamp, body, DefaultTolerance))
+	assert.Equal(t, ErrInvalidSignature, Verify("other secret", signature, timestamp, body, DefaultTolerance))
+	assert.Equal(t, ErrInvalidSignature, Verify("secret", signature, timestamp, []byte(`[]`), DefaultTolerance))
+	assert.Equal(t, ErrInvalidSignature, Verify("secret", "sha256=zz", timestamp, body, DefaultTolerance))
+	assert.Equal(t, ErrMissingSignature, Verify("secret", "", timestamp, body, DefaultTolerance))
+	assert.Equal(t, ErrMissingSignature, Verify("secret", signature, "", body, DefaultTolerance))
+
+	// The timestamp is part of the signature
+	otherTimestamp := strconv.FormatInt(now.Unix()+1, 10)
+	assert.Equal(t, ErrInvalidSignature, Verify("secret", signature, otherTimestamp, body, DefaultTolerance))
+}
+
+func TestVerifyRejectsReplays(t *testing.T) {
+	body := []byte(`[]`)
+	signedAt := time.Now().Add(-10 * time.Minute)
+	timestamp := strconv.FormatInt(signedAt.Unix(), 10)
+	signature := Sign("secret", signedAt, body)
+
+	assert.Equal(t, ErrExpiredTimestamp, Verify("secret", signature, timestamp, body, DefaultTolerance))
+	assert.Nil(t, Verify("secret", signature, timestamp, body, 0))
+}
+
+func TestVerifyRequest(t *testing.T) {
+	body := []byte(`[{"subscriptionId":1}]`)
+	now := time.Now()
+
+	request := httptest.NewRequest("POST", "/hook", bytes.NewReader(body))
+	request.Header.Set(SignatureHeader, Sign("secret", now, body))
+	request.Header.Set(TimestampHeader, strconv.FormatInt(now.Unix(), 10))
+
+	verifiedBody, err := VerifyRequest(request, "secret", DefaultTolerance)
+	assert.Nil(t, err)
+	assert.Equal(t, body, verifiedBody)
+
+	// The body can still be read after verification
+	rereadBody, err := ioutil.ReadAll(request.Body)
+	assert.Nil(t, err)
+	assert.Equal(t, body, rereadBody)
+}
+
+func TestNewSecret(t *testing.T) {
+	secret, err := NewSecret()
+	assert.Nil(t, err)
+	assert.Len(t, secret, 64)
+
+	otherSecret, err := NewSecret()
+	assert.Nil(t, err)
+	assert.NotEqual(t, secret, otherSecret)
+}